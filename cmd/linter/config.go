@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codevault-llc/php-lint/internal/linter"
+)
+
+// runConfig implements php-lint config COMMAND. Its only command, schema,
+// prints the JSON Schema of the config files for editors to use. It
+// returns the exit status.
+func runConfig(args []string) int {
+	if len(args) != 1 || args[0] != "schema" {
+		fmt.Fprintln(os.Stderr, "Usage: php-lint config schema")
+		fmt.Fprintln(os.Stderr, "Prints the JSON Schema of the config files, with the built-in rules and their options.")
+		return 2
+	}
+	schema, err := linter.Schema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(schema))
+	return 0
+}
@@ -5,8 +5,9 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/codevault-llc/php-lint/internal/config"
 	"github.com/codevault-llc/php-lint/internal/linter"
-	"github.com/codevault-llc/php-lint/internal/stubs"
+	"github.com/codevault-llc/php-lint/internal/reporter"
 	"github.com/codevault-llc/php-lint/internal/workspace"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,8 +21,15 @@ func main() {
 	logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	zerolog.SetGlobalLevel(zerolog.DebugLevel)
 
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		os.Exit(runSearch(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:]))
+	}
+
 	var err error
-	linterInstance, err = linter.New("config.json", logger)
+	linterInstance, err = linter.New(config.Find("."), logger)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to create linter")
 	}
@@ -30,6 +38,8 @@ func main() {
 	if len(os.Args) > 1 {
 		if os.Args[1] == "--help" || os.Args[1] == "-h" {
 			fmt.Println("Usage: php-lint [options] [paths...]")
+			fmt.Println("       php-lint search PATTERN [paths...]")
+			fmt.Println("       php-lint config schema")
 			fmt.Println("Options:")
 			fmt.Println("  --help, -h       Show this help message")
 			return
@@ -48,8 +58,7 @@ func main() {
 	logger.Info().Str("path", absPath).Msg("Starting linting for path")
 
 	// Workspace -- Init
-	stubsTable := stubs.NewSymbolTable()
-	workspaceInstance = workspace.New(absPath, stubsTable, logger)
+	workspaceInstance = workspace.New(absPath, linterInstance.Stubs(), linterInstance.PHPVersion(), logger)
 	workspaceInstance.Build()
 
 	// Run linter
@@ -57,7 +66,12 @@ func main() {
 
 	logger.Info().Int("files", len(phpFiles)).Msg("Starting linting process")
 
-	linterInstance.LintFiles(phpFiles, workspaceInstance.GetSymbolTable())
+	results := linterInstance.LintFiles(phpFiles, workspaceInstance.GetSymbolTable())
 
-	//reporter.Render(results)
+	linterInstance.Close()
+
+	reporter.Render(results)
+	if reporter.HasErrors(results) {
+		os.Exit(1)
+	}
 }
\ No newline at end of file
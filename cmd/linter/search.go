@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/pattern"
+	"github.com/codevault-llc/php-lint/internal/resolver"
+)
+
+// runSearch implements php-lint search PATTERN [paths...], which prints
+// the code matching a pattern like grep to stdout, and errors to stderr.
+// It returns the exit status: 0 if something matched, 1 if nothing did and
+// 2 on errors.
+func runSearch(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Fprintln(stderr, "Usage: php-lint search PATTERN [paths...]")
+		fmt.Fprintln(stderr, "Prints the code matching PATTERN in the PHP files under paths, by default the current directory.")
+		return 2
+	}
+	p, err := pattern.Compile(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 1
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named on the command line are searched whatever their
+			// extension.
+			if d.IsDir() || path != root && filepath.Ext(path) != ".php" {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if searchFile(stdout, p, path, string(content)) {
+				status = 0
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	return status
+}
+
+// searchFile prints the matches of p in a file to w and reports whether
+// there were any.
+func searchFile(w io.Writer, p *pattern.Pattern, path, content string) bool {
+	program := parser.New(lexer.New(content)).ParseProgram()
+	resolver.Resolve(program)
+	matches := p.FindAll(program)
+	for _, m := range matches {
+		text := content[m.Span.Start.Offset:m.Span.End.Offset]
+		if line, _, more := strings.Cut(text, "\n"); more {
+			text = strings.TrimRight(line, " \t\r") + " ..."
+		}
+		fmt.Fprintf(w, "%s:%d:%d: %s\n", path, m.Span.Start.Line, m.Span.Start.Col, text)
+	}
+	return len(matches) > 0
+}
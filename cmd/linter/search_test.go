@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.php":       "<?php\neval($code);\n$x = 1; eval('1');\n",
+		"sub/b.php":   "<?php\nif ($a) {\n    eval($b);\n}\n",
+		"notes.txt":   "eval($c);",
+		"script":      "<?php eval($d);",
+		"nothing.php": "<?php echo 1;",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args   []string
+		status int
+		stdout []string
+		stderr string
+	}{
+		{
+			// Only .php files are searched in a directory.
+			[]string{"eval($A)", dir}, 0,
+			[]string{"a.php:2:1: eval($code)", "a.php:3:9: eval('1')", "sub/b.php:3:5: eval($b)"}, "",
+		},
+		{
+			[]string{"eval($A) where $A is literal", dir}, 0,
+			[]string{"a.php:3:9: eval('1')"}, "",
+		},
+		{
+			// Matches over several lines are cut after the first.
+			[]string{"if ($A) { ... }", dir}, 0,
+			[]string{"sub/b.php:2:1: if ($a) { ..."}, "",
+		},
+		{
+			// A file named on the command line is searched whatever its name.
+			[]string{"eval($A)", filepath.Join(dir, "script")}, 0,
+			[]string{"script:1:7: eval($d)"}, "",
+		},
+		{[]string{"exit()", dir}, 1, nil, ""},
+		{[]string{"eval(", dir}, 2, nil, "pattern: syntax error"},
+		{[]string{"eval($A)", filepath.Join(dir, "missing")}, 2, nil, "no such file or directory"},
+		{nil, 2, nil, "Usage: php-lint search PATTERN [paths...]"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if status := runSearch(tt.args, &stdout, &stderr); status != tt.status {
+			t.Errorf("%q: got status %d, want %d", tt.args, status, tt.status)
+		}
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+			if line != "" {
+				lines = append(lines, filepath.ToSlash(strings.TrimPrefix(line, dir+string(filepath.Separator))))
+			}
+		}
+		if strings.Join(lines, "\n") != strings.Join(tt.stdout, "\n") {
+			t.Errorf("%q: got output\n%s\nwant\n%s", tt.args, strings.Join(lines, "\n"), strings.Join(tt.stdout, "\n"))
+		}
+		if !strings.Contains(stderr.String(), tt.stderr) || tt.stderr == "" && stderr.Len() > 0 {
+			t.Errorf("%q: got errors %q, want %q", tt.args, stderr.String(), tt.stderr)
+		}
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"net/url"
 	"os"
 
+	"github.com/codevault-llc/php-lint/internal/config"
+	"github.com/codevault-llc/php-lint/internal/lexer"
 	"github.com/codevault-llc/php-lint/internal/linter"
 	"github.com/codevault-llc/php-lint/internal/stubs"
 	"github.com/codevault-llc/php-lint/internal/workspace"
+	"github.com/codevault-llc/php-lint/pkg/types"
 	"github.com/rs/zerolog"
 	"github.com/tliron/commonlog"
 	"github.com/tliron/glsp"
@@ -15,13 +18,13 @@ import (
 	"github.com/tliron/glsp/server"
 )
 
-
 const lsName = "php-linter"
 
 var version string = "0.0.1"
 var handler protocol.Handler
 
 var linterInstance *linter.Linter
+var linterErr error // Why linterInstance is nil, shown to the client
 var workspaceInstance *workspace.Workspace
 var serverLogger commonlog.Logger
 var logger zerolog.Logger
@@ -30,52 +33,61 @@ func main() {
 	commonlog.Configure(1, nil)
 	serverLogger = commonlog.GetLogger("php-linter")
 
-	var logger zerolog.Logger
-	var err error
-
-	logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
-
-	linterInstance, err = linter.New("config.json", logger)
-	if err != nil {
-		serverLogger.Criticalf("Failed to create linter: %v", err)
-	}
+	// Stdout carries the protocol, so logs must go elsewhere.
+	logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
 
 	handler = protocol.Handler{
-		Initialize:          onInitialize,
-		Initialized:         onInitialized,
-		Shutdown:            onShutdown,
-		TextDocumentDidOpen: onDidOpen,
+		Initialize:            onInitialize,
+		Initialized:           onInitialized,
+		Shutdown:              onShutdown,
+		TextDocumentDidOpen:   onDidOpen,
 		TextDocumentDidChange: onDidChange,
-		SetTrace:            setTrace,
+		SetTrace:              setTrace,
 	}
 
-	server := server.NewServer(&handler, lsName, false)
+	server := server.NewServer(encodingHandler{&handler}, lsName, false)
 	server.RunStdio()
 }
 
 func onInitialize(context *glsp.Context, params *protocol.InitializeParams) (any, error) {
-	if params.RootURI != nil {
+	// The config is the one of the workspace, not of the directory the
+	// editor started the server in.
+	root, ok := workspaceRoot(params)
+	dir := root
+	if !ok {
+		dir = "."
+	}
+	var err error
+	linterInstance, err = linter.New(config.Find(dir), logger)
+	if err != nil {
+		serverLogger.Criticalf("Failed to create linter: %v", err)
+		linterErr = err
+	}
+
+	if ok {
 		logger.Info().Msg("LSP server initialized")
-		uri, err := url.Parse(*params.RootURI)
-		if err == nil {
-			stubsTable := stubs.NewSymbolTable()
-			// You would parse configured stubs here and pass them to the workspace
-			// stubsTable.AddFromPath("/path/to/wordpress-stubs")
-
-			workspaceInstance = workspace.New(uri.Path, stubsTable, logger)
-			
-			go workspaceInstance.Build()
+		stubsTable := stubs.NewSymbolTable()
+		version := lexer.LatestVersion
+		if linterInstance != nil {
+			stubsTable = linterInstance.Stubs()
+			version = linterInstance.PHPVersion()
 		}
+		workspaceInstance = workspace.New(root, stubsTable, version, logger)
+
+		go workspaceInstance.Build()
 	}
 
 	capabilities := handler.CreateServerCapabilities()
 	capabilities.TextDocumentSync = &protocol.TextDocumentSyncOptions{
 		OpenClose: &protocol.True,
-		Change: func() *protocol.TextDocumentSyncKind { kind := protocol.TextDocumentSyncKindFull; return &kind }(),
+		Change:    func() *protocol.TextDocumentSyncKind { kind := protocol.TextDocumentSyncKindFull; return &kind }(),
 	}
 
-	return protocol.InitializeResult{
-		Capabilities: capabilities,
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			ServerCapabilities: capabilities,
+			PositionEncoding:   positionEncoding,
+		},
 		ServerInfo: &protocol.InitializeResultServerInfo{
 			Name:    lsName,
 			Version: &version,
@@ -83,14 +95,47 @@ func onInitialize(context *glsp.Context, params *protocol.InitializeParams) (any
 	}, nil
 }
 
+// workspaceRoot returns the directory of the workspace the client opened:
+// its rootUri, or else its first workspace folder, or else the deprecated
+// rootPath. It reports false if the client opened none.
+func workspaceRoot(params *protocol.InitializeParams) (string, bool) {
+	var uri string
+	switch {
+	case params.RootURI != nil:
+		uri = *params.RootURI
+	case len(params.WorkspaceFolders) > 0:
+		uri = params.WorkspaceFolders[0].URI
+	case params.RootPath != nil && *params.RootPath != "":
+		return *params.RootPath, true
+	default:
+		return "", false
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Path == "" {
+		return "", false
+	}
+	return parsed.Path, true
+}
+
 func onInitialized(ctx *glsp.Context, params *protocol.InitializedParams) error {
 	log.Println("LSP server initialized")
 
+	// Without a linter nothing is linted; the client is told why, e.g. a
+	// mistake in the config.
+	if linterErr != nil {
+		ctx.Notify(protocol.ServerWindowShowMessage, protocol.ShowMessageParams{
+			Type:    protocol.MessageTypeError,
+			Message: "php-lint: " + linterErr.Error(),
+		})
+	}
 	return nil
 }
 
 func onShutdown(ctx *glsp.Context) error {
 	log.Println("Shutting down LSP server...")
+	if linterInstance != nil {
+		linterInstance.Close()
+	}
 
 	protocol.SetTraceValue(protocol.TraceValueOff)
 	return nil
@@ -118,7 +163,10 @@ func lintDocument(ctx *glsp.Context, uri string, text []byte) error {
 		serverLogger.Warning("Workspace not initialized, cannot lint.")
 		return nil
 	}
-	
+	if linterInstance == nil {
+		return nil
+	}
+
 	path, err := url.Parse(uri)
 	if err != nil {
 		return nil
@@ -128,21 +176,21 @@ func lintDocument(ctx *glsp.Context, uri string, text []byte) error {
 	workspaceInstance.UpdateFile(path.Path, text)
 
 	// 2. Lint the file using the complete, up-to-date symbol table from the workspace
-	issues := linterInstance.LintFile(path.Path, text, workspaceInstance.GetSymbolTable())
+	// Excluded files get no diagnostics.
+	var issues []types.Issue
+	if !linterInstance.Excluded(path.Path) {
+		issues = linterInstance.LintFile(path.Path, text, workspaceInstance.GetSymbolTable())
+	}
 
+	positions := newPositionMapper(text, positionEncoding)
 	diagnostics := []protocol.Diagnostic{}
 	for _, issue := range issues {
-		positionLine := protocol.UInteger(issue.Range.Start.Line - 1)
-		positionColumn := protocol.UInteger(issue.Range.Start.Col - 1)
-
 		diagnostics = append(diagnostics, protocol.Diagnostic{
-			Range: protocol.Range{
-				Start: protocol.Position{Line: positionLine, Character: positionColumn},
-				End:   protocol.Position{Line: positionLine, Character: positionColumn},
-			},
-			Severity:  &issue.Severity,
-			Message:   issue.Message,
-			Source:    &issue.Source,
+			Range:    positions.Range(issue.Range),
+			Severity: &issue.Severity,
+			Message:  issue.Message,
+			Code:     &protocol.IntegerOrString{Value: issue.RuleName},
+			Source:   &issue.Source,
 			CodeDescription: &protocol.CodeDescription{
 				HRef: "https://example.com/rules/line-length",
 			},
@@ -161,4 +209,4 @@ func lintDocument(ctx *glsp.Context, uri string, text []byte) error {
 func setTrace(context *glsp.Context, params *protocol.SetTraceParams) error {
 	protocol.SetTraceValue(params.Value)
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestWorkspaceRoot(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name   string
+		params protocol.InitializeParams
+		root   string
+		ok     bool
+	}{
+		{"root uri", protocol.InitializeParams{RootURI: str("file:///home/me/project")}, "/home/me/project", true},
+		{"escaped", protocol.InitializeParams{RootURI: str("file:///home/me/my%20project")}, "/home/me/my project", true},
+		{
+			"root uri first",
+			protocol.InitializeParams{
+				RootURI:          str("file:///a"),
+				WorkspaceFolders: []protocol.WorkspaceFolder{{URI: "file:///b", Name: "b"}},
+			},
+			"/a", true,
+		},
+		{
+			"workspace folder",
+			protocol.InitializeParams{WorkspaceFolders: []protocol.WorkspaceFolder{{URI: "file:///b", Name: "b"}, {URI: "file:///c", Name: "c"}}},
+			"/b", true,
+		},
+		{"root path", protocol.InitializeParams{RootPath: str("/home/me/project")}, "/home/me/project", true},
+		{"none", protocol.InitializeParams{}, "", false},
+		{"empty root path", protocol.InitializeParams{RootPath: str("")}, "", false},
+	}
+	for _, tt := range tests {
+		root, ok := workspaceRoot(&tt.params)
+		if root != tt.root || ok != tt.ok {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, root, ok, tt.root, tt.ok)
+		}
+	}
+}
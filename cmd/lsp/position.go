@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Position encodings defined by LSP 3.17. Clients that do not announce any
+// encoding only understand UTF-16.
+const (
+	positionEncodingUTF8  = "utf-8"
+	positionEncodingUTF16 = "utf-16"
+)
+
+// positionEncoding is the encoding negotiated with the client during
+// initialize. Character offsets in published positions are counted in its
+// code units.
+var positionEncoding = positionEncodingUTF16
+
+// encodingHandler wraps the protocol handler to read the position encodings
+// offered by the client. The 3.16 protocol structs drop the 3.17
+// general.positionEncodings capability, so it is read from the raw params.
+type encodingHandler struct {
+	*protocol.Handler
+}
+
+func (h encodingHandler) Handle(ctx *glsp.Context) (any, bool, bool, error) {
+	if ctx.Method == protocol.MethodInitialize {
+		var params struct {
+			Capabilities struct {
+				General struct {
+					PositionEncodings []string `json:"positionEncodings"`
+				} `json:"general"`
+			} `json:"capabilities"`
+		}
+		if err := json.Unmarshal(ctx.Params, &params); err == nil {
+			positionEncoding = negotiatePositionEncoding(params.Capabilities.General.PositionEncodings)
+		}
+	}
+	return h.Handler.Handle(ctx)
+}
+
+// negotiatePositionEncoding picks UTF-8 when the client offers it, since
+// that matches our byte offsets, and falls back to the mandatory UTF-16.
+func negotiatePositionEncoding(offered []string) string {
+	for _, encoding := range offered {
+		if encoding == positionEncodingUTF8 {
+			return positionEncodingUTF8
+		}
+	}
+	return positionEncodingUTF16
+}
+
+// serverCapabilities adds the 3.17 positionEncoding field to the 3.16
+// server capabilities.
+type serverCapabilities struct {
+	protocol.ServerCapabilities
+	PositionEncoding string `json:"positionEncoding,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities                   `json:"capabilities"`
+	ServerInfo   *protocol.InitializeResultServerInfo `json:"serverInfo,omitempty"`
+}
+
+// positionMapper converts lexer positions, which carry byte offsets, into
+// LSP positions for one version of a document.
+type positionMapper struct {
+	text       []byte
+	lineStarts []int // Byte offset at which each 0-based line starts
+	encoding   string
+}
+
+func newPositionMapper(text []byte, encoding string) *positionMapper {
+	lineStarts := []int{0}
+	for i, b := range text {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &positionMapper{text: text, lineStarts: lineStarts, encoding: encoding}
+}
+
+// Position converts pos to a zero-based line and a character offset counted
+// in code units of the mapper's encoding.
+func (m *positionMapper) Position(pos token.Pos) protocol.Position {
+	line := pos.Line - 1
+	if line < 0 {
+		line = 0
+	}
+	if line >= len(m.lineStarts) {
+		line = len(m.lineStarts) - 1
+	}
+
+	// A position past the end of its line is moved back to the end, before
+	// the line break.
+	start, lineEnd := m.lineStarts[line], len(m.text)
+	if line+1 < len(m.lineStarts) {
+		lineEnd = m.lineStarts[line+1] - 1
+		if lineEnd > start && m.text[lineEnd-1] == '\r' {
+			lineEnd--
+		}
+	}
+	end := pos.Offset
+	if end > lineEnd {
+		end = lineEnd
+	}
+	if end < start {
+		end = start
+	}
+
+	return protocol.Position{
+		Line:      protocol.UInteger(line),
+		Character: protocol.UInteger(m.codeUnits(m.text[start:end])),
+	}
+}
+
+// Range converts a span to an LSP range.
+func (m *positionMapper) Range(span token.Span) protocol.Range {
+	return protocol.Range{Start: m.Position(span.Start), End: m.Position(span.End)}
+}
+
+// codeUnits counts the code units needed to encode b in the mapper's
+// encoding.
+func (m *positionMapper) codeUnits(b []byte) int {
+	if m.encoding == positionEncodingUTF8 {
+		return len(b)
+	}
+
+	units := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		if r >= 0x10000 {
+			units += 2 // Surrogate pair
+		} else {
+			units++
+		}
+	}
+	return units
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	tests := []struct {
+		offered []string
+		want    string
+	}{
+		{nil, positionEncodingUTF16},
+		{[]string{"utf-16"}, positionEncodingUTF16},
+		{[]string{"utf-32", "utf-16"}, positionEncodingUTF16},
+		{[]string{"utf-16", "utf-8"}, positionEncodingUTF8},
+		{[]string{"utf-8"}, positionEncodingUTF8},
+		{[]string{"UTF-8"}, positionEncodingUTF16},
+	}
+	for _, tt := range tests {
+		if got := negotiatePositionEncoding(tt.offered); got != tt.want {
+			t.Errorf("negotiatePositionEncoding(%q) = %s, want %s", tt.offered, got, tt.want)
+		}
+	}
+}
+
+func TestPositionMapper(t *testing.T) {
+	// "é" is two bytes and one UTF-16 code unit, "😀" four bytes and two
+	// code units.
+	text := "<?php\r\n$é = '😀';\nx"
+	tests := []struct {
+		pos         token.Pos
+		utf8, utf16 protocol.Position
+	}{
+		{token.Pos{Line: 1, Col: 1, Offset: 0}, protocol.Position{Line: 0, Character: 0}, protocol.Position{Line: 0, Character: 0}},
+		// The end of a CRLF line is before the '\r'.
+		{token.Pos{Line: 1, Col: 6, Offset: 5}, protocol.Position{Line: 0, Character: 5}, protocol.Position{Line: 0, Character: 5}},
+		// $é = starts the second line; after it, the counts differ.
+		{token.Pos{Line: 2, Col: 1, Offset: 7}, protocol.Position{Line: 1, Character: 0}, protocol.Position{Line: 1, Character: 0}},
+		{token.Pos{Line: 2, Col: 3, Offset: 10}, protocol.Position{Line: 1, Character: 3}, protocol.Position{Line: 1, Character: 2}},
+		{token.Pos{Line: 2, Col: 7, Offset: 14}, protocol.Position{Line: 1, Character: 7}, protocol.Position{Line: 1, Character: 6}},
+		{token.Pos{Line: 2, Col: 8, Offset: 18}, protocol.Position{Line: 1, Character: 11}, protocol.Position{Line: 1, Character: 8}},
+		{token.Pos{Line: 2, Col: 10, Offset: 20}, protocol.Position{Line: 1, Character: 13}, protocol.Position{Line: 1, Character: 10}},
+		// Past the end of a line, the position is at its end.
+		{token.Pos{Line: 1, Col: 20, Offset: 12}, protocol.Position{Line: 0, Character: 5}, protocol.Position{Line: 0, Character: 5}},
+		{token.Pos{Line: 2, Col: 30, Offset: 22}, protocol.Position{Line: 1, Character: 13}, protocol.Position{Line: 1, Character: 10}},
+		// Past the end of the file, at the end of the last line.
+		{token.Pos{Line: 3, Col: 2, Offset: 22}, protocol.Position{Line: 2, Character: 1}, protocol.Position{Line: 2, Character: 1}},
+		{token.Pos{Line: 3, Col: 9, Offset: 90}, protocol.Position{Line: 2, Character: 1}, protocol.Position{Line: 2, Character: 1}},
+		{token.Pos{Line: 7, Col: 1, Offset: 90}, protocol.Position{Line: 2, Character: 1}, protocol.Position{Line: 2, Character: 1}},
+		// Positions without a line, before the start.
+		{token.Pos{}, protocol.Position{Line: 0, Character: 0}, protocol.Position{Line: 0, Character: 0}},
+	}
+	utf8 := newPositionMapper([]byte(text), positionEncodingUTF8)
+	utf16 := newPositionMapper([]byte(text), positionEncodingUTF16)
+	for _, tt := range tests {
+		if got := utf8.Position(tt.pos); got != tt.utf8 {
+			t.Errorf("UTF-8 position of %+v: got %+v, want %+v", tt.pos, got, tt.utf8)
+		}
+		if got := utf16.Position(tt.pos); got != tt.utf16 {
+			t.Errorf("UTF-16 position of %+v: got %+v, want %+v", tt.pos, got, tt.utf16)
+		}
+	}
+
+	span := token.Span{Start: token.Pos{Line: 2, Col: 6, Offset: 13}, End: token.Pos{Line: 2, Col: 9, Offset: 19}}
+	want := protocol.Range{Start: protocol.Position{Line: 1, Character: 5}, End: protocol.Position{Line: 1, Character: 9}}
+	if got := utf16.Range(span); got != want {
+		t.Errorf("UTF-16 range of '😀': got %+v, want %+v", got, want)
+	}
+}
+
+func TestPositionMapperTrailingNewline(t *testing.T) {
+	m := newPositionMapper([]byte("a\n"), positionEncodingUTF16)
+	// The end of file is on the empty line after the last newline.
+	if got := m.Position(token.Pos{Line: 2, Col: 1, Offset: 2}); got != (protocol.Position{Line: 1, Character: 0}) {
+		t.Errorf("end of file: got %+v", got)
+	}
+}
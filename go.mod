@@ -4,9 +4,11 @@ go 1.24.4
 
 require (
 	github.com/fatih/color v1.18.0
+	github.com/pelletier/go-toml/v2 v2.4.3
 	github.com/rs/zerolog v1.34.0
 	github.com/tliron/commonlog v0.2.20
 	github.com/tliron/glsp v0.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,343 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// ClassDecl represents a class declaration, e.g.
+// final class Foo extends Bar implements Baz { ... }.
+type ClassDecl struct {
+	Base
+	Token      token.Token // The 'class' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Modifiers  Modifiers       // abstract, final, readonly
+	Name       *Identifier
+	Extends    *Identifier // nil if the class has no parent
+	Implements []*Identifier
+	Members    []Stmt
+}
+
+func (cd *ClassDecl) isStmt() {}
+func (cd *ClassDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, cd.Attributes)
+	if cd.Modifiers != 0 {
+		out.WriteString(cd.Modifiers.String() + " ")
+	}
+	out.WriteString("class")
+	if cd.Name != nil {
+		out.WriteString(" " + cd.Name.String())
+	}
+	if cd.Extends != nil {
+		out.WriteString(" extends " + cd.Extends.String())
+	}
+	if len(cd.Implements) > 0 {
+		out.WriteString(" implements " + joinIdentifiers(cd.Implements))
+	}
+	out.WriteString(" " + memberBlock(cd.Members))
+	return out.String()
+}
+
+// InterfaceDecl represents an interface declaration. Interfaces may extend
+// several other interfaces.
+type InterfaceDecl struct {
+	Base
+	Token      token.Token // The 'interface' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Name       *Identifier
+	Extends    []*Identifier
+	Members    []Stmt
+}
+
+func (id *InterfaceDecl) isStmt() {}
+func (id *InterfaceDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, id.Attributes)
+	out.WriteString("interface " + id.Name.String())
+	if len(id.Extends) > 0 {
+		out.WriteString(" extends " + joinIdentifiers(id.Extends))
+	}
+	out.WriteString(" " + memberBlock(id.Members))
+	return out.String()
+}
+
+// TraitDecl represents a trait declaration.
+type TraitDecl struct {
+	Base
+	Token      token.Token // The 'trait' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Name       *Identifier
+	Members    []Stmt
+}
+
+func (td *TraitDecl) isStmt() {}
+func (td *TraitDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, td.Attributes)
+	out.WriteString("trait " + td.Name.String() + " " + memberBlock(td.Members))
+	return out.String()
+}
+
+// EnumDecl represents an enum declaration. BackingType is set for backed
+// enums, e.g. enum Suit: string { case Hearts = 'H'; }.
+type EnumDecl struct {
+	Base
+	Token       token.Token // The 'enum' token
+	Attributes  []*AttributeGroup
+	Doc         *phpdoc.Comment // nil if there is no doc comment
+	Name        *Identifier
+	BackingType Type // nil for pure enums
+	Implements  []*Identifier
+	Members     []Stmt
+}
+
+func (ed *EnumDecl) isStmt() {}
+func (ed *EnumDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ed.Attributes)
+	out.WriteString("enum " + ed.Name.String())
+	if ed.BackingType != nil {
+		out.WriteString(": " + ed.BackingType.String())
+	}
+	if len(ed.Implements) > 0 {
+		out.WriteString(" implements " + joinIdentifiers(ed.Implements))
+	}
+	out.WriteString(" " + memberBlock(ed.Members))
+	return out.String()
+}
+
+// EnumCaseDecl represents a case of an enum, e.g. case Hearts = 'H';.
+type EnumCaseDecl struct {
+	Base
+	Token      token.Token // The 'case' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Name       *Identifier
+	Value      Expr // nil for cases of pure enums
+}
+
+func (ec *EnumCaseDecl) isStmt() {}
+func (ec *EnumCaseDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ec.Attributes)
+	out.WriteString("case " + ec.Name.String())
+	if ec.Value != nil {
+		out.WriteString(" = " + ec.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// MethodDecl represents a method of a class, interface, trait or enum.
+// Body is nil for abstract and interface methods.
+type MethodDecl struct {
+	Base
+	Token      token.Token // The 'function' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Modifiers  Modifiers
+	ByRef      bool
+	Name       *Identifier
+	Params     []*Param
+	ReturnType Type // nil if not declared
+	Body       *BlockStmt
+}
+
+func (md *MethodDecl) isStmt() {}
+func (md *MethodDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, md.Attributes)
+	if md.Modifiers != 0 {
+		out.WriteString(md.Modifiers.String() + " ")
+	}
+	out.WriteString("function ")
+	if md.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(md.Name.String() + paramList(md.Params))
+	if md.ReturnType != nil {
+		out.WriteString(": " + md.ReturnType.String())
+	}
+	if md.Body != nil {
+		out.WriteString(" " + md.Body.String())
+	} else {
+		out.WriteString(";")
+	}
+	return out.String()
+}
+
+// PropertyDecl represents a property declaration, which may declare several
+// properties at once, e.g. private ?int $a = 1, $b;.
+type PropertyDecl struct {
+	Base
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Modifiers  Modifiers       // 'var' is recorded as public
+	Type       Type            // nil if not declared
+	Props      []*PropertyItem
+}
+
+func (pd *PropertyDecl) isStmt() {}
+func (pd *PropertyDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, pd.Attributes)
+	if pd.Modifiers != 0 {
+		out.WriteString(pd.Modifiers.String() + " ")
+	} else {
+		out.WriteString("var ")
+	}
+	if pd.Type != nil {
+		out.WriteString(pd.Type.String() + " ")
+	}
+	props := make([]string, 0, len(pd.Props))
+	for _, prop := range pd.Props {
+		props = append(props, prop.String())
+	}
+	out.WriteString(strings.Join(props, ", ") + ";")
+	return out.String()
+}
+
+// PropertyItem is one property of a PropertyDecl.
+type PropertyItem struct {
+	Base
+	Name    *Variable
+	Default Expr // nil if there is no default value
+}
+
+func (pi *PropertyItem) String() string {
+	if pi.Default != nil {
+		return pi.Name.String() + " = " + pi.Default.String()
+	}
+	return pi.Name.String()
+}
+
+// ClassConstDecl represents a class constant declaration, e.g.
+// final public const string A = 'a', B = 'b';.
+type ClassConstDecl struct {
+	Base
+	Token      token.Token // The 'const' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Modifiers  Modifiers
+	Type       Type // nil if not declared
+	Consts     []*ConstItem
+}
+
+func (cc *ClassConstDecl) isStmt() {}
+func (cc *ClassConstDecl) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, cc.Attributes)
+	if cc.Modifiers != 0 {
+		out.WriteString(cc.Modifiers.String() + " ")
+	}
+	out.WriteString("const ")
+	if cc.Type != nil {
+		out.WriteString(cc.Type.String() + " ")
+	}
+	out.WriteString(joinConstItems(cc.Consts) + ";")
+	return out.String()
+}
+
+// ConstItem is a single NAME = value pair of a constant declaration.
+type ConstItem struct {
+	Base
+	Name  *Identifier
+	Value Expr
+}
+
+func (ci *ConstItem) String() string {
+	if ci.Value == nil {
+		return ci.Name.String()
+	}
+	return ci.Name.String() + " = " + ci.Value.String()
+}
+
+func joinConstItems(items []*ConstItem) string {
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		list = append(list, item.String())
+	}
+	return strings.Join(list, ", ")
+}
+
+// TraitUseStmt represents "use A, B { ... }" inside a class body. The
+// adaptations resolve conflicts between methods of the used traits.
+type TraitUseStmt struct {
+	Base
+	Token       token.Token // The 'use' token
+	Traits      []*Identifier
+	Adaptations []*TraitAdaptation
+}
+
+func (tu *TraitUseStmt) isStmt() {}
+func (tu *TraitUseStmt) String() string {
+	out := "use " + joinIdentifiers(tu.Traits)
+	if len(tu.Adaptations) == 0 {
+		return out + ";"
+	}
+	out += " { "
+	for _, a := range tu.Adaptations {
+		out += a.String() + " "
+	}
+	return out + "}"
+}
+
+// TraitAdaptation is a single conflict resolution rule of a TraitUseStmt:
+// either "A::foo insteadof B;" or "A::foo as protected bar;".
+type TraitAdaptation struct {
+	Base
+	Trait     *Identifier // nil if the method is not qualified
+	Method    *Identifier
+	Insteadof []*Identifier
+	Modifiers Modifiers   // Visibility given by an 'as' rule
+	Alias     *Identifier // nil if the 'as' rule only changes visibility
+}
+
+func (ta *TraitAdaptation) String() string {
+	out := ta.Method.String()
+	if ta.Trait != nil {
+		out = ta.Trait.String() + "::" + out
+	}
+	if len(ta.Insteadof) > 0 {
+		return out + " insteadof " + joinIdentifiers(ta.Insteadof) + ";"
+	}
+	out += " as"
+	if ta.Modifiers != 0 {
+		out += " " + ta.Modifiers.String()
+	}
+	if ta.Alias != nil {
+		out += " " + ta.Alias.String()
+	}
+	return out + ";"
+}
+
+func writeAttributes(out *bytes.Buffer, attrs []*AttributeGroup) {
+	for _, attr := range attrs {
+		out.WriteString(attr.String() + " ")
+	}
+}
+
+func joinIdentifiers(idents []*Identifier) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func memberBlock(members []Stmt) string {
+	var out bytes.Buffer
+	out.WriteString("{ ")
+	for _, m := range members {
+		out.WriteString(m.String() + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
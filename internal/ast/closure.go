@@ -0,0 +1,152 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// ClosureExpr represents an anonymous function, e.g.
+// static function ($a) use ($b, &$c): int { ... }.
+type ClosureExpr struct {
+	Base
+	Token      token.Token // The 'function' token
+	Attributes []*AttributeGroup
+	Static     bool // static function () {}, which does not bind $this
+	ByRef      bool // function &() {}
+	Params     []*Param
+	Uses       []*ClosureUse
+	ReturnType Type // nil if not declared
+	Body       *BlockStmt
+}
+
+func (ce *ClosureExpr) isExpr() {}
+func (ce *ClosureExpr) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, ce.Attributes)
+	if ce.Static {
+		out.WriteString("static ")
+	}
+	out.WriteString("function ")
+	if ce.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(paramList(ce.Params))
+	if len(ce.Uses) > 0 {
+		uses := make([]string, 0, len(ce.Uses))
+		for _, u := range ce.Uses {
+			uses = append(uses, u.String())
+		}
+		out.WriteString(" use (" + strings.Join(uses, ", ") + ")")
+	}
+	if ce.ReturnType != nil {
+		out.WriteString(": " + ce.ReturnType.String())
+	}
+	if ce.Body != nil {
+		out.WriteString(" " + ce.Body.String())
+	}
+	return out.String()
+}
+
+// ClosureUse is a variable imported into a closure by its use clause, by
+// value or with use (&$a) by reference.
+type ClosureUse struct {
+	Base
+	ByRef bool
+	Var   *Variable
+}
+
+func (cu *ClosureUse) String() string {
+	if cu.ByRef {
+		return "&" + cu.Var.String()
+	}
+	return cu.Var.String()
+}
+
+// ArrowFuncExpr represents an arrow function, e.g. static fn($a) => $a * 2.
+// Arrow functions capture the variables of the enclosing scope they use by
+// value; see Captures.
+type ArrowFuncExpr struct {
+	Base
+	Token      token.Token // The 'fn' token
+	Attributes []*AttributeGroup
+	Static     bool
+	ByRef      bool // fn&($a) => $a
+	Params     []*Param
+	ReturnType Type // nil if not declared
+	Expr       Expr
+}
+
+func (af *ArrowFuncExpr) isExpr() {}
+func (af *ArrowFuncExpr) String() string {
+	var out bytes.Buffer
+	writeAttributes(&out, af.Attributes)
+	if af.Static {
+		out.WriteString("static ")
+	}
+	out.WriteString("fn")
+	if af.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(paramList(af.Params))
+	if af.ReturnType != nil {
+		out.WriteString(": " + af.ReturnType.String())
+	}
+	out.WriteString(" => " + af.Expr.String())
+	return out.String()
+}
+
+// Captures returns the names of the variables the arrow function takes from
+// the enclosing scope, in order of first use: every variable used in its
+// body that is not one of its parameters or $this. Variables used by nested
+// arrow functions are captured through this one; a nested closure only
+// captures the variables listed in its use clause.
+func (af *ArrowFuncExpr) Captures() []string {
+	c := &captureCollector{
+		bound: map[string]bool{"this": true},
+		seen:  map[string]bool{},
+	}
+	for _, p := range af.Params {
+		if p.Name != nil {
+			c.bound[p.Name.Name] = true
+		}
+	}
+	Inspect(af.Expr, c.enter)
+	return c.names
+}
+
+type captureCollector struct {
+	bound map[string]bool // Parameters of the arrow function and $this
+	seen  map[string]bool
+	names []string
+}
+
+// enter adds the variables used in the arrow function. Nested functions
+// have their own scope: only what they capture is added, and their bodies
+// are skipped.
+func (c *captureCollector) enter(node Node, ancestors []Node) Action {
+	switch n := node.(type) {
+	case *Variable:
+		c.add(n.Name)
+	case *ClosureExpr:
+		for _, u := range n.Uses {
+			c.add(u.Var.Name)
+		}
+		return SkipChildren
+	case *ArrowFuncExpr:
+		for _, name := range n.Captures() {
+			c.add(name)
+		}
+		return SkipChildren
+	}
+	return Continue
+}
+
+func (c *captureCollector) add(name string) {
+	if c.bound[name] || c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+	c.names = append(c.names, name)
+}
@@ -0,0 +1,398 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// IfStmt represents an if statement with its elseif and else branches.
+// AltSyntax is set for "if (...): ... endif;".
+type IfStmt struct {
+	Base
+	Token     token.Token // The 'if' token
+	Condition Expr
+	Body      *BlockStmt
+	ElseIfs   []*ElseIfClause
+	Else      *ElseClause // nil if there is no else branch
+	AltSyntax bool
+}
+
+func (is *IfStmt) isStmt() {}
+func (is *IfStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("if (" + is.Condition.String() + ") " + is.Body.String())
+	for _, elseIf := range is.ElseIfs {
+		out.WriteString(" " + elseIf.String())
+	}
+	if is.Else != nil {
+		out.WriteString(" " + is.Else.String())
+	}
+	return out.String()
+}
+
+// ElseIfClause is an elseif branch of an IfStmt.
+type ElseIfClause struct {
+	Base
+	Token     token.Token // The 'elseif' token
+	Condition Expr
+	Body      *BlockStmt
+}
+
+func (ei *ElseIfClause) String() string {
+	return "elseif (" + ei.Condition.String() + ") " + ei.Body.String()
+}
+
+// ElseClause is the else branch of an IfStmt. "else if" is an else branch
+// whose body holds another IfStmt.
+type ElseClause struct {
+	Base
+	Token token.Token // The 'else' token
+	Body  *BlockStmt
+}
+
+func (ec *ElseClause) String() string { return "else " + ec.Body.String() }
+
+// WhileStmt represents while (cond) { ... }.
+type WhileStmt struct {
+	Base
+	Token     token.Token // The 'while' token
+	Condition Expr
+	Body      *BlockStmt
+	AltSyntax bool
+}
+
+func (ws *WhileStmt) isStmt() {}
+func (ws *WhileStmt) String() string {
+	return "while (" + ws.Condition.String() + ") " + ws.Body.String()
+}
+
+// DoWhileStmt represents do { ... } while (cond);.
+type DoWhileStmt struct {
+	Base
+	Token     token.Token // The 'do' token
+	Body      *BlockStmt
+	Condition Expr
+}
+
+func (dw *DoWhileStmt) isStmt() {}
+func (dw *DoWhileStmt) String() string {
+	return "do " + dw.Body.String() + " while (" + dw.Condition.String() + ");"
+}
+
+// ForStmt represents for (init; cond; step) { ... }. Each of the three parts
+// is a possibly empty comma-separated list of expressions.
+type ForStmt struct {
+	Base
+	Token     token.Token // The 'for' token
+	Init      []Expr
+	Condition []Expr
+	Step      []Expr
+	Body      *BlockStmt
+	AltSyntax bool
+}
+
+func (fs *ForStmt) isStmt() {}
+func (fs *ForStmt) String() string {
+	return "for (" + joinExprs(fs.Init) + "; " + joinExprs(fs.Condition) + "; " + joinExprs(fs.Step) + ") " + fs.Body.String()
+}
+
+// ForeachStmt represents foreach ($expr as $key => &$value) { ... }. Value
+// may be an ArrayLiteral that destructures each element.
+type ForeachStmt struct {
+	Base
+	Token     token.Token // The 'foreach' token
+	Expr      Expr
+	Key       Expr // nil if no key is bound
+	ByRef     bool
+	Value     Expr
+	Body      *BlockStmt
+	AltSyntax bool
+}
+
+func (fs *ForeachStmt) isStmt() {}
+func (fs *ForeachStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("foreach (" + fs.Expr.String() + " as ")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String() + " => ")
+	}
+	if fs.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(fs.Value.String() + ") " + fs.Body.String())
+	return out.String()
+}
+
+// SwitchStmt represents a switch statement.
+type SwitchStmt struct {
+	Base
+	Token     token.Token // The 'switch' token
+	Subject   Expr
+	Cases     []*SwitchCase
+	AltSyntax bool
+}
+
+func (ss *SwitchStmt) isStmt() {}
+func (ss *SwitchStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("switch (" + ss.Subject.String() + ") { ")
+	for _, c := range ss.Cases {
+		out.WriteString(c.String() + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// SwitchCase is a case or default label of a switch and the statements
+// following it.
+type SwitchCase struct {
+	Base
+	Token token.Token // The 'case' or 'default' token
+	Value Expr        // nil for default
+	Body  []Stmt
+}
+
+func (sc *SwitchCase) String() string {
+	var out bytes.Buffer
+	if sc.Value == nil {
+		out.WriteString("default:")
+	} else {
+		out.WriteString("case " + sc.Value.String() + ":")
+	}
+	for _, s := range sc.Body {
+		out.WriteString(" " + s.String())
+	}
+	return out.String()
+}
+
+// MatchExpr represents match ($subject) { cond1, cond2 => result, ... }.
+type MatchExpr struct {
+	Base
+	Token   token.Token // The 'match' token
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpr) isExpr() {}
+func (me *MatchExpr) String() string {
+	arms := make([]string, 0, len(me.Arms))
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+	return "match (" + me.Subject.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm is a single arm of a match. Conditions is nil for the default
+// arm.
+type MatchArm struct {
+	Base
+	Conditions []Expr
+	Body       Expr
+}
+
+func (ma *MatchArm) String() string {
+	if ma.Conditions == nil {
+		return "default => " + ma.Body.String()
+	}
+	return joinExprs(ma.Conditions) + " => " + ma.Body.String()
+}
+
+// TryStmt represents try { ... } catch (A | B $e) { ... } finally { ... }.
+type TryStmt struct {
+	Base
+	Token   token.Token // The 'try' token
+	Body    *BlockStmt
+	Catches []*CatchClause
+	Finally *BlockStmt // nil if there is no finally block
+}
+
+func (ts *TryStmt) isStmt() {}
+func (ts *TryStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("try " + ts.Body.String())
+	for _, c := range ts.Catches {
+		out.WriteString(" " + c.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally " + ts.Finally.String())
+	}
+	return out.String()
+}
+
+// CatchClause is a catch block of a TryStmt. Var is nil when the exception
+// is not bound, as allowed since PHP 8.
+type CatchClause struct {
+	Base
+	Token token.Token // The 'catch' token
+	Types []*Identifier
+	Var   *Variable
+	Body  *BlockStmt
+}
+
+func (cc *CatchClause) String() string {
+	types := make([]string, 0, len(cc.Types))
+	for _, t := range cc.Types {
+		types = append(types, t.String())
+	}
+	out := "catch (" + strings.Join(types, " | ")
+	if cc.Var != nil {
+		out += " " + cc.Var.String()
+	}
+	return out + ") " + cc.Body.String()
+}
+
+// ReturnStmt represents return or return $value.
+type ReturnStmt struct {
+	Base
+	Token token.Token // The 'return' token
+	Value Expr        // nil for a bare return
+}
+
+func (rs *ReturnStmt) isStmt() {}
+func (rs *ReturnStmt) String() string {
+	if rs.Value == nil {
+		return "return;"
+	}
+	return "return " + rs.Value.String() + ";"
+}
+
+// BreakStmt represents break or break N.
+type BreakStmt struct {
+	Base
+	Token  token.Token // The 'break' token
+	Levels Expr        // nil if no level is given
+}
+
+func (bs *BreakStmt) isStmt() {}
+func (bs *BreakStmt) String() string {
+	if bs.Levels == nil {
+		return "break;"
+	}
+	return "break " + bs.Levels.String() + ";"
+}
+
+// ContinueStmt represents continue or continue N.
+type ContinueStmt struct {
+	Base
+	Token  token.Token // The 'continue' token
+	Levels Expr        // nil if no level is given
+}
+
+func (cs *ContinueStmt) isStmt() {}
+func (cs *ContinueStmt) String() string {
+	if cs.Levels == nil {
+		return "continue;"
+	}
+	return "continue " + cs.Levels.String() + ";"
+}
+
+// GlobalStmt represents global $a, $b;.
+type GlobalStmt struct {
+	Base
+	Token token.Token // The 'global' token
+	Vars  []Expr      // Variable or VariableVariable
+}
+
+func (gs *GlobalStmt) isStmt()        {}
+func (gs *GlobalStmt) String() string { return "global " + joinExprs(gs.Vars) + ";" }
+
+// StaticStmt represents the static variable declaration static $a = 1, $b;.
+type StaticStmt struct {
+	Base
+	Token token.Token // The 'static' token
+	Vars  []*StaticVar
+}
+
+func (ss *StaticStmt) isStmt() {}
+func (ss *StaticStmt) String() string {
+	vars := make([]string, 0, len(ss.Vars))
+	for _, v := range ss.Vars {
+		vars = append(vars, v.String())
+	}
+	return "static " + strings.Join(vars, ", ") + ";"
+}
+
+// StaticVar is a single variable of a StaticStmt.
+type StaticVar struct {
+	Base
+	Name    *Variable
+	Default Expr // nil if there is no initial value
+}
+
+func (sv *StaticVar) String() string {
+	if sv.Default == nil {
+		return sv.Name.String()
+	}
+	return sv.Name.String() + " = " + sv.Default.String()
+}
+
+// UnsetStmt represents unset($a, $b['key']);.
+type UnsetStmt struct {
+	Base
+	Token token.Token // The 'unset' token
+	Vars  []Expr
+}
+
+func (us *UnsetStmt) isStmt()        {}
+func (us *UnsetStmt) String() string { return "unset(" + joinExprs(us.Vars) + ");" }
+
+// DeclareStmt represents declare(strict_types=1); and the block forms
+// declare(ticks=1) { ... }. Body is nil for the statement form.
+type DeclareStmt struct {
+	Base
+	Token      token.Token // The 'declare' token
+	Directives []*ConstItem
+	Body       *BlockStmt
+	AltSyntax  bool
+}
+
+func (ds *DeclareStmt) isStmt() {}
+func (ds *DeclareStmt) String() string {
+	out := "declare(" + joinConstItems(ds.Directives) + ")"
+	if ds.Body == nil {
+		return out + ";"
+	}
+	return out + " " + ds.Body.String()
+}
+
+// ConstStmt represents a constant declaration outside of a class, e.g.
+// const A = 1, B = 2;.
+type ConstStmt struct {
+	Base
+	Token  token.Token     // The 'const' token
+	Doc    *phpdoc.Comment // nil if there is no doc comment
+	Consts []*ConstItem
+}
+
+func (cs *ConstStmt) isStmt()        {}
+func (cs *ConstStmt) String() string { return "const " + joinConstItems(cs.Consts) + ";" }
+
+// GotoStmt represents goto label;.
+type GotoStmt struct {
+	Base
+	Token token.Token // The 'goto' token
+	Label *Identifier
+}
+
+func (gs *GotoStmt) isStmt()        {}
+func (gs *GotoStmt) String() string { return "goto " + gs.Label.String() + ";" }
+
+// LabelStmt represents a goto target, e.g. retry:.
+type LabelStmt struct {
+	Base
+	Name *Identifier
+}
+
+func (ls *LabelStmt) isStmt()        {}
+func (ls *LabelStmt) String() string { return ls.Name.String() + ":" }
+
+func joinExprs(exprs []Expr) string {
+	list := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		list = append(list, e.String())
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,127 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Modifiers is a set of declaration modifiers such as public or readonly.
+type Modifiers int
+
+const (
+	ModPublic Modifiers = 1 << iota
+	ModProtected
+	ModPrivate
+	ModStatic
+	ModAbstract
+	ModFinal
+	ModReadonly
+)
+
+var modifierNames = []struct {
+	mod  Modifiers
+	name string
+}{
+	{ModAbstract, "abstract"},
+	{ModFinal, "final"},
+	{ModPublic, "public"},
+	{ModProtected, "protected"},
+	{ModPrivate, "private"},
+	{ModStatic, "static"},
+	{ModReadonly, "readonly"},
+}
+
+// Has reports whether all modifiers in mod are set.
+func (m Modifiers) Has(mod Modifiers) bool { return m&mod == mod }
+
+func (m Modifiers) String() string {
+	names := []string{}
+	for _, mn := range modifierNames {
+		if m.Has(mn.mod) {
+			names = append(names, mn.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// Param represents a function or method parameter, e.g. "?int &...$values"
+// or a promoted constructor parameter such as "private readonly Foo $foo".
+type Param struct {
+	Base
+	Attributes []*AttributeGroup
+	Modifiers  Modifiers // Set for promoted constructor parameters
+	Type       Type      // nil if not declared
+	ByRef      bool
+	Variadic   bool
+	Name       *Variable
+	Default    Expr // nil if there is no default value
+}
+
+func (p *Param) String() string {
+	var out bytes.Buffer
+	for _, attr := range p.Attributes {
+		out.WriteString(attr.String() + " ")
+	}
+	if p.Modifiers != 0 {
+		out.WriteString(p.Modifiers.String() + " ")
+	}
+	if p.Type != nil {
+		out.WriteString(p.Type.String() + " ")
+	}
+	if p.ByRef {
+		out.WriteString("&")
+	}
+	if p.Variadic {
+		out.WriteString("...")
+	}
+	if p.Name != nil {
+		out.WriteString(p.Name.String())
+	}
+	if p.Default != nil {
+		out.WriteString(" = " + p.Default.String())
+	}
+	return out.String()
+}
+
+// Promoted reports whether the parameter also declares a property.
+func (p *Param) Promoted() bool { return p.Modifiers != 0 }
+
+func paramList(params []*Param) string {
+	list := make([]string, 0, len(params))
+	for _, p := range params {
+		list = append(list, p.String())
+	}
+	return "(" + strings.Join(list, ", ") + ")"
+}
+
+// AttributeGroup represents one "#[...]" group, which may hold several
+// comma-separated attributes.
+type AttributeGroup struct {
+	Base
+	Token      token.Token // The '#[' token
+	Attributes []*Attribute
+}
+
+func (ag *AttributeGroup) String() string {
+	attrs := make([]string, 0, len(ag.Attributes))
+	for _, a := range ag.Attributes {
+		attrs = append(attrs, a.String())
+	}
+	return "#[" + strings.Join(attrs, ", ") + "]"
+}
+
+// Attribute represents a single attribute such as Route('/home').
+type Attribute struct {
+	Base
+	Name      *Identifier
+	Arguments []*Argument
+}
+
+func (a *Attribute) String() string {
+	if len(a.Arguments) == 0 {
+		return a.Name.String()
+	}
+	return a.Name.String() + argumentList(a.Arguments)
+}
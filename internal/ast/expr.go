@@ -1,45 +1,439 @@
 package ast
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/codevault-llc/php-lint/internal/token"
 )
 
 // StringLiteral represents a string.
 type StringLiteral struct {
-    Base
-    Token token.Token // The string token
-    Value string
+	Base
+	Token token.Token // The string token
+	Value string
 }
 
 func (sl *StringLiteral) isExpr() {}
 func (sl *StringLiteral) String() string {
-    return fmt.Sprintf("'%s'", sl.Value)
+	return fmt.Sprintf("'%s'", sl.Value)
 }
 
-// Identifier represents a variable or function name.
+// Identifier represents a name such as a function, class or constant name.
+// Value is the name as written, which may be qualified (Foo\bar), fully
+// qualified (\Foo\bar) or relative (namespace\bar).
 type Identifier struct {
-	Base           // Embedded position
-	Token token.Token // The token.IDENT token
+	Base              // Embedded position
+	Token token.Token // The token.IDENT or token.NAME_* token
 	Value string
+
+	// Resolved is the fully-qualified name, without a leading backslash,
+	// that the identifier refers to once the resolver has run. It stays
+	// empty for names that are not references, such as method names.
+	Resolved string
+	// Fallback is the global name PHP falls back to at runtime when an
+	// unqualified function or constant is not defined in the namespace.
+	Fallback string
 }
 
 func (i *Identifier) isExpr()        {}
 func (i *Identifier) String() string { return i.Value }
 
-// CallExpr represents a function call.
+// FullName returns the resolved name, or the name as written if it has not
+// been resolved.
+func (i *Identifier) FullName() string {
+	if i.Resolved != "" {
+		return i.Resolved
+	}
+	return i.Value
+}
+
+// CallExpr represents a function call. With the first-class callable syntax
+// strlen(...) it creates a Closure instead; Callable is then set and
+// Arguments is empty.
 type CallExpr struct {
-    Base
-    Token     token.Token // The '(' token
-    Function  Expr        // Identifier or another expression
-    Arguments []Expr
+	Base
+	Token     token.Token // The '(' token
+	Function  Expr        // Identifier or another expression
+	Arguments []*Argument
+	Callable  bool
 }
 
 func (ce *CallExpr) isExpr() {}
 func (ce *CallExpr) String() string {
-    if ce.Function != nil {
-        return ce.Function.String() + "()"
-    }
-    return "<invalid call>"
+	if ce.Function != nil {
+		return ce.Function.String() + callArguments(ce.Arguments, ce.Callable)
+	}
+	return "<invalid call>"
+}
+
+// Argument is a single argument of a call, e.g. $a, ...$rest or the named
+// argument flags: FILTER_NULL.
+type Argument struct {
+	Base
+	Name   *Identifier // nil for positional arguments
+	Unpack bool        // ...$args
+	Value  Expr
+}
+
+func (a *Argument) String() string {
+	switch {
+	case a.Name != nil:
+		return a.Name.String() + ": " + a.Value.String()
+	case a.Unpack:
+		return "..." + a.Value.String()
+	}
+	return a.Value.String()
+}
+
+// callArguments is argumentList for a call that may use the first-class
+// callable syntax.
+func callArguments(args []*Argument, callable bool) string {
+	if callable {
+		return "(...)"
+	}
+	return argumentList(args)
+}
+
+func argumentList(args []*Argument) string {
+	list := make([]string, 0, len(args))
+	for _, arg := range args {
+		list = append(list, arg.String())
+	}
+	return "(" + strings.Join(list, ", ") + ")"
+}
+
+// Variable represents a variable such as $name. Name excludes the dollar sign.
+type Variable struct {
+	Base
+	Token token.Token // The token.VARIABLE token
+	Name  string
+}
+
+func (v *Variable) isExpr()        {}
+func (v *Variable) String() string { return "$" + v.Name }
+
+// VariableVariable represents a variable whose name is computed, e.g. $$name
+// or ${'a' . $b}.
+type VariableVariable struct {
+	Base
+	Token token.Token // The '$' token
+	Name  Expr
+}
+
+func (vv *VariableVariable) isExpr()        {}
+func (vv *VariableVariable) String() string { return "${" + vv.Name.String() + "}" }
+
+// InterpolatedString represents a double-quoted string or heredoc that
+// embeds variables, e.g. "Hello {$user->name}!". Parts holds StringLiteral
+// nodes for the literal text and arbitrary expressions for the
+// interpolated pieces, in source order.
+type InterpolatedString struct {
+	Base
+	Token token.Token // The token.INTERPOLATED_STRING token
+	Parts []Expr
+}
+
+func (is *InterpolatedString) isExpr() {}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	out.WriteString(`"`)
+	for _, part := range is.Parts {
+		if sl, ok := part.(*StringLiteral); ok {
+			out.WriteString(sl.Value)
+			continue
+		}
+		out.WriteString("{" + part.String() + "}")
+	}
+	out.WriteString(`"`)
+	return out.String()
+}
+
+// ShellExecExpr represents a shell command in backticks, e.g. `ls $dir`,
+// which runs it like shell_exec(). Parts are those of an
+// InterpolatedString.
+type ShellExecExpr struct {
+	Base
+	Token token.Token // The token.SHELL_EXEC token
+	Parts []Expr
+}
+
+func (se *ShellExecExpr) isExpr() {}
+func (se *ShellExecExpr) String() string {
+	var out bytes.Buffer
+	out.WriteString("`")
+	for _, part := range se.Parts {
+		if sl, ok := part.(*StringLiteral); ok {
+			out.WriteString(sl.Value)
+			continue
+		}
+		out.WriteString("{" + part.String() + "}")
+	}
+	out.WriteString("`")
+	return out.String()
+}
+
+// IntegerLiteral represents an integer such as 42, 0x2A or 1_000.
+type IntegerLiteral struct {
+	Base
+	Token token.Token // The token.INTEGER token
+	Value int64
+}
+
+func (il *IntegerLiteral) isExpr()        {}
+func (il *IntegerLiteral) String() string { return il.Token.Lexeme }
+
+// FloatLiteral represents a floating point number such as 1.5 or 1e10.
+type FloatLiteral struct {
+	Base
+	Token token.Token // The token.FLOAT or overflowing token.INTEGER token
+	Value float64
+}
+
+func (fl *FloatLiteral) isExpr()        {}
+func (fl *FloatLiteral) String() string { return fl.Token.Lexeme }
+
+// ArrayLiteral represents array(...), [...] or list(...). Used as the
+// target of an assignment or foreach it destructures its value; Items then
+// may contain nil entries for skipped elements, as in [, $b] = $pair.
+type ArrayLiteral struct {
+	Base
+	Token token.Token // The '[', 'array' or 'list' token
+	Items []*ArrayItem
+}
+
+func (al *ArrayLiteral) isExpr() {}
+func (al *ArrayLiteral) String() string {
+	items := make([]string, 0, len(al.Items))
+	for _, item := range al.Items {
+		if item == nil {
+			items = append(items, "")
+			continue
+		}
+		items = append(items, item.String())
+	}
+	if al.Token.Kind == token.LBRACKET {
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return strings.ToLower(al.Token.Lexeme) + "(" + strings.Join(items, ", ") + ")"
+}
+
+// ArrayItem is an element of an ArrayLiteral: a value, a key => value
+// pair, a by-reference &$value or a spread ...$values.
+type ArrayItem struct {
+	Base
+	Key    Expr // nil if the item has no key
+	Value  Expr
+	ByRef  bool
+	Unpack bool
+}
+
+func (ai *ArrayItem) String() string {
+	var out bytes.Buffer
+	if ai.Key != nil {
+		out.WriteString(ai.Key.String() + " => ")
+	}
+	if ai.ByRef {
+		out.WriteString("&")
+	}
+	if ai.Unpack {
+		out.WriteString("...")
+	}
+	out.WriteString(ai.Value.String())
+	return out.String()
+}
+
+// IndexExpr represents an array or string offset, e.g. $a['key']. Index is
+// nil for the append form $a[].
+type IndexExpr struct {
+	Base
+	Token token.Token // The '[' token
+	Left  Expr
+	Index Expr
+}
+
+func (ie *IndexExpr) isExpr() {}
+func (ie *IndexExpr) String() string {
+	if ie.Index == nil {
+		return ie.Left.String() + "[]"
+	}
+	return ie.Left.String() + "[" + ie.Index.String() + "]"
+}
+
+// PropertyFetchExpr represents $obj->name or the nullsafe $obj?->name.
+// Property is an Identifier for a plain name, and a Variable or other
+// expression for dynamic names such as $obj->$name or $obj->{'a' . $b}.
+type PropertyFetchExpr struct {
+	Base
+	Token    token.Token // The '->' or '?->' token
+	Object   Expr
+	Property Expr
+	NullSafe bool
+}
+
+func (pf *PropertyFetchExpr) isExpr() {}
+func (pf *PropertyFetchExpr) String() string {
+	return pf.Object.String() + pf.Token.Lexeme + memberString(pf.Property)
+}
+
+// MethodCallExpr represents $obj->name(args) or $obj?->name(args). Callable
+// is set for $obj->name(...), see CallExpr.
+type MethodCallExpr struct {
+	Base
+	Token     token.Token // The '->' or '?->' token
+	Object    Expr
+	Method    Expr // Identifier, Variable or other expression
+	Arguments []*Argument
+	NullSafe  bool
+	Callable  bool
+}
+
+func (mc *MethodCallExpr) isExpr() {}
+func (mc *MethodCallExpr) String() string {
+	return mc.Object.String() + mc.Token.Lexeme + memberString(mc.Method) + callArguments(mc.Arguments, mc.Callable)
+}
+
+// StaticCallExpr represents Foo::name(args). Class is an Identifier such as
+// Foo, self, parent or static, or an expression such as $obj. Callable is
+// set for Foo::name(...), see CallExpr.
+type StaticCallExpr struct {
+	Base
+	Token     token.Token // The '::' token
+	Class     Expr
+	Method    Expr // Identifier, Variable or other expression
+	Arguments []*Argument
+	Callable  bool
+}
+
+func (sc *StaticCallExpr) isExpr() {}
+func (sc *StaticCallExpr) String() string {
+	return sc.Class.String() + "::" + memberString(sc.Method) + callArguments(sc.Arguments, sc.Callable)
+}
+
+// StaticPropertyFetchExpr represents Foo::$name.
+type StaticPropertyFetchExpr struct {
+	Base
+	Token    token.Token // The '::' token
+	Class    Expr
+	Property Expr // Variable or VariableVariable
+}
+
+func (sp *StaticPropertyFetchExpr) isExpr() {}
+func (sp *StaticPropertyFetchExpr) String() string {
+	return sp.Class.String() + "::" + sp.Property.String()
+}
+
+// ClassConstFetchExpr represents Foo::NAME, including Foo::class and enum
+// cases such as Suit::Hearts.
+type ClassConstFetchExpr struct {
+	Base
+	Token token.Token // The '::' token
+	Class Expr
+	Name  *Identifier
+}
+
+func (cc *ClassConstFetchExpr) isExpr() {}
+func (cc *ClassConstFetchExpr) String() string {
+	return cc.Class.String() + "::" + cc.Name.String()
+}
+
+// memberString prints a member name, wrapping computed names in braces.
+func memberString(member Expr) string {
+	switch member.(type) {
+	case *Identifier, *Variable:
+		return member.String()
+	}
+	return "{" + member.String() + "}"
+}
+
+// NewExpr represents new Foo(args). Class is an Identifier or an expression
+// such as $className; for an anonymous class it is nil and AnonClass holds
+// the class body.
+type NewExpr struct {
+	Base
+	Token     token.Token // The 'new' token
+	Class     Expr
+	AnonClass *ClassDecl
+	Arguments []*Argument
+}
+
+func (ne *NewExpr) isExpr() {}
+func (ne *NewExpr) String() string {
+	if ne.AnonClass != nil {
+		return "new " + ne.AnonClass.String() + argumentList(ne.Arguments)
+	}
+	return "new " + ne.Class.String() + argumentList(ne.Arguments)
+}
+
+// CloneExpr represents clone $obj.
+type CloneExpr struct {
+	Base
+	Token token.Token // The 'clone' token
+	Expr  Expr
+}
+
+func (ce *CloneExpr) isExpr()        {}
+func (ce *CloneExpr) String() string { return "clone " + ce.Expr.String() }
+
+// PrintExpr represents print $value, which unlike echo is an expression.
+type PrintExpr struct {
+	Base
+	Token token.Token // The 'print' token
+	Expr  Expr
+}
+
+func (pe *PrintExpr) isExpr()        {}
+func (pe *PrintExpr) String() string { return "print " + pe.Expr.String() }
+
+// YieldExpr represents yield, yield $value or yield $key => $value.
+type YieldExpr struct {
+	Base
+	Token token.Token // The 'yield' token
+	Key   Expr        // nil if no key is given
+	Value Expr        // nil for a bare yield
+}
+
+func (ye *YieldExpr) isExpr() {}
+func (ye *YieldExpr) String() string {
+	switch {
+	case ye.Key != nil:
+		return "yield " + ye.Key.String() + " => " + ye.Value.String()
+	case ye.Value != nil:
+		return "yield " + ye.Value.String()
+	}
+	return "yield"
+}
+
+// YieldFromExpr represents yield from $generator.
+type YieldFromExpr struct {
+	Base
+	Token token.Token // The 'yield from' token
+	Expr  Expr
+}
+
+func (yf *YieldFromExpr) isExpr()        {}
+func (yf *YieldFromExpr) String() string { return "yield from " + yf.Expr.String() }
+
+// ThrowExpr represents throw $exception, which is an expression since PHP 8.
+type ThrowExpr struct {
+	Base
+	Token token.Token // The 'throw' token
+	Expr  Expr
+}
+
+func (te *ThrowExpr) isExpr()        {}
+func (te *ThrowExpr) String() string { return "throw " + te.Expr.String() }
+
+// IncludeExpr represents include, include_once, require or require_once;
+// Token tells which.
+type IncludeExpr struct {
+	Base
+	Token token.Token
+	Expr  Expr
+}
+
+func (ie *IncludeExpr) isExpr() {}
+func (ie *IncludeExpr) String() string {
+	return strings.ToLower(ie.Token.Lexeme) + " " + ie.Expr.String()
 }
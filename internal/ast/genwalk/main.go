@@ -0,0 +1,197 @@
+// Command genwalk generates walk_gen.go, which lists the children of each
+// node for ast.Walk and ast.Rewrite. It reads the node types from the
+// package source, so that Walk stays exhaustive as nodes and fields are
+// added: every struct embedding Base is a node, and every field holding a
+// node, an interface embedding Node, or a slice of either is a child,
+// visited in field order.
+//
+// Run it with go generate in internal/ast.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const output = "walk_gen.go"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("genwalk: ")
+
+	src, err := generate(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the content of walk_gen.go for the package ast in dir.
+func generate(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return fi.Name() != output && !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := pkgs["ast"]
+	if !ok {
+		return nil, fmt.Errorf("package ast not found; run genwalk in internal/ast")
+	}
+
+	structs := map[string]*goast.StructType{}
+	interfaces := map[string]bool{"Node": true}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*goast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*goast.TypeSpec)
+				switch t := ts.Type.(type) {
+				case *goast.StructType:
+					if embedsBase(t) {
+						structs[ts.Name.Name] = t
+					}
+				case *goast.InterfaceType:
+					if embeds(t.Methods, "Node") {
+						interfaces[ts.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(structs))
+	for name := range structs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := &generator{structs: structs, interfaces: interfaces}
+	g.printf("// Code generated by genwalk; DO NOT EDIT.\n\n")
+	g.printf("package ast\n\n")
+	g.printf("import \"fmt\"\n\n")
+	g.printf("// eachChild calls f for each child of node in source order, with the name\n")
+	g.printf("// of the field holding it and its index if the field is a slice, or -1.\n")
+	g.printf("// It returns false as soon as f does.\n")
+	g.printf("func eachChild(node Node, f func(child Node, field string, index int) bool) bool {\n")
+	g.printf("switch n := node.(type) {\n")
+	for _, name := range names {
+		g.node(name, structs[name])
+	}
+	g.printf("default:\n")
+	g.printf("panic(fmt.Sprintf(\"ast.Walk: unexpected node type %%T\", node))\n")
+	g.printf("}\n")
+	g.printf("return true\n")
+	g.printf("}\n")
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting output: %v", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	buf        bytes.Buffer
+	structs    map[string]*goast.StructType
+	interfaces map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// node writes the case of children for the node type name.
+func (g *generator) node(name string, st *goast.StructType) {
+	var body bytes.Buffer
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			g.field(&body, name, ident.Name, field.Type)
+		}
+	}
+	g.printf("case *%s:\n", name)
+	if body.Len() == 0 {
+		g.printf("// No children.\n")
+		return
+	}
+	g.buf.Write(body.Bytes())
+}
+
+// field writes the code visiting the field name of node if it holds nodes.
+// It fails on fields that mention a node type in a form Walk cannot visit.
+func (g *generator) field(out *bytes.Buffer, node, name string, typ goast.Expr) {
+	if g.isChild(typ) {
+		fmt.Fprintf(out, "if n.%s != nil && !f(n.%[1]s, %[1]q, -1) {\nreturn false\n}\n", name)
+		return
+	}
+	if slice, ok := typ.(*goast.ArrayType); ok && slice.Len == nil && g.isChild(slice.Elt) {
+		fmt.Fprintf(out, "for i, child := range n.%s {\nif child != nil && !f(child, %[1]q, i) {\nreturn false\n}\n}\n", name)
+		return
+	}
+	if g.mentionsNode(typ) {
+		log.Fatalf("%s.%s: cannot walk a field of type %s", node, name, exprString(typ))
+	}
+}
+
+// isChild reports whether typ is a pointer to a node or a node interface.
+func (g *generator) isChild(typ goast.Expr) bool {
+	switch t := typ.(type) {
+	case *goast.Ident:
+		return g.interfaces[t.Name]
+	case *goast.StarExpr:
+		ident, ok := t.X.(*goast.Ident)
+		return ok && g.structs[ident.Name] != nil
+	}
+	return false
+}
+
+// mentionsNode reports whether typ refers to a node type anywhere.
+func (g *generator) mentionsNode(typ goast.Expr) bool {
+	found := false
+	goast.Inspect(typ, func(n goast.Node) bool {
+		if ident, ok := n.(*goast.Ident); ok && (g.interfaces[ident.Name] || g.structs[ident.Name] != nil) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func embedsBase(st *goast.StructType) bool {
+	return embeds(st.Fields, "Base")
+}
+
+// embeds reports whether fields has an embedded field of the type name.
+func embeds(fields *goast.FieldList, name string) bool {
+	for _, field := range fields.List {
+		if ident, ok := field.Type.(*goast.Ident); ok && len(field.Names) == 0 && ident.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func exprString(typ goast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), typ); err != nil {
+		return fmt.Sprintf("%T", typ)
+	}
+	return buf.String()
+}
@@ -0,0 +1,114 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// NamespaceStmt represents "namespace Foo;" or "namespace Foo { ... }". The
+// statements following an unbraced declaration, up to the next namespace
+// declaration, are its Stmts.
+type NamespaceStmt struct {
+	Base
+	Token  token.Token // The 'namespace' token
+	Name   *Identifier // nil for the global namespace "namespace { ... }"
+	Braced bool
+	Stmts  []Stmt
+}
+
+func (ns *NamespaceStmt) isStmt() {}
+func (ns *NamespaceStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("namespace")
+	if ns.Name != nil {
+		out.WriteString(" " + ns.Name.String())
+	}
+	if ns.Braced {
+		out.WriteString(" { ")
+	} else {
+		out.WriteString("; ")
+	}
+	for _, s := range ns.Stmts {
+		out.WriteString(s.String() + " ")
+	}
+	if ns.Braced {
+		out.WriteString("}")
+	}
+	return out.String()
+}
+
+// UseKind tells which kind of symbol a use statement imports.
+type UseKind int
+
+const (
+	UseClass    UseKind = iota // use Foo\Bar;
+	UseFunction                // use function Foo\bar;
+	UseConst                   // use const Foo\BAR;
+)
+
+func (k UseKind) String() string {
+	switch k {
+	case UseFunction:
+		return "function"
+	case UseConst:
+		return "const"
+	}
+	return ""
+}
+
+// UseStmt represents an import such as "use Foo\Bar as Baz, Qux;" or the
+// group form "use Foo\{Bar, function baz};".
+type UseStmt struct {
+	Base
+	Token  token.Token // The 'use' token
+	Kind   UseKind
+	Prefix *Identifier // Shared prefix of a group use, nil otherwise
+	Uses   []*UseClause
+}
+
+func (us *UseStmt) isStmt() {}
+func (us *UseStmt) String() string {
+	out := "use "
+	if us.Kind != UseClass {
+		out += us.Kind.String() + " "
+	}
+	uses := make([]string, 0, len(us.Uses))
+	for _, use := range us.Uses {
+		uses = append(uses, use.String())
+	}
+	if us.Prefix != nil {
+		return out + us.Prefix.String() + "\\{" + strings.Join(uses, ", ") + "};"
+	}
+	return out + strings.Join(uses, ", ") + ";"
+}
+
+// UseClause is a single imported name of a UseStmt.
+type UseClause struct {
+	Base
+	Kind  UseKind // Kind given inside a mixed group use; UseClass otherwise
+	Name  *Identifier
+	Alias *Identifier // nil if there is no 'as' alias
+}
+
+func (uc *UseClause) String() string {
+	out := uc.Name.String()
+	if uc.Kind != UseClass {
+		out = uc.Kind.String() + " " + out
+	}
+	if uc.Alias != nil {
+		out += " as " + uc.Alias.String()
+	}
+	return out
+}
+
+// AliasName returns the name the clause imports its symbol as: the alias
+// if there is one and the last segment of the name otherwise.
+func (uc *UseClause) AliasName() string {
+	if uc.Alias != nil {
+		return uc.Alias.Value
+	}
+	name := uc.Name.Value
+	return name[strings.LastIndex(name, "\\")+1:]
+}
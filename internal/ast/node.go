@@ -4,9 +4,9 @@ import "github.com/codevault-llc/php-lint/internal/token"
 
 // Node is any AST node.
 type Node interface {
-    String() string
-    Pos() token.Pos // Start position of the node
-    End() token.Pos  // End position of the node
+	String() string
+	Pos() token.Pos // Start position of the node
+	End() token.Pos // End position of the node
 }
 
 // Base is a helper struct embedded in all AST nodes to store their span.
@@ -19,12 +19,12 @@ func (b *Base) End() token.Pos { return b.E }
 
 // Stmt is any statement/declaration node.
 type Stmt interface {
-    Node
-    isStmt()
+	Node
+	isStmt()
 }
 
 // Expr is any expression node.
 type Expr interface {
-    Node
-    isExpr()
+	Node
+	isExpr()
 }
@@ -0,0 +1,116 @@
+package ast
+
+import (
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// PrefixExpr represents a unary operator applied to its operand, e.g. !$a,
+// -$a, ~$a, @foo() or ++$a.
+type PrefixExpr struct {
+	Base
+	Token    token.Token // The operator token
+	Operator string
+	Right    Expr
+}
+
+func (pe *PrefixExpr) isExpr() {}
+func (pe *PrefixExpr) String() string {
+	return "(" + pe.Operator + pe.Right.String() + ")"
+}
+
+// PostfixExpr represents $a++ or $a--.
+type PostfixExpr struct {
+	Base
+	Token    token.Token // The operator token
+	Left     Expr
+	Operator string
+}
+
+func (pe *PostfixExpr) isExpr() {}
+func (pe *PostfixExpr) String() string {
+	return "(" + pe.Left.String() + pe.Operator + ")"
+}
+
+// BinaryExpr represents an arithmetic, bitwise, logical, comparison,
+// concatenation or null-coalescing operation, e.g. $a . $b or $a ?? $b.
+type BinaryExpr struct {
+	Base
+	Token    token.Token // The operator token
+	Left     Expr
+	Operator string // As written, e.g. "AND" is kept as "and" or "AND"
+	Right    Expr
+}
+
+func (be *BinaryExpr) isExpr() {}
+func (be *BinaryExpr) String() string {
+	return "(" + be.Left.String() + " " + be.Operator + " " + be.Right.String() + ")"
+}
+
+// AssignExpr represents an assignment, e.g. $a = 1, $a .= 'x', $a = &$b or
+// the destructuring [$a, $b] = $pair.
+type AssignExpr struct {
+	Base
+	Token    token.Token // The assignment operator token
+	Left     Expr
+	Operator string
+	ByRef    bool // $a = &$b
+	Right    Expr
+}
+
+func (ae *AssignExpr) isExpr() {}
+func (ae *AssignExpr) String() string {
+	op := ae.Operator
+	if ae.ByRef {
+		op += " &"
+	} else {
+		op += " "
+	}
+	return ae.Left.String() + " " + op + ae.Right.String()
+}
+
+// TernaryExpr represents $cond ? $then : $else. Then is nil for the short
+// form $cond ?: $else.
+type TernaryExpr struct {
+	Base
+	Token     token.Token // The '?' token
+	Condition Expr
+	Then      Expr
+	Else      Expr
+}
+
+func (te *TernaryExpr) isExpr() {}
+func (te *TernaryExpr) String() string {
+	if te.Then == nil {
+		return "(" + te.Condition.String() + " ?: " + te.Else.String() + ")"
+	}
+	return "(" + te.Condition.String() + " ? " + te.Then.String() + " : " + te.Else.String() + ")"
+}
+
+// InstanceofExpr represents $a instanceof Foo. Class is an Identifier or an
+// expression evaluating to a class name or object.
+type InstanceofExpr struct {
+	Base
+	Token token.Token // The 'instanceof' token
+	Expr  Expr
+	Class Expr
+}
+
+func (ie *InstanceofExpr) isExpr() {}
+func (ie *InstanceofExpr) String() string {
+	return "(" + ie.Expr.String() + " instanceof " + ie.Class.String() + ")"
+}
+
+// CastExpr represents a type cast such as (int) $a. Type is the normalised
+// cast name: "int", "float", "string", "bool", "array", "object" or
+// "unset".
+type CastExpr struct {
+	Base
+	Token token.Token // The cast token, e.g. "(integer)"
+	Type  string
+	Expr  Expr
+}
+
+func (ce *CastExpr) isExpr() {}
+func (ce *CastExpr) String() string {
+	return "((" + ce.Type + ") " + ce.Expr.String() + ")"
+}
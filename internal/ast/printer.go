@@ -0,0 +1,1063 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Print formats node as PHP code, for nodes built by hand or changed after
+// parsing. Unlike String, which is meant for messages, it prints valid code
+// in a fixed layout: one statement per line, blocks indented by four
+// spaces and operands parenthesised only where precedence requires it.
+// Comments and the original formatting are lost; Rewrite keeps them for the
+// parts of a file it does not change.
+func Print(node Node) string {
+	p := &printer{}
+	p.node(node)
+	return p.out.String()
+}
+
+// indentUnit is one level of indentation in printed code.
+const indentUnit = "    "
+
+type printer struct {
+	out    strings.Builder
+	indent string
+	// source returns the text of a node of the file being rewritten, which
+	// is copied as written rather than printed.
+	source func(node Node) (string, bool)
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.write("\n" + p.indent)
+}
+
+// node prints n, or copies its text if it is part of the source.
+func (p *printer) node(n Node) {
+	if p.source != nil {
+		if text, ok := p.source(n); ok {
+			p.write(text)
+			return
+		}
+	}
+	switch n := n.(type) {
+	case *Program:
+		p.write("<?php")
+		for _, stmt := range n.Stmts {
+			p.newline()
+			p.node(stmt)
+		}
+		p.write("\n")
+
+	// Statements
+	case *BlockStmt:
+		p.block(n.Stmts)
+	case *ExpressionStatement:
+		p.doc(n.Doc)
+		p.node(n.Expression)
+		p.write(";")
+	case *EchoStmt:
+		p.write("echo ")
+		p.exprs(n.Expressions)
+		p.write(";")
+	case *InlineHTMLStmt:
+		p.write("?>" + n.Value + "<?php")
+	case *HaltCompilerStmt:
+		p.write("__halt_compiler();" + n.Data)
+	case *FunctionDeclStmt:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.write("function ")
+		if n.ByRef {
+			p.write("&")
+		}
+		p.node(n.Name)
+		p.signature(n.Params, n.ReturnType)
+		p.write(" ")
+		p.node(n.Body)
+	case *IfStmt:
+		p.write("if (")
+		p.node(n.Condition)
+		p.write(")")
+		p.body(n.Body, n.AltSyntax)
+		for _, elseIf := range n.ElseIfs {
+			p.clause(elseIf, n.AltSyntax)
+		}
+		if n.Else != nil {
+			p.clause(n.Else, n.AltSyntax)
+		}
+		p.altEnd("endif", n.AltSyntax)
+	case *ElseIfClause:
+		p.write("elseif (")
+		p.node(n.Condition)
+		p.write(")")
+		p.body(n.Body, false)
+	case *ElseClause:
+		p.write("else")
+		p.body(n.Body, false)
+	case *WhileStmt:
+		p.write("while (")
+		p.node(n.Condition)
+		p.write(")")
+		p.body(n.Body, n.AltSyntax)
+		p.altEnd("endwhile", n.AltSyntax)
+	case *DoWhileStmt:
+		p.write("do")
+		p.body(n.Body, false)
+		p.write(" while (")
+		p.node(n.Condition)
+		p.write(");")
+	case *ForStmt:
+		p.write("for (")
+		p.exprs(n.Init)
+		p.write("; ")
+		p.exprs(n.Condition)
+		p.write("; ")
+		p.exprs(n.Step)
+		p.write(")")
+		p.body(n.Body, n.AltSyntax)
+		p.altEnd("endfor", n.AltSyntax)
+	case *ForeachStmt:
+		p.write("foreach (")
+		p.node(n.Expr)
+		p.write(" as ")
+		if n.Key != nil {
+			p.node(n.Key)
+			p.write(" => ")
+		}
+		if n.ByRef {
+			p.write("&")
+		}
+		p.node(n.Value)
+		p.write(")")
+		p.body(n.Body, n.AltSyntax)
+		p.altEnd("endforeach", n.AltSyntax)
+	case *SwitchStmt:
+		p.write("switch (")
+		p.node(n.Subject)
+		p.write(")")
+		if n.AltSyntax {
+			p.write(":")
+		} else {
+			p.write(" {")
+		}
+		p.indented(func() {
+			for _, c := range n.Cases {
+				p.newline()
+				p.node(c)
+			}
+		})
+		p.newline()
+		if n.AltSyntax {
+			p.write("endswitch;")
+		} else {
+			p.write("}")
+		}
+	case *SwitchCase:
+		if n.Value == nil {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			p.node(n.Value)
+			p.write(":")
+		}
+		p.stmts(n.Body)
+	case *TryStmt:
+		p.write("try ")
+		p.node(n.Body)
+		for _, c := range n.Catches {
+			p.write(" ")
+			p.node(c)
+		}
+		if n.Finally != nil {
+			p.write(" finally ")
+			p.node(n.Finally)
+		}
+	case *CatchClause:
+		p.write("catch (")
+		p.list(len(n.Types), "|", func(i int) { p.node(n.Types[i]) })
+		if n.Var != nil {
+			p.write(" ")
+			p.node(n.Var)
+		}
+		p.write(") ")
+		p.node(n.Body)
+	case *ReturnStmt:
+		p.keywordStmt("return", n.Value)
+	case *BreakStmt:
+		p.keywordStmt("break", n.Levels)
+	case *ContinueStmt:
+		p.keywordStmt("continue", n.Levels)
+	case *GlobalStmt:
+		p.write("global ")
+		p.exprs(n.Vars)
+		p.write(";")
+	case *StaticStmt:
+		p.write("static ")
+		p.list(len(n.Vars), ", ", func(i int) { p.node(n.Vars[i]) })
+		p.write(";")
+	case *StaticVar:
+		p.node(n.Name)
+		p.initializer(n.Default)
+	case *UnsetStmt:
+		p.write("unset(")
+		p.exprs(n.Vars)
+		p.write(");")
+	case *DeclareStmt:
+		p.write("declare(")
+		p.list(len(n.Directives), ", ", func(i int) {
+			// Directives are conventionally written without spaces, as
+			// in declare(strict_types=1).
+			p.node(n.Directives[i].Name)
+			p.write("=")
+			p.node(n.Directives[i].Value)
+		})
+		p.write(")")
+		if n.Body == nil {
+			p.write(";")
+			return
+		}
+		p.body(n.Body, n.AltSyntax)
+		p.altEnd("enddeclare", n.AltSyntax)
+	case *ConstStmt:
+		p.doc(n.Doc)
+		p.write("const ")
+		p.list(len(n.Consts), ", ", func(i int) { p.node(n.Consts[i]) })
+		p.write(";")
+	case *GotoStmt:
+		p.write("goto ")
+		p.node(n.Label)
+		p.write(";")
+	case *LabelStmt:
+		p.node(n.Name)
+		p.write(":")
+	case *NamespaceStmt:
+		p.write("namespace")
+		if n.Name != nil {
+			p.write(" ")
+			p.node(n.Name)
+		}
+		if n.Braced {
+			p.write(" ")
+			p.block(n.Stmts)
+			return
+		}
+		p.write(";")
+		for _, stmt := range n.Stmts {
+			p.newline()
+			p.node(stmt)
+		}
+	case *UseStmt:
+		p.write("use ")
+		if n.Kind != UseClass {
+			p.write(n.Kind.String() + " ")
+		}
+		if n.Prefix != nil {
+			p.node(n.Prefix)
+			p.write(`\{`)
+		}
+		p.list(len(n.Uses), ", ", func(i int) { p.node(n.Uses[i]) })
+		if n.Prefix != nil {
+			p.write("}")
+		}
+		p.write(";")
+	case *UseClause:
+		if n.Kind != UseClass {
+			p.write(n.Kind.String() + " ")
+		}
+		p.node(n.Name)
+		if n.Alias != nil {
+			p.write(" as ")
+			p.node(n.Alias)
+		}
+
+	// Declarations
+	case *ClassDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.modifiers(n.Modifiers)
+		p.write("class")
+		if n.Name != nil {
+			p.write(" ")
+			p.node(n.Name)
+		}
+		p.classTail(n)
+	case *InterfaceDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.write("interface ")
+		p.node(n.Name)
+		p.identifiers(" extends ", n.Extends)
+		p.write(" ")
+		p.block(n.Members)
+	case *TraitDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.write("trait ")
+		p.node(n.Name)
+		p.write(" ")
+		p.block(n.Members)
+	case *EnumDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.write("enum ")
+		p.node(n.Name)
+		if n.BackingType != nil {
+			p.write(": ")
+			p.node(n.BackingType)
+		}
+		p.identifiers(" implements ", n.Implements)
+		p.write(" ")
+		p.block(n.Members)
+	case *EnumCaseDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.write("case ")
+		p.node(n.Name)
+		p.initializer(n.Value)
+		p.write(";")
+	case *MethodDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.modifiers(n.Modifiers)
+		p.write("function ")
+		if n.ByRef {
+			p.write("&")
+		}
+		p.node(n.Name)
+		p.signature(n.Params, n.ReturnType)
+		if n.Body == nil {
+			p.write(";")
+			return
+		}
+		p.write(" ")
+		p.node(n.Body)
+	case *PropertyDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		if n.Modifiers == 0 {
+			p.write("var ")
+		}
+		p.modifiers(n.Modifiers)
+		if n.Type != nil {
+			p.node(n.Type)
+			p.write(" ")
+		}
+		p.list(len(n.Props), ", ", func(i int) { p.node(n.Props[i]) })
+		p.write(";")
+	case *PropertyItem:
+		p.node(n.Name)
+		p.initializer(n.Default)
+	case *ClassConstDecl:
+		p.doc(n.Doc)
+		p.attributes(n.Attributes, true)
+		p.modifiers(n.Modifiers)
+		p.write("const ")
+		if n.Type != nil {
+			p.node(n.Type)
+			p.write(" ")
+		}
+		p.list(len(n.Consts), ", ", func(i int) { p.node(n.Consts[i]) })
+		p.write(";")
+	case *ConstItem:
+		p.node(n.Name)
+		p.initializer(n.Value)
+	case *TraitUseStmt:
+		p.write("use ")
+		p.list(len(n.Traits), ", ", func(i int) { p.node(n.Traits[i]) })
+		if len(n.Adaptations) == 0 {
+			p.write(";")
+			return
+		}
+		p.write(" {")
+		p.indented(func() {
+			for _, a := range n.Adaptations {
+				p.newline()
+				p.node(a)
+			}
+		})
+		p.newline()
+		p.write("}")
+	case *TraitAdaptation:
+		if n.Trait != nil {
+			p.node(n.Trait)
+			p.write("::")
+		}
+		p.node(n.Method)
+		if len(n.Insteadof) > 0 {
+			p.identifiers(" insteadof ", n.Insteadof)
+			p.write(";")
+			return
+		}
+		p.write(" as")
+		if n.Modifiers != 0 {
+			p.write(" " + n.Modifiers.String())
+		}
+		if n.Alias != nil {
+			p.write(" ")
+			p.node(n.Alias)
+		}
+		p.write(";")
+	case *Param:
+		p.attributes(n.Attributes, false)
+		p.modifiers(n.Modifiers)
+		if n.Type != nil {
+			p.node(n.Type)
+			p.write(" ")
+		}
+		if n.ByRef {
+			p.write("&")
+		}
+		if n.Variadic {
+			p.write("...")
+		}
+		p.node(n.Name)
+		p.initializer(n.Default)
+	case *AttributeGroup:
+		p.write("#[")
+		p.list(len(n.Attributes), ", ", func(i int) { p.node(n.Attributes[i]) })
+		p.write("]")
+	case *Attribute:
+		p.node(n.Name)
+		if len(n.Arguments) > 0 {
+			p.arguments(n.Arguments, false)
+		}
+
+	// Types
+	case *NamedType:
+		p.write(n.Name)
+	case *NullableType:
+		p.write("?")
+		p.node(n.Type)
+	case *UnionType:
+		p.list(len(n.Types), "|", func(i int) {
+			if _, ok := n.Types[i].(*IntersectionType); ok {
+				p.write("(")
+				p.node(n.Types[i])
+				p.write(")")
+				return
+			}
+			p.node(n.Types[i])
+		})
+	case *IntersectionType:
+		p.list(len(n.Types), "&", func(i int) { p.node(n.Types[i]) })
+
+	// Expressions
+	case *Identifier:
+		p.write(n.Value)
+	case *Variable:
+		p.write("$" + n.Name)
+	case *VariableVariable:
+		if _, ok := n.Name.(*Variable); ok {
+			p.write("$")
+			p.node(n.Name)
+			return
+		}
+		p.write("${")
+		p.node(n.Name)
+		p.write("}")
+	case *StringLiteral:
+		p.write(quoteSingle(n.Value))
+	case *InterpolatedString:
+		p.write(`"`)
+		for _, part := range n.Parts {
+			if lit, ok := part.(*StringLiteral); ok {
+				p.write(escapeDouble(lit.Value))
+				continue
+			}
+			p.write("{")
+			p.node(part)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ShellExecExpr:
+		p.write("`")
+		for _, part := range n.Parts {
+			if lit, ok := part.(*StringLiteral); ok {
+				p.write(shellEscaper.Replace(lit.Value))
+				continue
+			}
+			p.write("{")
+			p.node(part)
+			p.write("}")
+		}
+		p.write("`")
+	case *IntegerLiteral:
+		if n.Token.Lexeme != "" {
+			p.write(n.Token.Lexeme)
+			return
+		}
+		p.write(strconv.FormatInt(n.Value, 10))
+	case *FloatLiteral:
+		if n.Token.Lexeme != "" {
+			p.write(n.Token.Lexeme)
+			return
+		}
+		s := strconv.FormatFloat(n.Value, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		p.write(s)
+	case *ArrayLiteral:
+		open, end := "[", "]"
+		if n.Token.Kind == token.ARRAY || n.Token.Kind == token.LIST {
+			open, end = strings.ToLower(n.Token.Lexeme)+"(", ")"
+		}
+		p.write(open)
+		p.list(len(n.Items), ", ", func(i int) {
+			if n.Items[i] != nil {
+				p.node(n.Items[i])
+			}
+		})
+		p.write(end)
+	case *ArrayItem:
+		if n.Key != nil {
+			p.node(n.Key)
+			p.write(" => ")
+		}
+		if n.ByRef {
+			p.write("&")
+		}
+		if n.Unpack {
+			p.write("...")
+		}
+		p.node(n.Value)
+	case *CallExpr:
+		p.operand(n, "Function", n.Function)
+		p.arguments(n.Arguments, n.Callable)
+	case *Argument:
+		if n.Name != nil {
+			p.node(n.Name)
+			p.write(": ")
+		}
+		if n.Unpack {
+			p.write("...")
+		}
+		p.node(n.Value)
+	case *IndexExpr:
+		p.operand(n, "Left", n.Left)
+		p.write("[")
+		if n.Index != nil {
+			p.node(n.Index)
+		}
+		p.write("]")
+	case *PropertyFetchExpr:
+		p.operand(n, "Object", n.Object)
+		p.arrow(n.NullSafe)
+		p.member(n.Property)
+	case *MethodCallExpr:
+		p.operand(n, "Object", n.Object)
+		p.arrow(n.NullSafe)
+		p.member(n.Method)
+		p.arguments(n.Arguments, n.Callable)
+	case *StaticCallExpr:
+		p.operand(n, "Class", n.Class)
+		p.write("::")
+		p.member(n.Method)
+		p.arguments(n.Arguments, n.Callable)
+	case *StaticPropertyFetchExpr:
+		p.operand(n, "Class", n.Class)
+		p.write("::")
+		p.node(n.Property)
+	case *ClassConstFetchExpr:
+		p.operand(n, "Class", n.Class)
+		p.write("::")
+		p.node(n.Name)
+	case *NewExpr:
+		p.write("new ")
+		if n.AnonClass != nil {
+			p.write("class")
+			p.arguments(n.Arguments, false)
+			p.classTail(n.AnonClass)
+			return
+		}
+		p.operand(n, "Class", n.Class)
+		p.arguments(n.Arguments, false)
+	case *CloneExpr:
+		p.write("clone ")
+		p.operand(n, "Expr", n.Expr)
+	case *PrintExpr:
+		p.write("print ")
+		p.operand(n, "Expr", n.Expr)
+	case *YieldExpr:
+		p.write("yield")
+		if n.Key != nil {
+			p.write(" ")
+			p.operand(n, "Key", n.Key)
+			p.write(" =>")
+		}
+		if n.Value != nil {
+			p.write(" ")
+			p.operand(n, "Value", n.Value)
+		}
+	case *YieldFromExpr:
+		p.write("yield from ")
+		p.operand(n, "Expr", n.Expr)
+	case *ThrowExpr:
+		p.write("throw ")
+		p.node(n.Expr)
+	case *IncludeExpr:
+		keyword := strings.ToLower(n.Token.Lexeme)
+		if keyword == "" {
+			keyword = "include"
+		}
+		p.write(keyword + " ")
+		p.node(n.Expr)
+	case *PrefixExpr:
+		p.write(n.Operator)
+		p.operand(n, "Right", n.Right)
+	case *PostfixExpr:
+		p.operand(n, "Left", n.Left)
+		p.write(n.Operator)
+	case *BinaryExpr:
+		p.operand(n, "Left", n.Left)
+		p.write(" " + n.Operator + " ")
+		p.operand(n, "Right", n.Right)
+	case *AssignExpr:
+		p.node(n.Left)
+		p.write(" " + n.Operator + " ")
+		if n.ByRef {
+			p.write("&")
+		}
+		p.operand(n, "Right", n.Right)
+	case *TernaryExpr:
+		p.operand(n, "Condition", n.Condition)
+		if n.Then == nil {
+			p.write(" ?: ")
+		} else {
+			p.write(" ? ")
+			p.node(n.Then)
+			p.write(" : ")
+		}
+		p.operand(n, "Else", n.Else)
+	case *InstanceofExpr:
+		p.operand(n, "Expr", n.Expr)
+		p.write(" instanceof ")
+		p.operand(n, "Class", n.Class)
+	case *CastExpr:
+		p.write("(" + n.Type + ") ")
+		p.operand(n, "Expr", n.Expr)
+	case *MatchExpr:
+		p.write("match (")
+		p.node(n.Subject)
+		p.write(") {")
+		p.indented(func() {
+			for _, arm := range n.Arms {
+				p.newline()
+				p.node(arm)
+				p.write(",")
+			}
+		})
+		p.newline()
+		p.write("}")
+	case *MatchArm:
+		if n.Conditions == nil {
+			p.write("default")
+		} else {
+			p.exprs(n.Conditions)
+		}
+		p.write(" => ")
+		p.node(n.Body)
+	case *ClosureExpr:
+		p.attributes(n.Attributes, false)
+		if n.Static {
+			p.write("static ")
+		}
+		p.write("function ")
+		if n.ByRef {
+			p.write("&")
+		}
+		p.write("(")
+		p.list(len(n.Params), ", ", func(i int) { p.node(n.Params[i]) })
+		p.write(")")
+		if len(n.Uses) > 0 {
+			p.write(" use (")
+			p.list(len(n.Uses), ", ", func(i int) { p.node(n.Uses[i]) })
+			p.write(")")
+		}
+		if n.ReturnType != nil {
+			p.write(": ")
+			p.node(n.ReturnType)
+		}
+		p.write(" ")
+		p.node(n.Body)
+	case *ClosureUse:
+		if n.ByRef {
+			p.write("&")
+		}
+		p.node(n.Var)
+	case *ArrowFuncExpr:
+		p.attributes(n.Attributes, false)
+		if n.Static {
+			p.write("static ")
+		}
+		p.write("fn")
+		if n.ByRef {
+			p.write("&")
+		}
+		p.signature(n.Params, n.ReturnType)
+		p.write(" => ")
+		p.node(n.Expr)
+	default:
+		panic(fmt.Sprintf("ast.Print: unexpected node type %T", n))
+	}
+}
+
+func (p *printer) indented(f func()) {
+	p.indent += indentUnit
+	f()
+	p.indent = p.indent[:len(p.indent)-len(indentUnit)]
+}
+
+// stmts prints stmts on their own lines, one level deeper.
+func (p *printer) stmts(stmts []Stmt) {
+	p.indented(func() {
+		for _, stmt := range stmts {
+			p.newline()
+			p.node(stmt)
+		}
+	})
+}
+
+// block prints stmts between braces.
+func (p *printer) block(stmts []Stmt) {
+	if len(stmts) == 0 {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	p.stmts(stmts)
+	p.newline()
+	p.write("}")
+}
+
+// body prints the body of a control structure after its header: a block
+// after a space or, in the alternative syntax, the statements after a ':'.
+func (p *printer) body(b *BlockStmt, alt bool) {
+	if !alt {
+		p.write(" ")
+		p.node(b)
+		return
+	}
+	if p.source != nil {
+		// The block of the alternative syntax starts at the ':'.
+		if text, ok := p.source(b); ok {
+			p.write(text)
+			return
+		}
+	}
+	p.write(":")
+	p.stmts(b.Stmts)
+}
+
+// clause prints an elseif or else clause of an if statement.
+func (p *printer) clause(clause Node, alt bool) {
+	if !alt {
+		p.write(" ")
+		p.node(clause)
+		return
+	}
+	p.newline()
+	if p.source != nil {
+		if text, ok := p.source(clause); ok {
+			p.write(text)
+			return
+		}
+	}
+	switch c := clause.(type) {
+	case *ElseIfClause:
+		p.write("elseif (")
+		p.node(c.Condition)
+		p.write(")")
+		p.body(c.Body, true)
+	case *ElseClause:
+		p.write("else")
+		p.body(c.Body, true)
+	}
+}
+
+// altEnd closes a control structure written in the alternative syntax.
+func (p *printer) altEnd(keyword string, alt bool) {
+	if alt {
+		p.newline()
+		p.write(keyword + ";")
+	}
+}
+
+func (p *printer) keywordStmt(keyword string, value Expr) {
+	p.write(keyword)
+	if value != nil {
+		p.write(" ")
+		p.node(value)
+	}
+	p.write(";")
+}
+
+// doc prints a doc comment on its own line before a declaration.
+func (p *printer) doc(doc *phpdoc.Comment) {
+	if doc != nil {
+		p.write(doc.Text)
+		p.newline()
+	}
+}
+
+func (p *printer) list(n int, sep string, item func(i int)) {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			p.write(sep)
+		}
+		item(i)
+	}
+}
+
+func (p *printer) exprs(exprs []Expr) {
+	p.list(len(exprs), ", ", func(i int) { p.node(exprs[i]) })
+}
+
+func (p *printer) identifiers(keyword string, idents []*Identifier) {
+	if len(idents) == 0 {
+		return
+	}
+	p.write(keyword)
+	p.list(len(idents), ", ", func(i int) { p.node(idents[i]) })
+}
+
+func (p *printer) arguments(args []*Argument, callable bool) {
+	if callable {
+		p.write("(...)")
+		return
+	}
+	p.write("(")
+	p.list(len(args), ", ", func(i int) { p.node(args[i]) })
+	p.write(")")
+}
+
+func (p *printer) signature(params []*Param, returnType Type) {
+	p.write("(")
+	p.list(len(params), ", ", func(i int) { p.node(params[i]) })
+	p.write(")")
+	if returnType != nil {
+		p.write(": ")
+		p.node(returnType)
+	}
+}
+
+func (p *printer) initializer(value Expr) {
+	if value != nil {
+		p.write(" = ")
+		p.node(value)
+	}
+}
+
+func (p *printer) modifiers(mods Modifiers) {
+	if mods != 0 {
+		p.write(mods.String() + " ")
+	}
+}
+
+// attributes prints attribute groups, each on its own line before a
+// declaration or inline before a parameter or closure.
+func (p *printer) attributes(groups []*AttributeGroup, ownLine bool) {
+	for _, group := range groups {
+		p.node(group)
+		if ownLine {
+			p.newline()
+		} else {
+			p.write(" ")
+		}
+	}
+}
+
+// classTail prints a class declaration from its extends clause, which is
+// shared with anonymous classes.
+func (p *printer) classTail(cd *ClassDecl) {
+	if cd.Extends != nil {
+		p.write(" extends ")
+		p.node(cd.Extends)
+	}
+	p.identifiers(" implements ", cd.Implements)
+	p.write(" ")
+	p.block(cd.Members)
+}
+
+func (p *printer) arrow(nullSafe bool) {
+	if nullSafe {
+		p.write("?->")
+	} else {
+		p.write("->")
+	}
+}
+
+// member prints a member name, wrapping computed names in braces.
+func (p *printer) member(name Expr) {
+	switch name.(type) {
+	case *Identifier, *Variable:
+		p.node(name)
+		return
+	}
+	p.write("{")
+	p.node(name)
+	p.write("}")
+}
+
+// operand prints child, the field of parent, in parentheses if the
+// precedence of the operators requires them.
+func (p *printer) operand(parent Node, field string, child Expr) {
+	if needsParens(parent, field, child) {
+		p.write("(")
+		p.node(child)
+		p.write(")")
+		return
+	}
+	p.node(child)
+}
+
+// Operator precedence, from loosest to tightest binding. Expressions such
+// as yield, print and arrow functions, which extend as far right as they
+// can, bind loosest of all.
+const (
+	precLoose = iota
+	precOr
+	precXor
+	precAnd
+	precAssign
+	precTernary
+	precCoalesce
+	precBoolOr
+	precBoolAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precComparison
+	precConcat
+	precShift
+	precAdditive
+	precMultiplicative
+	precNot
+	precInstanceof
+	precUnary
+	precPow
+	precNew
+	precPrimary
+)
+
+var binaryPrecedence = map[string]int{
+	"or": precOr, "xor": precXor, "and": precAnd,
+	"??": precCoalesce, "||": precBoolOr, "&&": precBoolAnd,
+	"|": precBitOr, "^": precBitXor, "&": precBitAnd,
+	"==": precEquality, "!=": precEquality, "<>": precEquality,
+	"===": precEquality, "!==": precEquality, "<=>": precEquality,
+	"<": precComparison, "<=": precComparison, ">": precComparison, ">=": precComparison,
+	".": precConcat, "<<": precShift, ">>": precShift,
+	"+": precAdditive, "-": precAdditive,
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative,
+	"**": precPow,
+}
+
+func precedence(e Node) int {
+	switch e := e.(type) {
+	case *BinaryExpr:
+		return binaryPrecedence[strings.ToLower(e.Operator)]
+	case *AssignExpr:
+		return precAssign
+	case *TernaryExpr:
+		return precTernary
+	case *InstanceofExpr:
+		return precInstanceof
+	case *PrefixExpr:
+		if e.Operator == "!" {
+			return precNot
+		}
+		return precUnary
+	case *PostfixExpr, *CastExpr:
+		return precUnary
+	case *NewExpr, *CloneExpr:
+		return precNew
+	case *YieldExpr, *YieldFromExpr, *PrintExpr, *ThrowExpr, *IncludeExpr, *ArrowFuncExpr:
+		return precLoose
+	}
+	return precPrimary
+}
+
+// needsParens reports whether child, the field of parent, must be
+// parenthesised to keep its meaning.
+func needsParens(parent Node, field string, child Node) bool {
+	prec := precedence(child)
+	switch parent := parent.(type) {
+	case *BinaryExpr:
+		outer := precedence(parent)
+		if prec != outer {
+			return prec < outer
+		}
+		// Comparisons do not associate, ?? and ** associate to the
+		// right and the other operators to the left.
+		switch outer {
+		case precEquality, precComparison:
+			return true
+		case precCoalesce, precPow:
+			return field == "Left"
+		}
+		return field == "Right"
+	case *AssignExpr:
+		// Only and, or and xor bind looser than assignment on its right;
+		// yield, print and the like take the rest of it anyway.
+		return field == "Right" && prec < precAssign && prec != precLoose
+	case *PrintExpr, *YieldExpr, *YieldFromExpr:
+		// They bind tighter than and, or and xor, unlike throw, include
+		// and arrow functions.
+		return prec < precAssign && prec != precLoose
+	case *TernaryExpr:
+		return field != "Then" && prec <= precTernary
+	case *PrefixExpr:
+		_, nested := child.(*PrefixExpr) // -(-$a) is not --$a
+		return nested || prec < precedence(parent)
+	case *CastExpr:
+		return prec < precUnary
+	case *InstanceofExpr:
+		if field == "Class" {
+			return prec < precPrimary
+		}
+		return prec < precInstanceof
+	case *PostfixExpr, *CloneExpr:
+		return prec < precPrimary
+	case *CallExpr, *IndexExpr, *PropertyFetchExpr, *MethodCallExpr,
+		*StaticCallExpr, *StaticPropertyFetchExpr, *ClassConstFetchExpr:
+		if _, ok := child.(*ClosureExpr); ok {
+			return true
+		}
+		return prec < precPrimary
+	case *NewExpr:
+		// new takes a name or a variable-like expression; a call would
+		// be taken for the constructor arguments.
+		switch child.(type) {
+		case *Identifier, *Variable, *VariableVariable, *PropertyFetchExpr,
+			*StaticPropertyFetchExpr, *IndexExpr:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+var singleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func quoteSingle(s string) string {
+	return "'" + singleQuoteEscaper.Replace(s) + "'"
+}
+
+var doubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+var shellEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", `$`, `\$`)
+
+func escapeDouble(s string) string {
+	return doubleQuoteEscaper.Replace(s)
+}
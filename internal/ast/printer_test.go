@@ -0,0 +1,60 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+)
+
+func TestPrintParens(t *testing.T) {
+	// Each source is printed as it is written.
+	tests := []string{
+		"print ($a and $b);",
+		"$x = ($a or $b);",
+		"$x = print ($a xor $b);",
+		"$x = $a and $b;",
+		"throw $a or $b;",
+		"($a + $b) * $c;",
+		"$a - ($b - $c);",
+		"$a ?? $b ?? $c;",
+		"($a ?? $b) ?? $c;",
+		"-(-$a);",
+		"(!$a) instanceof B;",
+		"(function () {})();",
+		"function g() {\n    yield ($a and $b);\n    yield $k => ($a or $b);\n    yield from ($a xor $b);\n}",
+	}
+	for _, src := range tests {
+		p := parser.New(lexer.New("<?php\n" + src + "\n"))
+		program := p.ParseProgram()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("%q: %s", src, errs[0].Message)
+		}
+		if got := ast.Print(program); got != "<?php\n"+src+"\n" {
+			t.Errorf("printing %q gave %q", src, got)
+		}
+	}
+}
+
+func TestPrintStrings(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`'a\'b';`, `'a\'b';`},
+		{`"a\"b $c";`, `"a\"b {$c}";`},
+		{`"\$a";`, `'$a';`},
+		{"`ls $dir`;", "`ls {$dir}`;"},
+		{"`echo \\` \\$a`;", "`echo \\` \\$a`;"},
+	}
+	for _, tt := range tests {
+		p := parser.New(lexer.New("<?php\n" + tt.src + "\n"))
+		program := p.ParseProgram()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Fatalf("%q: %s", tt.src, errs[0].Message)
+		}
+		if got := ast.Print(program); got != "<?php\n"+tt.want+"\n" {
+			t.Errorf("printing %s gave %q, want %s", tt.src, got, tt.want)
+		}
+	}
+}
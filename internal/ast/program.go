@@ -2,18 +2,74 @@ package ast
 
 import (
 	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
 )
 
 // Program is the root node for a PHP file.
 type Program struct {
-    Base
-    Stmts []Stmt
+	Base
+	Stmts []Stmt
+
+	// Tokens is the file's complete token stream, ending with EOF, when it
+	// was parsed with a lexer created with lexer.WithTrivia. The tokens and
+	// their trivia cover every byte of the file, see Source.
+	Tokens []token.Token
 }
 
 func (p *Program) String() string {
-    var out bytes.Buffer
-    for _, s := range p.Stmts {
-        out.WriteString(s.String())
-    }
-    return out.String()
+	var out bytes.Buffer
+	for _, s := range p.Stmts {
+		out.WriteString(s.String())
+	}
+	return out.String()
+}
+
+// Source reproduces the parsed file byte for byte from its tokens. It
+// returns "" unless the program was parsed with trivia.
+func (p *Program) Source() string {
+	var out strings.Builder
+	for _, tok := range p.Tokens {
+		tok.WriteFullText(&out)
+	}
+	return out.String()
+}
+
+// NodeTokens returns the tokens that make up n, a node of the program. Nodes
+// parsed from inside a string, such as an interpolated "{$a->b}", have no
+// tokens of their own.
+func (p *Program) NodeTokens(n Node) []token.Token {
+	start, end := n.Pos().Offset, n.End().Offset
+	first := sort.Search(len(p.Tokens), func(i int) bool {
+		return p.Tokens[i].Span.Start.Offset >= start
+	})
+	last := first
+	for last < len(p.Tokens) && p.Tokens[last].Span.End.Offset <= end && p.Tokens[last].Kind != token.EOF {
+		last++
+	}
+	return p.Tokens[first:last]
+}
+
+// NodeText returns the source text of n exactly as written, including the
+// comments and whitespace inside it but not the trivia before its first
+// token or after its last one.
+func (p *Program) NodeText(n Node) string {
+	tokens := p.NodeTokens(n)
+	var out strings.Builder
+	for i, tok := range tokens {
+		if i > 0 {
+			for _, trivia := range tok.Leading {
+				out.WriteString(trivia.Text)
+			}
+		}
+		out.WriteString(tok.Lexeme)
+		if i < len(tokens)-1 {
+			for _, trivia := range tok.Trailing {
+				out.WriteString(trivia.Text)
+			}
+		}
+	}
+	return out.String()
 }
@@ -0,0 +1,464 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Edit replaces the text of a file at Span with NewText. Span is empty for
+// an insertion.
+type Edit struct {
+	Span    token.Span
+	NewText string
+}
+
+// ErrNoTokens is returned by Rewrite for a program parsed without trivia,
+// whose source cannot be reproduced.
+var ErrNoTokens = errors.New("ast: Rewrite needs a program parsed with lexer.WithTrivia")
+
+// Rewrite walks program like Inspect, calling f with a cursor at each node
+// through which f can replace the node, delete it or insert nodes next to
+// it. It returns the changes as edits to the source of program, which leave
+// the code outside the changed nodes exactly as it was; see ApplyEdits.
+// The program itself is not changed: parse the edited source to get the
+// new tree.
+//
+// New nodes are printed with Print, except for the nodes of program used in
+// them, which keep their text. Rewrite does not walk into the nodes it
+// replaced or deleted.
+func Rewrite(program *Program, f func(c *Cursor) Action) ([]Edit, error) {
+	if len(program.Tokens) == 0 {
+		return nil, ErrNoTokens
+	}
+	r := &rewriter{
+		program:  program,
+		src:      program.Source(),
+		original: map[Node]bool{},
+		f:        f,
+	}
+	Inspect(program, func(n Node, _ []Node) Action {
+		r.original[n] = true
+		return Continue
+	})
+	r.lines = []int{0}
+	for i := 0; i < len(r.src); i++ {
+		if r.src[i] == '\n' {
+			r.lines = append(r.lines, i+1)
+		}
+	}
+	r.rewrite(program, "", -1)
+	return r.edits, nil
+}
+
+// ApplyEdits applies edits made against src. Edits at the same offset are
+// applied in the order given.
+func ApplyEdits(src string, edits []Edit) (string, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Span, sorted[j].Span
+		if a.Start.Offset != b.Start.Offset {
+			return a.Start.Offset < b.Start.Offset
+		}
+		return a.End.Offset < b.End.Offset
+	})
+	var out strings.Builder
+	last := 0
+	for _, edit := range sorted {
+		start, end := edit.Span.Start.Offset, edit.Span.End.Offset
+		if start < last || end < start || end > len(src) {
+			return "", fmt.Errorf("ast: overlapping or invalid edit at %d:%d", edit.Span.Start.Line, edit.Span.Start.Col)
+		}
+		out.WriteString(src[last:start])
+		out.WriteString(edit.NewText)
+		last = end
+	}
+	out.WriteString(src[last:])
+	return out.String(), nil
+}
+
+// Cursor is the position of Rewrite in the tree, passed to its callback.
+// The callback changes the node through the cursor; a cursor must not be
+// used after the callback returns.
+type Cursor struct {
+	node      Node
+	field     string
+	index     int
+	ancestors []Node
+
+	replacement Node
+	deleted     bool
+	before      []Node
+	after       []Node
+}
+
+// Node returns the node the cursor is at.
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the parent of the node, or nil for the program.
+func (c *Cursor) Parent() Node {
+	if len(c.ancestors) == 0 {
+		return nil
+	}
+	return c.ancestors[len(c.ancestors)-1]
+}
+
+// Ancestors returns the ancestors of the node, from the program down to its
+// parent. The slice is reused once the callback returns.
+func (c *Cursor) Ancestors() []Node { return c.ancestors }
+
+// Field returns the name of the field of the parent that holds the node,
+// e.g. "Condition" or "Stmts", or "" for the program.
+func (c *Cursor) Field() string { return c.field }
+
+// Index returns the position of the node in its field if the field is a
+// list such as "Stmts" or "Arguments", or -1.
+func (c *Cursor) Index() int { return c.index }
+
+// Replace replaces the node with n.
+func (c *Cursor) Replace(n Node) {
+	c.replacement, c.deleted = n, false
+}
+
+// Delete removes the node from its list, along with the comma or the line
+// break that separates it from the next one. The statement of a body
+// without braces, as in if ($a) foo();, is replaced by an empty statement.
+// It panics if the node is not in a list.
+func (c *Cursor) Delete() {
+	c.mustBeInList("Delete")
+	c.replacement, c.deleted = nil, true
+}
+
+// InsertBefore inserts n before the node in its list. It panics if the node
+// is not in a list.
+func (c *Cursor) InsertBefore(n Node) {
+	c.mustBeInList("InsertBefore")
+	c.before = append(c.before, n)
+}
+
+// InsertAfter inserts n after the node in its list. Nodes inserted after
+// the same node end up in the order of the calls. It panics if the node is
+// not in a list.
+func (c *Cursor) InsertAfter(n Node) {
+	c.mustBeInList("InsertAfter")
+	c.after = append(c.after, n)
+}
+
+func (c *Cursor) mustBeInList(method string) {
+	if c.index < 0 {
+		panic(fmt.Sprintf("ast: Cursor.%s of a %T that is not in a list", method, c.node))
+	}
+	if c.field == "Parts" {
+		panic(fmt.Sprintf("ast: Cursor.%s of a part of an interpolated string", method))
+	}
+}
+
+type rewriter struct {
+	program   *Program
+	src       string
+	lines     []int         // Offsets of the line starts of src
+	original  map[Node]bool // The nodes of program
+	f         func(c *Cursor) Action
+	ancestors []Node
+	edits     []Edit
+}
+
+func (r *rewriter) rewrite(node Node, field string, index int) bool {
+	c := &Cursor{node: node, field: field, index: index, ancestors: r.ancestors}
+	action := r.f(c)
+	r.apply(c)
+	if action == Stop {
+		return false
+	}
+	if action == SkipChildren || c.replacement != nil || c.deleted {
+		return true
+	}
+	r.ancestors = append(r.ancestors, node)
+	ok := eachChild(node, r.rewrite)
+	r.ancestors = r.ancestors[:len(r.ancestors)-1]
+	return ok
+}
+
+// apply turns the changes made through c into edits. They stay within the
+// node and the separators next to it, so that the edits of a rewrite never
+// overlap.
+func (r *rewriter) apply(c *Cursor) {
+	if c.replacement == nil && !c.deleted && len(c.before) == 0 && len(c.after) == 0 {
+		return
+	}
+	start, end := r.offsets(c.node)
+	indent := r.indentAt(start)
+	sep := r.separator(c, indent)
+
+	if c.deleted && len(c.before) == 0 && len(c.after) == 0 {
+		if braceless(c) {
+			// The body would take the next statement: an empty statement
+			// stands in.
+			r.edit(start, end, ";")
+			return
+		}
+		start, end = r.deletion(c, start, end)
+		r.edit(start, end, "")
+		return
+	}
+	if braceless(c) {
+		// A body without braces holds a single statement, so the
+		// statements are put in braces.
+		parts := r.printAll(c.before, indent)
+		if c.replacement != nil {
+			parts = append(parts, r.print(c.replacement, indent))
+		} else if !c.deleted {
+			parts = append(parts, r.src[start:end])
+		}
+		parts = append(parts, r.printAll(c.after, indent)...)
+		r.edit(start, end, "{ "+strings.Join(parts, " ")+" }")
+		return
+	}
+
+	before := r.printAll(c.before, indent)
+	after := r.printAll(c.after, indent)
+	if c.replacement == nil && !c.deleted {
+		// Only insertions: the node, and any comment after it on the same
+		// line, stay as they are.
+		if len(before) > 0 {
+			r.edit(start, start, strings.Join(before, sep)+sep)
+		}
+		if len(after) > 0 {
+			end = r.statementEnd(c, end)
+			r.edit(end, end, sep+strings.Join(after, sep))
+		}
+		return
+	}
+
+	parts := before
+	if c.replacement != nil {
+		text := r.print(c.replacement, indent)
+		if needsParens(c.Parent(), c.field, c.replacement) && !r.parenthesized(start, end) {
+			text = "(" + text + ")"
+		}
+		parts = append(parts, text)
+	}
+	parts = append(parts, after...)
+	r.edit(start, end, strings.Join(parts, sep))
+}
+
+func (r *rewriter) printAll(nodes []Node, indent string) []string {
+	texts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		texts = append(texts, r.print(n, indent))
+	}
+	return texts
+}
+
+// print prints n for a rewrite at the given indentation.
+func (r *rewriter) print(n Node, indent string) string {
+	p := &printer{indent: indent, source: r.source}
+	p.node(n)
+	return p.out.String()
+}
+
+// source returns the text of n if it is a node of the program.
+func (r *rewriter) source(n Node) (string, bool) {
+	if !r.original[n] {
+		return "", false
+	}
+	start, end := r.offsets(n)
+	return r.src[start:end], true
+}
+
+func (r *rewriter) offsets(n Node) (int, int) {
+	start := min(n.Pos().Offset, len(r.src))
+	end := min(max(n.End().Offset, start), len(r.src))
+	return start, end
+}
+
+func (r *rewriter) edit(start, end int, text string) {
+	r.edits = append(r.edits, Edit{
+		Span:    token.Span{Start: r.pos(start), End: r.pos(end)},
+		NewText: text,
+	})
+}
+
+// pos returns the position of the byte at offset.
+func (r *rewriter) pos(offset int) token.Pos {
+	line := sort.Search(len(r.lines), func(i int) bool { return r.lines[i] > offset })
+	lineStart := r.lines[line-1]
+	return token.Pos{
+		Line:   line,
+		Col:    utf8.RuneCountInString(r.src[lineStart:offset]) + 1,
+		Offset: offset,
+	}
+}
+
+// indentAt returns the indentation of the line holding offset.
+func (r *rewriter) indentAt(offset int) string {
+	lineStart := r.lines[r.pos(offset).Line-1]
+	rest := r.src[lineStart:]
+	return rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+}
+
+// startsLine reports whether only whitespace precedes offset on its line.
+func (r *rewriter) startsLine(offset int) bool {
+	lineStart := r.lines[r.pos(offset).Line-1]
+	return strings.TrimLeft(r.src[lineStart:offset], " \t") == ""
+}
+
+// Kinds of list, by what separates their elements.
+const (
+	lineList  = iota // Statements, class members, switch cases
+	spaceList        // elseif and catch clauses, attribute groups
+	tokenList        // Arguments, array items, union types, ...
+)
+
+// list returns the kind of the list holding the node of c and, for token
+// lists, the separating token.
+func (c *Cursor) list() (int, string) {
+	switch c.field {
+	case "Stmts", "Members", "Body", "Cases", "Adaptations":
+		return lineList, ""
+	case "ElseIfs", "Catches":
+		return spaceList, ""
+	case "Attributes":
+		if _, ok := c.Parent().(*AttributeGroup); !ok {
+			return spaceList, ""
+		}
+	case "Types":
+		if _, ok := c.Parent().(*IntersectionType); ok {
+			return tokenList, "&"
+		}
+		return tokenList, "|"
+	}
+	return tokenList, ","
+}
+
+// braceless reports whether the node of c is the statement of a control
+// structure body written without braces, such as that of if ($a) foo();.
+func braceless(c *Cursor) bool {
+	block, ok := c.Parent().(*BlockStmt)
+	if !ok || c.field != "Stmts" {
+		return false
+	}
+	switch block.Token.Kind {
+	case token.LBRACE, token.COLON:
+		return false
+	}
+	return true
+}
+
+// separator returns the text put between the node of c and the nodes
+// inserted next to it.
+func (r *rewriter) separator(c *Cursor, indent string) string {
+	if c.index < 0 {
+		return ""
+	}
+	start, _ := r.offsets(c.node)
+	kind, tok := c.list()
+	switch {
+	case kind == lineList, kind == spaceList && r.startsLine(start):
+		return "\n" + indent
+	case kind == spaceList:
+		return " "
+	case tok != ",":
+		return tok
+	case r.startsLine(start):
+		return ",\n" + indent
+	}
+	return ", "
+}
+
+// statementEnd extends the end of a statement over the comments after it
+// on the same line, so that they stay with it.
+func (r *rewriter) statementEnd(c *Cursor, end int) int {
+	if kind, _ := c.list(); kind != lineList {
+		return end
+	}
+	i := r.tokenAt(end) - 1
+	if i < 0 {
+		return end
+	}
+	for _, trivia := range r.program.Tokens[i].Trailing {
+		if trivia.Kind != token.WHITESPACE {
+			end = trivia.Span.End.Offset
+			if strings.HasSuffix(trivia.Text, "\n") {
+				end--
+			}
+		}
+	}
+	return end
+}
+
+// deletion returns the text to remove to delete the node of c, spanning
+// start to end, from its list.
+func (r *rewriter) deletion(c *Cursor, start, end int) (int, int) {
+	kind, tok := c.list()
+	if kind != tokenList {
+		end = r.statementEnd(c, end)
+		lineEnd := len(r.src)
+		if i := strings.IndexByte(r.src[end:], '\n'); i >= 0 {
+			lineEnd = end + i
+		}
+		if r.startsLine(start) && strings.TrimSpace(r.src[end:lineEnd]) == "" {
+			// Remove the whole line, from the line break before it.
+			lineStart := r.lines[r.pos(start).Line-1]
+			if lineStart > 0 {
+				return lineStart - 1, lineEnd
+			}
+			return lineStart, min(lineEnd+1, len(r.src))
+		}
+		return start, end + len(r.src[end:lineEnd]) - len(strings.TrimLeft(r.src[end:lineEnd], " \t"))
+	}
+
+	tokens := r.program.Tokens
+	next := r.tokenAt(end)
+	if next < len(tokens) && tokens[next].Lexeme == tok {
+		closing := next+1 < len(tokens) && isClosing(tokens[next+1].Kind)
+		if !closing || r.separatorBefore(start, tok) < 0 {
+			// Remove up to the next element, keeping the indentation
+			// before this one.
+			if next+1 < len(tokens) {
+				return start, tokens[next+1].Span.Start.Offset
+			}
+			return start, tokens[next].Span.End.Offset
+		}
+	}
+	if prev := r.separatorBefore(start, tok); prev >= 0 {
+		return tokens[prev].Span.Start.Offset, end
+	}
+	return start, end
+}
+
+// separatorBefore returns the index of the tok token right before offset,
+// or -1.
+func (r *rewriter) separatorBefore(offset int, tok string) int {
+	prev := r.tokenAt(offset) - 1
+	if prev >= 0 && r.program.Tokens[prev].Lexeme == tok {
+		return prev
+	}
+	return -1
+}
+
+// parenthesized reports whether the text from start to end is enclosed in
+// parentheses.
+func (r *rewriter) parenthesized(start, end int) bool {
+	tokens := r.program.Tokens
+	before, after := r.tokenAt(start)-1, r.tokenAt(end)
+	return before >= 0 && after < len(tokens) &&
+		tokens[before].Kind == token.LPAREN && tokens[after].Kind == token.RPAREN
+}
+
+// tokenAt returns the index of the first token starting at or after offset.
+func (r *rewriter) tokenAt(offset int) int {
+	tokens := r.program.Tokens
+	return sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Span.Start.Offset >= offset
+	})
+}
+
+func isClosing(kind token.Kind) bool {
+	return kind == token.RPAREN || kind == token.RBRACKET || kind == token.RBRACE
+}
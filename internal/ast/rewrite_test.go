@@ -0,0 +1,122 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+)
+
+// rewrite parses src, rewrites it with f and returns the edited source.
+func rewrite(t *testing.T, src string, f func(c *ast.Cursor) ast.Action) string {
+	t.Helper()
+	p := parser.New(lexer.New(src, lexer.WithTrivia()))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: %s", src, errs[0].Message)
+	}
+	edits, err := ast.Rewrite(program, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ast.ApplyEdits(src, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// callTo reports whether n is the statement calling the function name.
+func callTo(n ast.Node, name string) bool {
+	stmt, ok := n.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	call, ok := stmt.Expression.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+func newCall(name string) ast.Stmt {
+	return &ast.ExpressionStatement{Expression: &ast.CallExpr{Function: &ast.Identifier{Value: name}}}
+}
+
+func TestRewriteDelete(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"<?php\nfoo();\nbar();\n", "<?php\nbar();\n"},
+		{"<?php\nif ($a) {\n    foo();\n    bar();\n}\n", "<?php\nif ($a) {\n    bar();\n}\n"},
+		{"<?php f(1, foo(), 2);", "<?php f(1, foo(), 2);"},
+		// The only statement of a body without braces leaves an empty
+		// statement, not the next statement as the body.
+		{"<?php\nif ($a) foo();\nbar();\n", "<?php\nif ($a) ;\nbar();\n"},
+		{"<?php\nif ($a) bar(); else foo();\nbar();\n", "<?php\nif ($a) bar(); else ;\nbar();\n"},
+		{"<?php\nif ($a) bar(); elseif ($b) foo();\n", "<?php\nif ($a) bar(); elseif ($b) ;\n"},
+		{"<?php\nwhile ($a) foo();\nbar();\n", "<?php\nwhile ($a) ;\nbar();\n"},
+		{"<?php\nfor (;;) foo();\nbar();\n", "<?php\nfor (;;) ;\nbar();\n"},
+		{"<?php\nforeach ($a as $b) foo();\nbar();\n", "<?php\nforeach ($a as $b) ;\nbar();\n"},
+		{"<?php\nif ($a):\n    foo();\nendif;\n", "<?php\nif ($a):\nendif;\n"},
+	}
+	for _, tt := range tests {
+		got := rewrite(t, tt.src, func(c *ast.Cursor) ast.Action {
+			if callTo(c.Node(), "foo") {
+				c.Delete()
+			}
+			return ast.Continue
+		})
+		if got != tt.want {
+			t.Errorf("deleting foo() from %q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteInsert(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"<?php\nfoo();\n", "<?php\nbefore();\nfoo();\nafter();\n"},
+		{"<?php\n    foo(); // why\n", "<?php\n    before();\n    foo(); // why\n    after();\n"},
+		// A body without braces gets them to hold the statements.
+		{"<?php\nif ($a) foo();\n", "<?php\nif ($a) { before(); foo(); after(); }\n"},
+	}
+	for _, tt := range tests {
+		got := rewrite(t, tt.src, func(c *ast.Cursor) ast.Action {
+			if callTo(c.Node(), "foo") {
+				c.InsertBefore(newCall("before"))
+				c.InsertAfter(newCall("after"))
+			}
+			return ast.Continue
+		})
+		if got != tt.want {
+			t.Errorf("inserting around foo() in %q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteReplaceParens(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"<?php print $x;", "<?php print ($a and $b);"},
+		{"<?php $y = $x;", "<?php $y = ($a and $b);"},
+		{"<?php function g() { yield $x; }", "<?php function g() { yield ($a and $b); }"},
+		{"<?php throw $x;", "<?php throw $a and $b;"},
+		{"<?php $y = ($x);", "<?php $y = ($a and $b);"},
+	}
+	for _, tt := range tests {
+		got := rewrite(t, tt.src, func(c *ast.Cursor) ast.Action {
+			if v, ok := c.Node().(*ast.Variable); ok && v.Name == "x" {
+				c.Replace(&ast.BinaryExpr{Left: &ast.Variable{Name: "a"}, Operator: "and", Right: &ast.Variable{Name: "b"}})
+			}
+			return ast.Continue
+		})
+		if got != tt.want {
+			t.Errorf("replacing $x in %q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
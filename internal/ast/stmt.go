@@ -4,55 +4,124 @@ import (
 	"bytes"
 	"strings"
 
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
 	"github.com/codevault-llc/php-lint/internal/token"
 )
 
 // EchoStmt represents an 'echo' statement, e.g., echo "Hello", "World";
 type EchoStmt struct {
-    Base
-    Token       token.Token // The 'echo' token
-    Expressions []Expr
+	Base
+	Token       token.Token // The 'echo' token
+	Expressions []Expr
 }
 
 func (es *EchoStmt) isStmt() {}
 func (es *EchoStmt) String() string {
-    var out bytes.Buffer
-    out.WriteString(es.Token.Lexeme + " ")
-    expressions := []string{}
-    for _, e := range es.Expressions {
-        expressions = append(expressions, e.String())
-    }
-    out.WriteString(strings.Join(expressions, ", "))
-    out.WriteString(";")
-    return out.String()
+	var out bytes.Buffer
+	out.WriteString("echo ")
+	expressions := []string{}
+	for _, e := range es.Expressions {
+		expressions = append(expressions, e.String())
+	}
+	out.WriteString(strings.Join(expressions, ", "))
+	out.WriteString(";")
+	return out.String()
 }
 
 // ExpressionStatement holds an expression.
 type ExpressionStatement struct {
-    Base
-    Token      token.Token // The first token of the expression
-    Expression Expr
+	Base
+	Token      token.Token     // The first token of the expression
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	Expression Expr
 }
 
 func (es *ExpressionStatement) isStmt() {}
 func (es *ExpressionStatement) String() string {
-    if es.Expression != nil {
-        return es.Expression.String()
-    }
-    return ""
+	if es.Expression != nil {
+		return es.Expression.String()
+	}
+	return ""
 }
 
 // FunctionDeclStmt represents a 'function' statement, e.g., function my_func($a) {}.
 type FunctionDeclStmt struct {
-    Base
-    Token token.Token // The 'function' token
-    Name  *Identifier
+	Base
+	Token      token.Token // The 'function' token
+	Attributes []*AttributeGroup
+	Doc        *phpdoc.Comment // nil if there is no doc comment
+	ByRef      bool            // function &name()
+	Name       *Identifier
+	Params     []*Param
+	ReturnType Type // nil if not declared
+	Body       *BlockStmt
 }
 
 func (fds *FunctionDeclStmt) isStmt() {}
 func (fds *FunctionDeclStmt) String() string {
-    if fds.Name != nil {
-        return "function " + fds.Name.String()
-    }
-    return "<invalid function decl>"
+	if fds.Name == nil {
+		return "<invalid function decl>"
+	}
+	var out bytes.Buffer
+	for _, attr := range fds.Attributes {
+		out.WriteString(attr.String() + " ")
+	}
+	out.WriteString("function ")
+	if fds.ByRef {
+		out.WriteString("&")
+	}
+	out.WriteString(fds.Name.String())
+	out.WriteString(paramList(fds.Params))
+	if fds.ReturnType != nil {
+		out.WriteString(": " + fds.ReturnType.String())
+	}
+	if fds.Body != nil {
+		out.WriteString(" " + fds.Body.String())
+	}
+	return out.String()
 }
+
+// BlockStmt represents a list of statements in braces, e.g. a function body.
+// The body of a control structure is always a BlockStmt, even when written
+// as a single statement or in the alternative "if (...): ... endif;" syntax;
+// Token is then the first token of the body or the ':'.
+type BlockStmt struct {
+	Base
+	Token token.Token // The '{' token
+	Stmts []Stmt
+}
+
+func (bs *BlockStmt) isStmt() {}
+func (bs *BlockStmt) String() string {
+	var out bytes.Buffer
+	out.WriteString("{ ")
+	for _, s := range bs.Stmts {
+		out.WriteString(s.String() + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// InlineHTMLStmt represents text outside of the PHP tags, e.g. the HTML of a
+// template between "?>" and "<?php".
+type InlineHTMLStmt struct {
+	Base
+	Token token.Token // The token.INLINE_HTML token
+	Value string
+}
+
+func (ih *InlineHTMLStmt) isStmt() {}
+func (ih *InlineHTMLStmt) String() string {
+	return "?>" + ih.Value + "<?php "
+}
+
+// HaltCompilerStmt represents __halt_compiler();, which ends the PHP code of
+// a file. Data holds the rest of the file, which PHP does not parse.
+type HaltCompilerStmt struct {
+	Base
+	Token token.Token // The '__halt_compiler' token
+	Data  string
+}
+
+func (hc *HaltCompilerStmt) isStmt()        {}
+func (hc *HaltCompilerStmt) String() string { return "__halt_compiler();" + hc.Data }
@@ -0,0 +1,73 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Type is a type declaration on a parameter, property or return value.
+type Type interface {
+	Node
+	isType()
+}
+
+// NamedType is a single type such as int, self, array or Foo.
+type NamedType struct {
+	Base
+	Token token.Token // The first token of the name
+	Name  string
+	// Resolved is the fully-qualified class name once the resolver has run.
+	// It stays empty for built-in types and self, parent and static.
+	Resolved string
+}
+
+func (nt *NamedType) isType()        {}
+func (nt *NamedType) String() string { return nt.Name }
+
+// NullableType is a type prefixed with '?', e.g. ?int.
+type NullableType struct {
+	Base
+	Token token.Token // The '?' token
+	Type  Type
+}
+
+func (nt *NullableType) isType()        {}
+func (nt *NullableType) String() string { return "?" + nt.Type.String() }
+
+// UnionType is a list of alternatives, e.g. int|string|null.
+type UnionType struct {
+	Base
+	Types []Type
+}
+
+func (ut *UnionType) isType() {}
+func (ut *UnionType) String() string {
+	return joinTypes(ut.Types, "|")
+}
+
+// IntersectionType is a list of types a value must satisfy together, e.g.
+// Countable&Traversable.
+type IntersectionType struct {
+	Base
+	Types []Type
+}
+
+func (it *IntersectionType) isType() {}
+func (it *IntersectionType) String() string {
+	return joinTypes(it.Types, "&")
+}
+
+// joinTypes prints a union or intersection. Intersections nested in a union
+// (DNF types) are parenthesised.
+func joinTypes(types []Type, sep string) string {
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		s := t.String()
+		if _, ok := t.(*IntersectionType); ok && sep == "|" {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, sep)
+}
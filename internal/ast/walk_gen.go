@@ -0,0 +1,736 @@
+// Code generated by genwalk; DO NOT EDIT.
+
+package ast
+
+import "fmt"
+
+// eachChild calls f for each child of node in source order, with the name
+// of the field holding it and its index if the field is a slice, or -1.
+// It returns false as soon as f does.
+func eachChild(node Node, f func(child Node, field string, index int) bool) bool {
+	switch n := node.(type) {
+	case *Argument:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *ArrayItem:
+		if n.Key != nil && !f(n.Key, "Key", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *ArrayLiteral:
+		for i, child := range n.Items {
+			if child != nil && !f(child, "Items", i) {
+				return false
+			}
+		}
+	case *ArrowFuncExpr:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		for i, child := range n.Params {
+			if child != nil && !f(child, "Params", i) {
+				return false
+			}
+		}
+		if n.ReturnType != nil && !f(n.ReturnType, "ReturnType", -1) {
+			return false
+		}
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *AssignExpr:
+		if n.Left != nil && !f(n.Left, "Left", -1) {
+			return false
+		}
+		if n.Right != nil && !f(n.Right, "Right", -1) {
+			return false
+		}
+	case *Attribute:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Arguments {
+			if child != nil && !f(child, "Arguments", i) {
+				return false
+			}
+		}
+	case *AttributeGroup:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+	case *BinaryExpr:
+		if n.Left != nil && !f(n.Left, "Left", -1) {
+			return false
+		}
+		if n.Right != nil && !f(n.Right, "Right", -1) {
+			return false
+		}
+	case *BlockStmt:
+		for i, child := range n.Stmts {
+			if child != nil && !f(child, "Stmts", i) {
+				return false
+			}
+		}
+	case *BreakStmt:
+		if n.Levels != nil && !f(n.Levels, "Levels", -1) {
+			return false
+		}
+	case *CallExpr:
+		if n.Function != nil && !f(n.Function, "Function", -1) {
+			return false
+		}
+		for i, child := range n.Arguments {
+			if child != nil && !f(child, "Arguments", i) {
+				return false
+			}
+		}
+	case *CastExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *CatchClause:
+		for i, child := range n.Types {
+			if child != nil && !f(child, "Types", i) {
+				return false
+			}
+		}
+		if n.Var != nil && !f(n.Var, "Var", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *ClassConstDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Type != nil && !f(n.Type, "Type", -1) {
+			return false
+		}
+		for i, child := range n.Consts {
+			if child != nil && !f(child, "Consts", i) {
+				return false
+			}
+		}
+	case *ClassConstFetchExpr:
+		if n.Class != nil && !f(n.Class, "Class", -1) {
+			return false
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+	case *ClassDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Extends != nil && !f(n.Extends, "Extends", -1) {
+			return false
+		}
+		for i, child := range n.Implements {
+			if child != nil && !f(child, "Implements", i) {
+				return false
+			}
+		}
+		for i, child := range n.Members {
+			if child != nil && !f(child, "Members", i) {
+				return false
+			}
+		}
+	case *CloneExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *ClosureExpr:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		for i, child := range n.Params {
+			if child != nil && !f(child, "Params", i) {
+				return false
+			}
+		}
+		for i, child := range n.Uses {
+			if child != nil && !f(child, "Uses", i) {
+				return false
+			}
+		}
+		if n.ReturnType != nil && !f(n.ReturnType, "ReturnType", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *ClosureUse:
+		if n.Var != nil && !f(n.Var, "Var", -1) {
+			return false
+		}
+	case *ConstItem:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *ConstStmt:
+		for i, child := range n.Consts {
+			if child != nil && !f(child, "Consts", i) {
+				return false
+			}
+		}
+	case *ContinueStmt:
+		if n.Levels != nil && !f(n.Levels, "Levels", -1) {
+			return false
+		}
+	case *DeclareStmt:
+		for i, child := range n.Directives {
+			if child != nil && !f(child, "Directives", i) {
+				return false
+			}
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *DoWhileStmt:
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+		if n.Condition != nil && !f(n.Condition, "Condition", -1) {
+			return false
+		}
+	case *EchoStmt:
+		for i, child := range n.Expressions {
+			if child != nil && !f(child, "Expressions", i) {
+				return false
+			}
+		}
+	case *ElseClause:
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *ElseIfClause:
+		if n.Condition != nil && !f(n.Condition, "Condition", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *EnumCaseDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *EnumDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.BackingType != nil && !f(n.BackingType, "BackingType", -1) {
+			return false
+		}
+		for i, child := range n.Implements {
+			if child != nil && !f(child, "Implements", i) {
+				return false
+			}
+		}
+		for i, child := range n.Members {
+			if child != nil && !f(child, "Members", i) {
+				return false
+			}
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil && !f(n.Expression, "Expression", -1) {
+			return false
+		}
+	case *FloatLiteral:
+	// No children.
+	case *ForStmt:
+		for i, child := range n.Init {
+			if child != nil && !f(child, "Init", i) {
+				return false
+			}
+		}
+		for i, child := range n.Condition {
+			if child != nil && !f(child, "Condition", i) {
+				return false
+			}
+		}
+		for i, child := range n.Step {
+			if child != nil && !f(child, "Step", i) {
+				return false
+			}
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *ForeachStmt:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+		if n.Key != nil && !f(n.Key, "Key", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *FunctionDeclStmt:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Params {
+			if child != nil && !f(child, "Params", i) {
+				return false
+			}
+		}
+		if n.ReturnType != nil && !f(n.ReturnType, "ReturnType", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *GlobalStmt:
+		for i, child := range n.Vars {
+			if child != nil && !f(child, "Vars", i) {
+				return false
+			}
+		}
+	case *GotoStmt:
+		if n.Label != nil && !f(n.Label, "Label", -1) {
+			return false
+		}
+	case *HaltCompilerStmt:
+	// No children.
+	case *Identifier:
+	// No children.
+	case *IfStmt:
+		if n.Condition != nil && !f(n.Condition, "Condition", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+		for i, child := range n.ElseIfs {
+			if child != nil && !f(child, "ElseIfs", i) {
+				return false
+			}
+		}
+		if n.Else != nil && !f(n.Else, "Else", -1) {
+			return false
+		}
+	case *IncludeExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *IndexExpr:
+		if n.Left != nil && !f(n.Left, "Left", -1) {
+			return false
+		}
+		if n.Index != nil && !f(n.Index, "Index", -1) {
+			return false
+		}
+	case *InlineHTMLStmt:
+	// No children.
+	case *InstanceofExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+		if n.Class != nil && !f(n.Class, "Class", -1) {
+			return false
+		}
+	case *IntegerLiteral:
+	// No children.
+	case *InterfaceDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Extends {
+			if child != nil && !f(child, "Extends", i) {
+				return false
+			}
+		}
+		for i, child := range n.Members {
+			if child != nil && !f(child, "Members", i) {
+				return false
+			}
+		}
+	case *InterpolatedString:
+		for i, child := range n.Parts {
+			if child != nil && !f(child, "Parts", i) {
+				return false
+			}
+		}
+	case *IntersectionType:
+		for i, child := range n.Types {
+			if child != nil && !f(child, "Types", i) {
+				return false
+			}
+		}
+	case *LabelStmt:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+	case *MatchArm:
+		for i, child := range n.Conditions {
+			if child != nil && !f(child, "Conditions", i) {
+				return false
+			}
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *MatchExpr:
+		if n.Subject != nil && !f(n.Subject, "Subject", -1) {
+			return false
+		}
+		for i, child := range n.Arms {
+			if child != nil && !f(child, "Arms", i) {
+				return false
+			}
+		}
+	case *MethodCallExpr:
+		if n.Object != nil && !f(n.Object, "Object", -1) {
+			return false
+		}
+		if n.Method != nil && !f(n.Method, "Method", -1) {
+			return false
+		}
+		for i, child := range n.Arguments {
+			if child != nil && !f(child, "Arguments", i) {
+				return false
+			}
+		}
+	case *MethodDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Params {
+			if child != nil && !f(child, "Params", i) {
+				return false
+			}
+		}
+		if n.ReturnType != nil && !f(n.ReturnType, "ReturnType", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *NamedType:
+	// No children.
+	case *NamespaceStmt:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Stmts {
+			if child != nil && !f(child, "Stmts", i) {
+				return false
+			}
+		}
+	case *NewExpr:
+		if n.Class != nil && !f(n.Class, "Class", -1) {
+			return false
+		}
+		if n.AnonClass != nil && !f(n.AnonClass, "AnonClass", -1) {
+			return false
+		}
+		for i, child := range n.Arguments {
+			if child != nil && !f(child, "Arguments", i) {
+				return false
+			}
+		}
+	case *NullableType:
+		if n.Type != nil && !f(n.Type, "Type", -1) {
+			return false
+		}
+	case *Param:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Type != nil && !f(n.Type, "Type", -1) {
+			return false
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Default != nil && !f(n.Default, "Default", -1) {
+			return false
+		}
+	case *PostfixExpr:
+		if n.Left != nil && !f(n.Left, "Left", -1) {
+			return false
+		}
+	case *PrefixExpr:
+		if n.Right != nil && !f(n.Right, "Right", -1) {
+			return false
+		}
+	case *PrintExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *Program:
+		for i, child := range n.Stmts {
+			if child != nil && !f(child, "Stmts", i) {
+				return false
+			}
+		}
+	case *PropertyDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Type != nil && !f(n.Type, "Type", -1) {
+			return false
+		}
+		for i, child := range n.Props {
+			if child != nil && !f(child, "Props", i) {
+				return false
+			}
+		}
+	case *PropertyFetchExpr:
+		if n.Object != nil && !f(n.Object, "Object", -1) {
+			return false
+		}
+		if n.Property != nil && !f(n.Property, "Property", -1) {
+			return false
+		}
+	case *PropertyItem:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Default != nil && !f(n.Default, "Default", -1) {
+			return false
+		}
+	case *ReturnStmt:
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *ShellExecExpr:
+		for i, child := range n.Parts {
+			if child != nil && !f(child, "Parts", i) {
+				return false
+			}
+		}
+	case *StaticCallExpr:
+		if n.Class != nil && !f(n.Class, "Class", -1) {
+			return false
+		}
+		if n.Method != nil && !f(n.Method, "Method", -1) {
+			return false
+		}
+		for i, child := range n.Arguments {
+			if child != nil && !f(child, "Arguments", i) {
+				return false
+			}
+		}
+	case *StaticPropertyFetchExpr:
+		if n.Class != nil && !f(n.Class, "Class", -1) {
+			return false
+		}
+		if n.Property != nil && !f(n.Property, "Property", -1) {
+			return false
+		}
+	case *StaticStmt:
+		for i, child := range n.Vars {
+			if child != nil && !f(child, "Vars", i) {
+				return false
+			}
+		}
+	case *StaticVar:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Default != nil && !f(n.Default, "Default", -1) {
+			return false
+		}
+	case *StringLiteral:
+	// No children.
+	case *SwitchCase:
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+		for i, child := range n.Body {
+			if child != nil && !f(child, "Body", i) {
+				return false
+			}
+		}
+	case *SwitchStmt:
+		if n.Subject != nil && !f(n.Subject, "Subject", -1) {
+			return false
+		}
+		for i, child := range n.Cases {
+			if child != nil && !f(child, "Cases", i) {
+				return false
+			}
+		}
+	case *TernaryExpr:
+		if n.Condition != nil && !f(n.Condition, "Condition", -1) {
+			return false
+		}
+		if n.Then != nil && !f(n.Then, "Then", -1) {
+			return false
+		}
+		if n.Else != nil && !f(n.Else, "Else", -1) {
+			return false
+		}
+	case *ThrowExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	case *TraitAdaptation:
+		if n.Trait != nil && !f(n.Trait, "Trait", -1) {
+			return false
+		}
+		if n.Method != nil && !f(n.Method, "Method", -1) {
+			return false
+		}
+		for i, child := range n.Insteadof {
+			if child != nil && !f(child, "Insteadof", i) {
+				return false
+			}
+		}
+		if n.Alias != nil && !f(n.Alias, "Alias", -1) {
+			return false
+		}
+	case *TraitDecl:
+		for i, child := range n.Attributes {
+			if child != nil && !f(child, "Attributes", i) {
+				return false
+			}
+		}
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		for i, child := range n.Members {
+			if child != nil && !f(child, "Members", i) {
+				return false
+			}
+		}
+	case *TraitUseStmt:
+		for i, child := range n.Traits {
+			if child != nil && !f(child, "Traits", i) {
+				return false
+			}
+		}
+		for i, child := range n.Adaptations {
+			if child != nil && !f(child, "Adaptations", i) {
+				return false
+			}
+		}
+	case *TryStmt:
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+		for i, child := range n.Catches {
+			if child != nil && !f(child, "Catches", i) {
+				return false
+			}
+		}
+		if n.Finally != nil && !f(n.Finally, "Finally", -1) {
+			return false
+		}
+	case *UnionType:
+		for i, child := range n.Types {
+			if child != nil && !f(child, "Types", i) {
+				return false
+			}
+		}
+	case *UnsetStmt:
+		for i, child := range n.Vars {
+			if child != nil && !f(child, "Vars", i) {
+				return false
+			}
+		}
+	case *UseClause:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+		if n.Alias != nil && !f(n.Alias, "Alias", -1) {
+			return false
+		}
+	case *UseStmt:
+		if n.Prefix != nil && !f(n.Prefix, "Prefix", -1) {
+			return false
+		}
+		for i, child := range n.Uses {
+			if child != nil && !f(child, "Uses", i) {
+				return false
+			}
+		}
+	case *Variable:
+	// No children.
+	case *VariableVariable:
+		if n.Name != nil && !f(n.Name, "Name", -1) {
+			return false
+		}
+	case *WhileStmt:
+		if n.Condition != nil && !f(n.Condition, "Condition", -1) {
+			return false
+		}
+		if n.Body != nil && !f(n.Body, "Body", -1) {
+			return false
+		}
+	case *YieldExpr:
+		if n.Key != nil && !f(n.Key, "Key", -1) {
+			return false
+		}
+		if n.Value != nil && !f(n.Value, "Value", -1) {
+			return false
+		}
+	case *YieldFromExpr:
+		if n.Expr != nil && !f(n.Expr, "Expr", -1) {
+			return false
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+	return true
+}
@@ -1,29 +1,89 @@
 package ast
 
-// Visitor defines the Visit method for the AST walker.
+//go:generate go run ./genwalk
+
+// Action tells Walk how to go on after a visitor has seen a node.
+type Action int
+
+const (
+	// Continue walks the children of the node, then the rest of the tree.
+	Continue Action = iota
+	// SkipChildren does not walk the children of the node entered. Leave is
+	// still called for it.
+	SkipChildren
+	// Stop ends the walk. No more Enter or Leave calls are made.
+	Stop
+)
+
+// Visitor is called by Walk when it enters a node, before its children, and
+// when it leaves it, after them. The ancestors of the node are passed from
+// the root down to its parent; the slice is reused by Walk, so a visitor
+// must copy it to keep it past the call.
 type Visitor interface {
-    Visit(node Node)
+	Enter(node Node, ancestors []Node) Action
+	Leave(node Node, ancestors []Node) Action
 }
 
-// Walk traverses an AST in depth-first order.
+// Walk traverses an AST in depth-first order, calling visitor.Enter and
+// visitor.Leave for node and every node below it. The children of a node
+// are walked in source order.
 func Walk(node Node, visitor Visitor) {
-    if node == nil || visitor == nil {
-        return
-    }
-
-    visitor.Visit(node)
-
-    switch n := node.(type) {
-    case *Program:
-        for _, stmt := range n.Stmts {
-            Walk(stmt, visitor)
-        }
-    case *ExpressionStatement:
-        Walk(n.Expression, visitor)
-    case *CallExpr:
-        Walk(n.Function, visitor)
-        for _, arg := range n.Arguments {
-            Walk(arg, visitor)
-        }
-    }
+	if node == nil || visitor == nil {
+		return
+	}
+	w := &walker{visitor: visitor}
+	w.visit = w.walkChild
+	w.walk(node)
+}
+
+// Inspect walks node like Walk, calling enter for each node before its
+// children. It is the shorthand for visitors that have nothing to do when
+// leaving a node.
+func Inspect(node Node, enter func(node Node, ancestors []Node) Action) {
+	Walk(node, inspector(enter))
+}
+
+type inspector func(node Node, ancestors []Node) Action
+
+func (f inspector) Enter(node Node, ancestors []Node) Action { return f(node, ancestors) }
+func (f inspector) Leave(node Node, ancestors []Node) Action { return Continue }
+
+// Enclosing returns the nearest of ancestors of type T, e.g. the
+// *ForeachStmt or the *ClosureExpr a node is in.
+func Enclosing[T Node](ancestors []Node) (T, bool) {
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if n, ok := ancestors[i].(T); ok {
+			return n, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+type walker struct {
+	visitor   Visitor
+	ancestors []Node
+	visit     func(child Node, field string, index int) bool // w.walkChild
+}
+
+// walk visits node and its children. It returns false if the visitor
+// stopped the walk.
+func (w *walker) walk(node Node) bool {
+	switch w.visitor.Enter(node, w.ancestors) {
+	case Stop:
+		return false
+	case SkipChildren:
+	default:
+		w.ancestors = append(w.ancestors, node)
+		ok := eachChild(node, w.visit)
+		w.ancestors = w.ancestors[:len(w.ancestors)-1]
+		if !ok {
+			return false
+		}
+	}
+	return w.visitor.Leave(node, w.ancestors) != Stop
+}
+
+func (w *walker) walkChild(child Node, field string, index int) bool {
+	return w.walk(child)
 }
@@ -0,0 +1,218 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+)
+
+func parseProgram(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: %s", src, errs[0].Message)
+	}
+	return program
+}
+
+// label names a node by its type, with the name of variables and
+// identifiers.
+func label(node ast.Node) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	switch n := node.(type) {
+	case *ast.Variable:
+		name += " $" + n.Name
+	case *ast.Identifier:
+		name += " " + n.Value
+	}
+	return name
+}
+
+// recorder records the calls Walk makes, and returns action for the nodes
+// labelled at.
+type recorder struct {
+	calls  []string
+	at     string
+	action ast.Action
+}
+
+func (r *recorder) Enter(node ast.Node, ancestors []ast.Node) ast.Action {
+	r.calls = append(r.calls, "enter "+label(node))
+	if label(node) == r.at {
+		return r.action
+	}
+	return ast.Continue
+}
+
+func (r *recorder) Leave(node ast.Node, ancestors []ast.Node) ast.Action {
+	r.calls = append(r.calls, "leave "+label(node))
+	return ast.Continue
+}
+
+func TestWalk(t *testing.T) {
+	program := parseProgram(t, "<?php $a = f($b); echo $c;")
+	tests := []struct {
+		name   string
+		at     string
+		action ast.Action
+		want   []string
+	}{
+		{"order", "", ast.Continue, []string{
+			"enter Program",
+			"enter ExpressionStatement",
+			"enter AssignExpr",
+			"enter Variable $a", "leave Variable $a",
+			"enter CallExpr",
+			"enter Identifier f", "leave Identifier f",
+			"enter Argument",
+			"enter Variable $b", "leave Variable $b",
+			"leave Argument",
+			"leave CallExpr",
+			"leave AssignExpr",
+			"leave ExpressionStatement",
+			"enter EchoStmt",
+			"enter Variable $c", "leave Variable $c",
+			"leave EchoStmt",
+			"leave Program",
+		}},
+		// The children are skipped, but the node is still left.
+		{"skip", "CallExpr", ast.SkipChildren, []string{
+			"enter Program",
+			"enter ExpressionStatement",
+			"enter AssignExpr",
+			"enter Variable $a", "leave Variable $a",
+			"enter CallExpr", "leave CallExpr",
+			"leave AssignExpr",
+			"leave ExpressionStatement",
+			"enter EchoStmt",
+			"enter Variable $c", "leave Variable $c",
+			"leave EchoStmt",
+			"leave Program",
+		}},
+		// Nothing is called after a stop, not even leave.
+		{"stop", "Variable $b", ast.Stop, []string{
+			"enter Program",
+			"enter ExpressionStatement",
+			"enter AssignExpr",
+			"enter Variable $a", "leave Variable $a",
+			"enter CallExpr",
+			"enter Identifier f", "leave Identifier f",
+			"enter Argument",
+			"enter Variable $b",
+		}},
+	}
+	for _, tt := range tests {
+		r := &recorder{at: tt.at, action: tt.action}
+		ast.Walk(program, r)
+		if !reflect.DeepEqual(r.calls, tt.want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, strings.Join(r.calls, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+// stopper stops the walk when it leaves the node labelled at.
+type stopper struct {
+	recorder
+}
+
+func (s *stopper) Leave(node ast.Node, ancestors []ast.Node) ast.Action {
+	s.recorder.Leave(node, ancestors)
+	if label(node) == s.at {
+		return ast.Stop
+	}
+	return ast.Continue
+}
+
+func TestWalkStopOnLeave(t *testing.T) {
+	program := parseProgram(t, "<?php f($a); g($b);")
+	s := &stopper{recorder{at: "ExpressionStatement"}}
+	ast.Walk(program, s)
+	want := []string{
+		"enter Program",
+		"enter ExpressionStatement",
+		"enter CallExpr",
+		"enter Identifier f", "leave Identifier f",
+		"enter Argument",
+		"enter Variable $a", "leave Variable $a",
+		"leave Argument",
+		"leave CallExpr",
+		"leave ExpressionStatement",
+	}
+	if !reflect.DeepEqual(s.calls, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(s.calls, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWalkAncestors(t *testing.T) {
+	program := parseProgram(t, "<?php function f() { foreach ($a as $b) { $c = fn() => $d; } }")
+	got := map[string]string{}
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		if v, ok := node.(*ast.Variable); ok {
+			labels := make([]string, len(ancestors))
+			for i, a := range ancestors {
+				labels[i] = label(a)
+			}
+			got[v.Name] = strings.Join(labels, " > ")
+		}
+		return ast.Continue
+	})
+	want := map[string]string{
+		"a": "Program > FunctionDeclStmt > BlockStmt > ForeachStmt",
+		"b": "Program > FunctionDeclStmt > BlockStmt > ForeachStmt",
+		"c": "Program > FunctionDeclStmt > BlockStmt > ForeachStmt > BlockStmt > ExpressionStatement > AssignExpr",
+		"d": "Program > FunctionDeclStmt > BlockStmt > ForeachStmt > BlockStmt > ExpressionStatement > AssignExpr > ArrowFuncExpr",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ancestors:\n got %q\nwant %q", got, want)
+	}
+
+	// Each node is a child of the last of its ancestors.
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		if len(ancestors) == 0 {
+			if node != program {
+				t.Errorf("%s has no ancestors", label(node))
+			}
+			return ast.Continue
+		}
+		parent, found := ancestors[len(ancestors)-1], false
+		ast.Inspect(parent, func(child ast.Node, within []ast.Node) ast.Action {
+			if len(within) == 1 && child == node {
+				found = true
+			}
+			if len(within) == 0 {
+				return ast.Continue
+			}
+			return ast.SkipChildren
+		})
+		if !found {
+			t.Errorf("%s is not a child of %s", label(node), label(parent))
+		}
+		return ast.Continue
+	})
+}
+
+func TestEnclosing(t *testing.T) {
+	program := parseProgram(t, "<?php function f() { foreach ($a as $b) { $c = fn() => $d; } }")
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		v, ok := node.(*ast.Variable)
+		if !ok || v.Name != "d" {
+			return ast.Continue
+		}
+		if fn, ok := ast.Enclosing[*ast.FunctionDeclStmt](ancestors); !ok || fn.Name.Value != "f" {
+			t.Errorf("enclosing function of $d: got %v", fn)
+		}
+		if _, ok := ast.Enclosing[*ast.ArrowFuncExpr](ancestors); !ok {
+			t.Error("$d is not found in its arrow function")
+		}
+		if _, ok := ast.Enclosing[*ast.ClassDecl](ancestors); ok {
+			t.Error("$d is found in a class")
+		}
+		return ast.Continue
+	})
+}
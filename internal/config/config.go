@@ -1,26 +1,90 @@
 package config
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/glob"
 )
 
 type Config struct {
+	// Extends names the config this one builds on: a built-in preset such
+	// as "php-lint:recommended", or the path of another config file,
+	// relative to this one.
 	Extends  string          `json:"extends"`
 	Paths    []string        `json:"paths"`
 	Excludes []string        `json:"excludes"`
 	Stubs    []string        `json:"stubs"`
-	Rules    map[string]bool `json:"rules"`
+	Rules    map[string]RuleSetting `json:"rules"`
+
+	// Root stops the search for config files in the directories above
+	// this one.
+	Root bool `json:"root,omitempty"`
+
+	// Overrides change the rules for some of the files under the config.
+	Overrides []Override `json:"overrides,omitempty"`
+
+	// CustomRules lists the files declaring custom rules, relative to the
+	// config file. Entries can be globs, e.g. "rules/*.yaml".
+	CustomRules []string `json:"custom_rules,omitempty"`
+
+	// Plugins lists the executables implementing rules out of process.
+	Plugins []PluginConfig `json:"plugins,omitempty"`
 
 	PHPVersion string `json:"php_version,omitempty"`
 }
 
-func New() *Config {
-	cfg, err := loadAndMergeConfig("config.json")
-	if err != nil {
+// Override sets rules for the files matching its globs, e.g. to relax
+// them under tests/**. Globs are relative to the config file the override
+// is in.
+type Override struct {
+	Files    []string               `json:"files"`
+	Excludes []string               `json:"excludes,omitempty"`
+	Rules    map[string]RuleSetting `json:"rules"`
+
+	dir string // The directory the globs are relative to
+}
+
+// Matches reports whether the override applies to a file.
+func (o *Override) Matches(path string) bool {
+	name := filepath.ToSlash(path)
+	if rel, err := filepath.Rel(o.dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	for _, g := range o.Excludes {
+		if glob.Match(g, name) {
+			return false
+		}
+	}
+	for _, g := range o.Files {
+		if glob.Match(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PluginConfig tells how to run a rule plugin. A relative command path is
+// relative to the config file; a bare command name is looked up in PATH.
+type PluginConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Timeout string   `json:"timeout,omitempty"` // How long a plugin has to check a file, e.g. "5s"
+}
+
+// New loads the config at path, with the configs it extends. presets
+// holds the built-in configs extends can name, see Load. Without a config
+// file, the defaults are used.
+func New(path string, presets fs.FS) (*Config, error) {
+	cfg, err := Load(path, presets)
+	if errors.Is(err, fs.ErrNotExist) {
 		cfg = &Config{}
 		cfg.Defaults()
+	} else if err != nil {
+		return nil, err
 	}
 
 	phpVersion := cfg.PHPVersion
@@ -34,8 +98,12 @@ func New() *Config {
 		Excludes: cfg.Excludes,
 		Stubs:    cfg.Stubs,
 		Rules:    cfg.Rules,
+		Root:      cfg.Root,
+		Overrides: cfg.Overrides,
+		CustomRules: cfg.CustomRules,
+		Plugins:     cfg.Plugins,
 		PHPVersion: phpVersion,
-	}
+	}, nil
 }
 
 func (cfg *Config) Defaults() {
@@ -43,11 +111,8 @@ func (cfg *Config) Defaults() {
 		cfg.PHPVersion = "8.0"
 	}
 	if cfg.Rules == nil {
-		cfg.Rules = map[string]bool{
-			"no_unused_variables": true,
-			"no_undefined_functions": true,
-			"no_undefined_classes": true,
-			"no_syntax_errors": true,
+		cfg.Rules = map[string]RuleSetting{
+			"syntax-error": {},
 		}
 	}
 	if len(cfg.Stubs) == 0 {
@@ -65,22 +130,3 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
-
-func loadAndMergeConfig(path string) (*Config, error) {
-	userBytes, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var userCfg Config
-	if err := json.Unmarshal(userBytes, &userCfg); err != nil {
-		return nil, err
-	}
-
-	// If no extends, just return the user's config
-	if userCfg.Extends == "" {
-		return &userCfg, nil
-	}
-
-	// Not implemented yet.
-	return &userCfg, nil
-}
\ No newline at end of file
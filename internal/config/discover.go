@@ -0,0 +1,125 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileNames are the names of the config files looked for in a directory,
+// the first found winning.
+var FileNames = []string{".php-lint.json", ".php-lint.yaml", ".php-lint.yml", ".php-lint.toml"}
+
+// LegacyFileName is the config read from the working directory when there
+// is no config file.
+const LegacyFileName = "config.json"
+
+// Find returns the config file nearest to dir: the first of FileNames in
+// dir or the directories above it, or else config.json in dir.
+func Find(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Join(dir, LegacyFileName)
+	}
+	for d := abs; ; {
+		if file := findIn(d); file != "" {
+			return file
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return filepath.Join(dir, LegacyFileName)
+		}
+		d = parent
+	}
+}
+
+// findIn returns the config file of a directory, or "" if it has none.
+func findIn(dir string) string {
+	for _, name := range FileNames {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+	return ""
+}
+
+// Cascade resolves the rules of each file from the config files of its
+// directory and of those above, up to one with root: true. Configs nearer
+// to the file win, and the overrides of each config apply over its own
+// rules and those of the configs above. A file under no config file gets
+// the rules of a fallback config.
+//
+// Only rules are set per file; the other settings are those of the config
+// the linter was started with.
+type Cascade struct {
+	fallback *Config
+	presets  fs.FS
+
+	mu   sync.Mutex
+	dirs map[string]dirConfigs // The configs applying in each directory
+}
+
+type dirConfigs struct {
+	configs []*Config // From the outermost
+	err     error
+}
+
+// NewCascade returns a cascade reading presets from presets, see Load.
+func NewCascade(fallback *Config, presets fs.FS) *Cascade {
+	return &Cascade{fallback: fallback, presets: presets, dirs: map[string]dirConfigs{}}
+}
+
+// Rules returns the rule settings of a file.
+func (c *Cascade) Rules(path string) (map[string]RuleSetting, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	configs, err := c.configs(filepath.Dir(path))
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 && c.fallback != nil {
+		configs = []*Config{c.fallback}
+	}
+
+	var rules map[string]RuleSetting
+	for _, cfg := range configs {
+		rules = mergeRules(rules, cfg.Rules)
+		for i := range cfg.Overrides {
+			if cfg.Overrides[i].Matches(path) {
+				rules = mergeRules(rules, cfg.Overrides[i].Rules)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// configs returns the configs applying in an absolute directory. c.mu must
+// be held.
+func (c *Cascade) configs(dir string) ([]*Config, error) {
+	if d, ok := c.dirs[dir]; ok {
+		return d.configs, d.err
+	}
+	var d dirConfigs
+	var cfg *Config
+	if file := findIn(dir); file != "" {
+		cfg, d.err = Load(file, c.presets)
+	}
+	if d.err == nil && (cfg == nil || !cfg.Root) {
+		if parent := filepath.Dir(dir); parent != dir {
+			var above []*Config
+			above, d.err = c.configs(parent)
+			d.configs = append(d.configs, above...)
+		}
+	}
+	if d.err == nil && cfg != nil {
+		d.configs = append(d.configs, cfg)
+	}
+	c.dirs[dir] = d
+	return d.configs, d.err
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/.php-lint.yaml":        "",
+		"a/b/.php-lint.toml":      "",
+		"a/b/.php-lint.json":      "{}",
+		"a/b/c/d/file.php":        "",
+		"a/e/.php-lint.json/file": "",
+	})
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"a", "a/.php-lint.yaml"},
+		// JSON comes first.
+		{"a/b", "a/b/.php-lint.json"},
+		{"a/b/c/d", "a/b/.php-lint.json"},
+		// A directory is not a config.
+		{"a/e", "a/.php-lint.yaml"},
+	}
+	for _, tt := range tests {
+		if got := Find(filepath.Join(dir, tt.dir)); got != filepath.Join(dir, tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestCascade(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"project/.php-lint.json":       `{"rules": {"a": "error", "b": "warn"}, "overrides": [{"files": ["tests/**"], "rules": {"a": "off"}}]}`,
+		"project/lib/.php-lint.yaml":   "rules:\n  b: error\n",
+		"project/tests/.php-lint.toml": "[rules]\nc = 'info'\n",
+		"project/root/.php-lint.json":  `{"root": true, "rules": {"d": "hint"}}`,
+		"project/bad/.php-lint.json":   `{"rules": {"a": "loud"}}`,
+	})
+	fallback := &Config{Rules: map[string]RuleSetting{"fallback": {Severity: SeverityWarn}}}
+	tests := []struct {
+		file  string
+		rules string // As JSON
+		err   string
+	}{
+		{"project/a.php", `{"a":"error","b":"warn"}`, ""},
+		// Nearer configs win.
+		{"project/lib/sub/a.php", `{"a":"error","b":"error"}`, ""},
+		// Overrides apply over the config of the directory below.
+		{"project/tests/a.php", `{"a":"off","b":"warn","c":"info"}`, ""},
+		// A root config stops the search.
+		{"project/root/a.php", `{"d":"hint"}`, ""},
+		{"a.php", `{"fallback":"warn"}`, ""},
+		{"project/bad/a.php", "", `$DIR/project/bad/.php-lint.json:1:17: rules.a: invalid rule severity "loud"`},
+	}
+	c := NewCascade(fallback, testPresets)
+	for _, tt := range tests {
+		rules, err := c.Rules(filepath.Join(dir, tt.file))
+		if tt.err != "" {
+			if want := strings.ReplaceAll(tt.err, "$DIR", dir); err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("%s: got error %v, want %s", tt.file, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if got, _ := json.Marshal(rules); string(got) != tt.rules {
+			t.Errorf("%s: got rules %s, want %s", tt.file, got, tt.rules)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/glob"
+)
+
+// PresetPrefix starts the names of the built-in presets a config can
+// extend, e.g. "php-lint:recommended".
+const PresetPrefix = "php-lint:"
+
+// Load reads the config at path, in JSON, YAML or TOML, and merges it over
+// the configs it extends, down the whole chain. A preset php-lint:NAME is
+// read from the file NAME.json of presets; presets can only extend other
+// presets.
+//
+// Each config overrides the one it extends:
+//   - A rule set in both takes the severity and options of the extending
+//     config. A rule set to true keeps the severity it extends, unless that
+//     is off, and a rule set without options keeps the options it extends.
+//   - Paths replace the extended ones, if any are given.
+//   - Excludes, stubs, custom rules and plugins add to the extended ones.
+//     A plugin running the same command replaces the extended one.
+//   - php_version replaces the extended one, if given.
+//   - Overrides add to the extended ones, and apply after them.
+//
+// Relative paths in a config file that is extended are made relative to
+// that file.
+func Load(path string, presets fs.FS) (*Config, error) {
+	cfg, err := loadAndMergeConfig(path, presets, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The overrides of presets are relative to the config extending them.
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for i := range cfg.Overrides {
+		if cfg.Overrides[i].dir == "" {
+			cfg.Overrides[i].dir = dir
+		}
+	}
+	return cfg, nil
+}
+
+// loadAndMergeConfig loads the config at path, given the chain of configs
+// that extend it.
+func loadAndMergeConfig(path string, presets fs.FS, chain []string) (*Config, error) {
+	name, content, err := readConfig(path, presets)
+	if err != nil && len(chain) > 0 {
+		// Only a missing config at the start of the chain means there is
+		// none.
+		return nil, fmt.Errorf("%s extends %s: %v", chain[len(chain)-1], path, err)
+	} else if err != nil {
+		return nil, err
+	}
+	for _, c := range chain {
+		if c == name {
+			return nil, fmt.Errorf("configs extend each other: %s", strings.Join(append(chain, name), " -> "))
+		}
+	}
+	chain = append(chain, name)
+
+	v, err := parseConfig(name, content)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := decodeValue(v, &cfg); err != nil {
+		return nil, err
+	}
+	_, preset := strings.CutPrefix(name, PresetPrefix)
+	for i, o := range cfg.Overrides {
+		pos := v.lookup("overrides").items[i].pos
+		if len(o.Files) == 0 {
+			return nil, errorf(pos, "overrides[%d] lists no files", i)
+		}
+		for _, g := range append(append([]string{}, o.Files...), o.Excludes...) {
+			if err := glob.Validate(g); err != nil {
+				return nil, errorf(pos, "overrides[%d]: %v", i, err)
+			}
+		}
+		if !preset {
+			cfg.Overrides[i].dir = filepath.Dir(name)
+		}
+	}
+	if !preset && len(chain) > 1 {
+		cfg.rebase(filepath.Dir(name))
+	}
+
+	if cfg.Extends == "" {
+		return &cfg, nil
+	}
+	base := cfg.Extends
+	if !strings.HasPrefix(base, PresetPrefix) {
+		if preset {
+			return nil, fmt.Errorf("%s: a preset can only extend presets, not %s", name, base)
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(name), base)
+		}
+	}
+	baseCfg, err := loadAndMergeConfig(base, presets, chain)
+	if err != nil {
+		return nil, err
+	}
+	return merge(baseCfg, &cfg), nil
+}
+
+// readConfig reads a preset or config file, and returns the name it is
+// known by in the chain: the preset name or the absolute path of the file.
+func readConfig(path string, presets fs.FS) (string, []byte, error) {
+	if preset, ok := strings.CutPrefix(path, PresetPrefix); ok {
+		content, err := fs.ReadFile(presets, preset+".json")
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, fmt.Errorf("unknown preset %q, expected one of %s", path, strings.Join(PresetNames(presets), ", "))
+		}
+		return path, content, err
+	}
+	name, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := os.ReadFile(name)
+	return name, content, err
+}
+
+// PresetNames returns the names of the presets held by presets.
+func PresetNames(presets fs.FS) []string {
+	files, _ := fs.Glob(presets, "*.json")
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = PresetPrefix + strings.TrimSuffix(file, ".json")
+	}
+	return names
+}
+
+// rebase makes the relative paths of the config relative to dir.
+func (cfg *Config) rebase(dir string) {
+	join := func(paths []string) {
+		for i, p := range paths {
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(dir, p)
+			}
+		}
+	}
+	join(cfg.Paths)
+	join(cfg.Excludes)
+	join(cfg.Stubs)
+	join(cfg.CustomRules)
+	for i, plugin := range cfg.Plugins {
+		// A bare command name is looked up in PATH.
+		if strings.ContainsRune(plugin.Command, filepath.Separator) && !filepath.IsAbs(plugin.Command) {
+			cfg.Plugins[i].Command = filepath.Join(dir, plugin.Command)
+		}
+	}
+}
+
+// merge returns cfg merged over base, the config it extends.
+func merge(base, cfg *Config) *Config {
+	merged := *cfg
+	if len(merged.Paths) == 0 {
+		merged.Paths = base.Paths
+	}
+	merged.Excludes = appendNew(base.Excludes, cfg.Excludes)
+	merged.Stubs = appendNew(base.Stubs, cfg.Stubs)
+	merged.CustomRules = appendNew(base.CustomRules, cfg.CustomRules)
+	if merged.PHPVersion == "" {
+		merged.PHPVersion = base.PHPVersion
+	}
+
+	merged.Plugins = nil
+	for _, plugin := range base.Plugins {
+		if !hasPlugin(cfg.Plugins, plugin.Command) {
+			merged.Plugins = append(merged.Plugins, plugin)
+		}
+	}
+	merged.Plugins = append(merged.Plugins, cfg.Plugins...)
+
+	// The overrides of the extending config come last, so they win.
+	merged.Overrides = append(append([]Override(nil), base.Overrides...), cfg.Overrides...)
+	merged.Rules = mergeRules(base.Rules, cfg.Rules)
+	return &merged
+}
+
+// mergeRules returns the rule settings of rules merged over those of base,
+// see Load.
+func mergeRules(base, rules map[string]RuleSetting) map[string]RuleSetting {
+	if base == nil && rules == nil {
+		return nil
+	}
+	merged := make(map[string]RuleSetting, len(base)+len(rules))
+	for name, setting := range base {
+		merged[name] = setting
+	}
+	for name, setting := range rules {
+		if extended, ok := base[name]; ok {
+			if setting.Severity == "" && extended.Enabled() {
+				setting.Severity = extended.Severity
+			}
+			if setting.Options == nil {
+				setting.Options = extended.Options
+			}
+		}
+		merged[name] = setting
+	}
+	return merged
+}
+
+// appendNew returns the paths of list followed by those of more that list
+// does not hold.
+func appendNew(list, more []string) []string {
+	merged := append([]string(nil), list...)
+	for _, p := range more {
+		found := false
+		for _, q := range merged {
+			if p == q {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func hasPlugin(plugins []PluginConfig, command string) bool {
+	for _, plugin := range plugins {
+		if plugin.Command == command {
+			return true
+		}
+	}
+	return false
+}
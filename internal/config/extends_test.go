@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testPresets = fstest.MapFS{
+	"base.json":   {Data: []byte(`{"rules": {"a": "warn", "b": ["error", {"max": 1}], "c": "off"}, "excludes": ["vendor"]}`)},
+	"strict.json": {Data: []byte(`{"extends": "php-lint:base", "rules": {"c": "error"}, "php_version": "8.1"}`)},
+	"bad.json":    {Data: []byte(`{"extends": "other.json"}`)},
+}
+
+// writeFiles writes files, by path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string // The config loaded is c.json
+		want  string            // The config loaded, in JSON, with $DIR for the directory
+		err   string
+	}{
+		{
+			name:  "preset",
+			files: map[string]string{"c.json": `{"extends": "php-lint:strict", "rules": {"a": true, "b": "warn"}}`},
+			// true keeps the severity extended and a severity alone its
+			// options.
+			want: `{"extends":"php-lint:strict","paths":null,"excludes":["vendor"],"stubs":null,"rules":{"a":"warn","b":["warn",{"max":1}],"c":"error"},"php_version":"8.1"}`,
+		},
+		{
+			name:  "turned on",
+			files: map[string]string{"c.json": `{"extends": "php-lint:base", "rules": {"c": true}}`},
+			want:  `{"extends":"php-lint:base","paths":null,"excludes":["vendor"],"stubs":null,"rules":{"a":"warn","b":["error",{"max":1}],"c":true}}`,
+		},
+		{
+			name: "file",
+			files: map[string]string{
+				"c.json":           `{"extends": "shared/base.yaml", "paths": ["src"], "excludes": ["build"], "php_version": "7.4"}`,
+				"shared/base.yaml": "paths: [lib]\nexcludes: [vendor]\nstubs: [stubs]\nphp_version: '8.2'\n",
+			},
+			// Paths in the config extended are relative to it.
+			want: `{"extends":"shared/base.yaml","paths":["src"],"excludes":["$DIR/shared/vendor","build"],"stubs":["$DIR/shared/stubs"],"rules":null,"php_version":"7.4"}`,
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"c.json": `{"extends": "d.toml"}`,
+				"d.toml": `extends = "c.json"`,
+			},
+			err: "configs extend each other: $DIR/c.json -> $DIR/d.toml -> $DIR/c.json",
+		},
+		{
+			name:  "missing",
+			files: map[string]string{"c.json": `{"extends": "d.json"}`},
+			err:   "$DIR/c.json extends $DIR/d.json: open $DIR/d.json: no such file or directory",
+		},
+		{
+			name:  "unknown preset",
+			files: map[string]string{"c.json": `{"extends": "php-lint:none"}`},
+			err:   `$DIR/c.json extends php-lint:none: unknown preset "php-lint:none", expected one of php-lint:bad, php-lint:base, php-lint:strict`,
+		},
+		{
+			name:  "preset extending a file",
+			files: map[string]string{"c.json": `{"extends": "php-lint:bad"}`},
+			err:   "php-lint:bad: a preset can only extend presets, not other.json",
+		},
+		{
+			name:  "override without files",
+			files: map[string]string{"c.json": `{"overrides": [{"rules": {}}]}`},
+			err:   "$DIR/c.json:1:16: overrides[0] lists no files",
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		writeFiles(t, dir, tt.files)
+		cfg, err := Load(filepath.Join(dir, "c.json"), testPresets)
+		if tt.err != "" {
+			if want := strings.ReplaceAll(tt.err, "$DIR", dir); err == nil || err.Error() != want {
+				t.Errorf("%s: got error %v, want %s", tt.name, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got, _ := json.Marshal(cfg)
+		if want := strings.ReplaceAll(tt.want, "$DIR", dir); string(got) != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
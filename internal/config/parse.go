@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseConfig parses a config file, in YAML if its name ends with .yaml or
+// .yml, in TOML if it ends with .toml and in JSON otherwise.
+func parseConfig(name string, content []byte) (*value, error) {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		return parseYAML(name, content)
+	case ".toml":
+		return parseTOML(name, content)
+	}
+	return parseJSON(name, content)
+}
+
+// position returns the position of a byte offset in a file.
+func position(name string, content []byte, offset int) Pos {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return Pos{File: name, Line: line, Col: col}
+}
+
+func parseJSON(name string, content []byte) (*value, error) {
+	p := &jsonParser{name: name, content: content, decoder: json.NewDecoder(bytes.NewReader(content))}
+	p.decoder.UseNumber()
+	v, err := p.parse()
+	if err == nil {
+		if _, err = p.next(); err != io.EOF {
+			err = errorf(p.pos, "unexpected data after the config")
+		} else {
+			err = nil
+		}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, errorf(position(name, content, int(syntaxErr.Offset)), "%s", strings.TrimPrefix(syntaxErr.Error(), "json: "))
+	}
+	if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errorf(position(name, content, len(content)), "unexpected end of the config")
+	}
+	return v, err
+}
+
+// jsonParser builds the values of a JSON file from the tokens of
+// encoding/json, which does not tell where values are.
+type jsonParser struct {
+	name    string
+	content []byte
+	decoder *json.Decoder
+	pos     Pos // The position of the last token
+}
+
+func (p *jsonParser) next() (json.Token, error) {
+	// The decoder is past the previous token; the next one starts after
+	// the blanks and separators following it.
+	offset := int(p.decoder.InputOffset())
+	for offset < len(p.content) && strings.IndexByte(" \t\r\n,:", p.content[offset]) >= 0 {
+		offset++
+	}
+	p.pos = position(p.name, p.content, offset)
+	return p.decoder.Token()
+}
+
+func (p *jsonParser) parse() (*value, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	v := &value{pos: p.pos}
+	switch tok := tok.(type) {
+	case nil:
+		v.kind = nullValue
+	case bool:
+		v.kind, v.text = boolValue, strconv.FormatBool(tok)
+	case json.Number:
+		v.kind, v.text = numberValue, tok.String()
+	case string:
+		v.kind, v.text = stringValue, tok
+	case json.Delim:
+		if tok == '[' {
+			v.kind = listValue
+			for p.decoder.More() {
+				item, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				v.items = append(v.items, item)
+			}
+		} else {
+			v.kind = objectValue
+			for p.decoder.More() {
+				key, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				f := field{key: key.(string), pos: p.pos}
+				if f.value, err = p.parse(); err != nil {
+					return nil, err
+				}
+				if err := v.add(f); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The closing delimiter.
+		if _, err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func parseYAML(name string, content []byte) (*value, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(doc.Content) == 0 {
+		// An empty file.
+		return &value{kind: objectValue, pos: Pos{File: name, Line: 1, Col: 1}}, nil
+	}
+	return yamlValue(name, doc.Content[0])
+}
+
+func yamlValue(name string, node *yaml.Node) (*value, error) {
+	v := &value{pos: Pos{File: name, Line: node.Line, Col: node.Column}}
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(name, node.Alias)
+	case yaml.SequenceNode:
+		v.kind = listValue
+		for _, n := range node.Content {
+			item, err := yamlValue(name, n)
+			if err != nil {
+				return nil, err
+			}
+			v.items = append(v.items, item)
+		}
+	case yaml.MappingNode:
+		v.kind = objectValue
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, errorf(Pos{File: name, Line: key.Line, Col: key.Column}, "keys must be strings")
+			}
+			item, err := yamlValue(name, node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if err := v.add(field{key: key.Value, pos: Pos{File: name, Line: key.Line, Col: key.Column}, value: item}); err != nil {
+				return nil, err
+			}
+		}
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			v.kind = nullValue
+		case "!!bool":
+			var b bool
+			if err := node.Decode(&b); err != nil {
+				return nil, errorf(v.pos, "%v", err)
+			}
+			v.kind, v.text = boolValue, strconv.FormatBool(b)
+		case "!!int", "!!float":
+			var f float64
+			if err := node.Decode(&f); err != nil {
+				return nil, errorf(v.pos, "%v", err)
+			}
+			v.kind, v.text = numberValue, strconv.FormatFloat(f, 'g', -1, 64)
+		default:
+			v.kind, v.text = stringValue, node.Value
+		}
+	}
+	return v, nil
+}
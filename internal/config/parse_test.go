@@ -0,0 +1,89 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	// The same config in each format.
+	want := `{"extends":["recommended"],"rules":{"a":"error","b":{"severity":"warn","options":{"max":1000,"hex":16,"ratio":0.5,"tab":"x\ty"}}},"overrides":[{"files":["*.php"]},{"files":["b/*.php"],"rules":{"a":"off"}}]}`
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"c.json", `{
+  "extends": ["recommended"],
+  "rules": {"a": "error", "b": {"severity": "warn", "options": {"max": 1000, "hex": 16, "ratio": 0.5, "tab": "x\ty"}}},
+  "overrides": [{"files": ["*.php"]}, {"files": ["b/*.php"], "rules": {"a": "off"}}]
+}`},
+		{"c.yaml", `extends: [recommended]
+rules:
+  a: error
+  b:
+    severity: warn
+    options: {max: 1000, hex: 0x10, ratio: 0.5, tab: "x\ty"}
+overrides:
+  - files: ["*.php"]
+  - files: [b/*.php]
+    rules: {a: "off"}
+`},
+		{"c.toml", `extends = ["recommended"] # A comment
+[rules]
+a = "error"
+b = { severity = 'warn', options = { max = 1_000, hex = 0x10, ratio = 5e-1, tab = "x\ty" } }
+
+[[overrides]]
+files = ["*.php"]
+
+[[overrides]]
+files = ["""b/*.php"""]
+rules.a = "off"
+`},
+	}
+	for _, tt := range tests {
+		v, err := parseConfig(tt.name, []byte(tt.content))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := string(v.json()); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, want)
+		}
+		// Errors point at the keys.
+		if got := v.lookup("rules").fields[1].pos; got.Line < 2 || got.Col == 0 {
+			t.Errorf("%s: rule b at %s", tt.name, got)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		// Each format rejects keys set twice.
+		{"c.json", "{\"a\": 1,\n \"a\": 2}", "c.json:2:2: key a is already defined"},
+		{"c.json", `{"r": {"b": 1, "b": 2}}`, "c.json:1:16: key b is already defined"},
+		{"c.yaml", "a: 1\na: 2", "c.yaml:2:1: key a is already defined"},
+		{"c.yaml", "r: {b: 1, b: 2}", "c.yaml:1:11: key b is already defined"},
+		{"c.toml", "a = 1\na = 2", "c.toml:2:1: key a is already defined"},
+		{"c.toml", "[r]\nb = 1\nb = 2", "c.toml:3:1: key b is already defined"},
+		{"c.toml", "r.b = 1\n[r]", "c.toml:2:2: table r already exists as defined by a dotted key"},
+		{"c.toml", "[t]\n[t]", "c.toml:2:2: table t already exists"},
+		// Values JSON cannot hold.
+		{"c.toml", "a = 1979-05-27", "c.toml:1:5: dates and times are not supported"},
+		{"c.toml", "a = -inf", "c.toml:1:5: bad number -inf"},
+		// Syntax errors.
+		{"c.json", `{"a": 1,}`, "c.json:1:9: invalid character ',' looking for beginning of value"},
+		{"c.json", `{"a": 1`, "c.json:1:8: unexpected end of JSON input"},
+		{"c.toml", "a = [1,\n", "c.toml:1:8: array is incomplete"},
+		{"c.toml", "a = 1 2", "c.toml:1:7: expected newline but got U+0032 '2'"},
+	}
+	for _, tt := range tests {
+		_, err := parseConfig(tt.name, []byte(tt.content))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s %q: got error %v, want %s", tt.name, tt.content, err, tt.err)
+		}
+	}
+}
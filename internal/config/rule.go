@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Severities a rule can be set to. SeverityOff disables the rule.
+const (
+	SeverityOff   = "off"
+	SeverityHint  = "hint"
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+var diagnosticSeverities = map[string]protocol.DiagnosticSeverity{
+	SeverityError: protocol.DiagnosticSeverityError,
+	SeverityWarn:  protocol.DiagnosticSeverityWarning,
+	SeverityInfo:  protocol.DiagnosticSeverityInformation,
+	SeverityHint:  protocol.DiagnosticSeverityHint,
+}
+
+// RuleSetting is how the config sets up a rule. Like in ESLint, it is
+// written as a severity, "off", "hint", "info", "warn" or "error", where 0,
+// 1 and 2 also stand for off, warn and error, or as an array of a severity
+// and the rule's options, e.g. ["error", {"ignore": ["apcu_fetch"]}]. true
+// and false turn the rule on with its own severity, and off, also in an
+// array.
+type RuleSetting struct {
+	Severity string          // The severity set, or "" for the rule's own
+	Options  json.RawMessage // The options of the rule, nil if none are set
+
+	pos Pos // Where the setting is in the config
+}
+
+// Pos returns where the rule is set in the config, to report errors in its
+// options.
+func (s RuleSetting) Pos() Pos {
+	return s.pos
+}
+
+func (s *RuleSetting) setPos(pos Pos) {
+	s.pos = pos
+}
+
+// Enabled reports whether the rule is on.
+func (s RuleSetting) Enabled() bool {
+	return s.Severity != SeverityOff
+}
+
+// DiagnosticSeverity returns the severity the issues of the rule are
+// reported with, or 0 if the rule keeps its own.
+func (s RuleSetting) DiagnosticSeverity() protocol.DiagnosticSeverity {
+	return diagnosticSeverities[s.Severity]
+}
+
+func (s *RuleSetting) UnmarshalJSON(data []byte) error {
+	var setting []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &setting); err != nil {
+			return err
+		}
+		if len(setting) == 0 || len(setting) > 2 {
+			return fmt.Errorf("a rule set with an array takes a severity and options")
+		}
+	} else {
+		setting = []json.RawMessage{data}
+	}
+
+	severity, err := parseSeverity(setting[0])
+	if err != nil {
+		return err
+	}
+	*s = RuleSetting{Severity: severity}
+	if len(setting) == 2 && !bytes.Equal(bytes.TrimSpace(setting[1]), []byte("null")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(setting[1]), []byte("{")) {
+			return fmt.Errorf("the options of a rule must be an object")
+		}
+		s.Options = setting[1]
+	}
+	return nil
+}
+
+func (s RuleSetting) MarshalJSON() ([]byte, error) {
+	// The rule's own severity has no name; true keeps it.
+	var severity any = s.Severity
+	if s.Severity == "" {
+		severity = true
+	}
+	if s.Options == nil {
+		return json.Marshal(severity)
+	}
+	return json.Marshal([]any{severity, s.Options})
+}
+
+func parseSeverity(data json.RawMessage) (string, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "", nil
+		}
+		return SeverityOff, nil
+	case float64:
+		switch v {
+		case 0:
+			return SeverityOff, nil
+		case 1:
+			return SeverityWarn, nil
+		case 2:
+			return SeverityError, nil
+		}
+	case string:
+		if _, ok := diagnosticSeverities[v]; ok || v == SeverityOff {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("invalid rule severity %s, expected \"off\", \"hint\", \"info\", \"warn\" or \"error\"", data)
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRuleSetting(t *testing.T) {
+	tests := []struct {
+		json     string
+		severity string
+		options  string // The options kept, as written
+		err      string
+	}{
+		// A severity alone.
+		{`"error"`, SeverityError, "", ""},
+		{`"warn"`, SeverityWarn, "", ""},
+		{`"info"`, SeverityInfo, "", ""},
+		{`"hint"`, SeverityHint, "", ""},
+		{`"off"`, SeverityOff, "", ""},
+		{`0`, SeverityOff, "", ""},
+		{`1`, SeverityWarn, "", ""},
+		{`2`, SeverityError, "", ""},
+		{`true`, "", "", ""},
+		{`false`, SeverityOff, "", ""},
+		// A severity and options.
+		{`["error", {"ignore": ["apcu_fetch"]}]`, SeverityError, `{"ignore": ["apcu_fetch"]}`, ""},
+		{`[true, {"max": 3}]`, "", `{"max": 3}`, ""},
+		{`[1, {}]`, SeverityWarn, `{}`, ""},
+		{`["warn"]`, SeverityWarn, "", ""},
+		{`["warn", null]`, SeverityWarn, "", ""},
+		// Errors.
+		{`"fatal"`, "", "", `invalid rule severity "fatal", expected "off", "hint", "info", "warn" or "error"`},
+		{`3`, "", "", `invalid rule severity 3, expected "off", "hint", "info", "warn" or "error"`},
+		{`"Error"`, "", "", `invalid rule severity "Error", expected "off", "hint", "info", "warn" or "error"`},
+		{`{"ignore": []}`, "", "", `invalid rule severity {"ignore": []}, expected "off", "hint", "info", "warn" or "error"`},
+		{`[]`, "", "", "a rule set with an array takes a severity and options"},
+		{`["error", {}, {}]`, "", "", "a rule set with an array takes a severity and options"},
+		{`["error", ["apcu_fetch"]]`, "", "", "the options of a rule must be an object"},
+		{`["error", "x"]`, "", "", "the options of a rule must be an object"},
+		{`[{"max": 3}]`, "", "", `invalid rule severity {"max": 3}, expected "off", "hint", "info", "warn" or "error"`},
+	}
+	for _, tt := range tests {
+		var s RuleSetting
+		err := s.UnmarshalJSON([]byte(tt.json))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: got error %v, want %s", tt.json, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if s.Severity != tt.severity || string(s.Options) != tt.options {
+			t.Errorf("%s: got severity %q and options %s, want %q and %s", tt.json, s.Severity, s.Options, tt.severity, tt.options)
+		}
+	}
+}
+
+func TestRuleSettingMarshal(t *testing.T) {
+	tests := []struct {
+		setting RuleSetting
+		want    string
+	}{
+		{RuleSetting{Severity: SeverityError}, `"error"`},
+		{RuleSetting{Severity: SeverityOff}, `"off"`},
+		{RuleSetting{}, `true`},
+		{RuleSetting{Severity: SeverityWarn, Options: json.RawMessage(`{"max": 3}`)}, `["warn",{"max":3}]`},
+		{RuleSetting{Options: json.RawMessage(`{}`)}, `[true,{}]`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.setting)
+		if err != nil {
+			t.Errorf("%+v: %v", tt.setting, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%+v: got %s, want %s", tt.setting, got, tt.want)
+		}
+		// What is marshalled reads back the same.
+		var back RuleSetting
+		if err := json.Unmarshal(got, &back); err != nil || back.Severity != tt.setting.Severity {
+			t.Errorf("%s: read back as %+v, %v", got, back, err)
+		}
+	}
+}
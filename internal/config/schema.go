@@ -0,0 +1,144 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// RuleSchema describes a rule for the schema of the config.
+type RuleSchema struct {
+	Name        string
+	Description string
+	Options     any // A pointer to the options struct of the rule, or nil
+}
+
+// settingDescriptions describe the settings in the schema, by their path.
+var settingDescriptions = map[string]string{
+	"extends":            "The config this one builds on: a preset or the path of a config file, relative to this one.",
+	"paths":              "The files and directories linted by default.",
+	"excludes":           "The files and directories not linted.",
+	"stubs":              "The PHP files or directories declaring the functions and classes the code uses.",
+	"rules":              "The rules to run, with their severity and options.",
+	"root":               "Stops the search for config files in the directories above this one.",
+	"overrides":          "Rules for the files matching some globs.",
+	"overrides.files":    "Globs of the files the override applies to, relative to the config file.",
+	"overrides.excludes": "Globs of the files the override does not apply to.",
+	"overrides.rules":    "The rules set for the files.",
+	"custom_rules":       "Files declaring custom rules, relative to the config file.",
+	"plugins":            "Executables implementing rules.",
+	"plugins.command":    "The executable, relative to the config file if it has a slash, else looked up in PATH.",
+	"plugins.args":       "The arguments of the command.",
+	"plugins.timeout":    "How long the plugin has to check a file, such as \"5s\".",
+	"php_version":        "The PHP version the code is parsed for, such as \"8.2\".",
+}
+
+// Schema returns the JSON Schema of the config files, for editors to
+// complete and check them, given the rules and presets there are.
+func Schema(rules []RuleSchema, presets []string) ([]byte, error) {
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	ruleProperties := map[string]any{}
+	for _, rule := range rules {
+		var options map[string]any
+		if rule.Options != nil {
+			options = typeSchema(reflect.TypeOf(rule.Options).Elem(), join("rules", rule.Name))
+		}
+		setting := ruleSettingSchema(options)
+		setting["description"] = rule.Description
+		ruleProperties[rule.Name] = setting
+	}
+
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "php-lint config"
+	schema["definitions"] = map[string]any{
+		"rules": map[string]any{
+			"type":       "object",
+			"properties": ruleProperties,
+			// Custom rules and those of plugins are known once loaded.
+			"additionalProperties": ruleSettingSchema(nil),
+		},
+	}
+	extends := schema["properties"].(map[string]any)["extends"].(map[string]any)
+	extends["anyOf"] = []any{
+		map[string]any{"enum": presets},
+		map[string]any{"type": "string"},
+	}
+	delete(extends, "type")
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// ruleSettingSchema returns the schema of the setting of a rule, given
+// that of its options, nil if it takes none.
+func ruleSettingSchema(options map[string]any) map[string]any {
+	severity := map[string]any{
+		"anyOf": []any{
+			map[string]any{"enum": []any{SeverityOff, SeverityHint, SeverityInfo, SeverityWarn, SeverityError}},
+			map[string]any{"enum": []any{0, 1, 2}},
+			map[string]any{"type": "boolean"},
+		},
+	}
+	if options == nil {
+		options = map[string]any{"type": "null"}
+	}
+	return map[string]any{
+		"anyOf": []any{
+			severity,
+			map[string]any{
+				"type":            "array",
+				"items":           []any{severity, options},
+				"minItems":        1,
+				"additionalItems": false,
+			},
+		},
+	}
+}
+
+// typeSchema returns the schema of the values a Go type is decoded from;
+// path is that of the setting, to find its description.
+func typeSchema(t reflect.Type, path string) map[string]any {
+	schema := map[string]any{}
+	if description := settingDescriptions[path]; description != "" {
+		schema["description"] = description
+	}
+	switch {
+	case t == reflect.TypeOf(map[string]RuleSetting(nil)):
+		schema["$ref"] = "#/definitions/rules"
+		return schema
+	case t.Kind() == reflect.String:
+		schema["type"] = "string"
+	case t.Kind() == reflect.Bool:
+		schema["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema["type"] = "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema["type"] = "number"
+	case t.Kind() == reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), path)
+		// Items share the description of the list.
+		delete(schema["items"].(map[string]any), "description")
+	case t.Kind() == reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), "")
+	case t.Kind() == reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := jsonName(f)
+			property := typeSchema(f.Type, join(path, name))
+			// Options describe themselves with a description tag.
+			if description := f.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			properties[name] = property
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// parseTOML parses a config in TOML. It reads all of TOML but dates and
+// times, which no setting takes.
+func parseTOML(name string, content []byte) (*value, error) {
+	// The decoder checks the whole document, keys and tables defined twice
+	// included; the parser then gives the positions of its keys and values.
+	var doc map[string]any
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, col := decodeErr.Position()
+			return nil, errorf(Pos{File: name, Line: line, Col: col}, "%s", strings.TrimPrefix(decodeErr.Error(), "toml: "))
+		}
+		return nil, fmt.Errorf("%s: %s", name, strings.TrimPrefix(err.Error(), "toml: "))
+	}
+
+	r := &tomlReader{name: name, content: content}
+	r.parser.Reset(content)
+	root := &value{kind: objectValue, pos: Pos{File: name, Line: 1, Col: 1}}
+	table := root
+	for r.parser.NextExpression() {
+		expr := r.parser.Expression()
+		var err error
+		switch expr.Kind {
+		case unstable.Table:
+			table, err = r.descend(root, tomlKeys(expr))
+		case unstable.ArrayTable:
+			table, err = r.appendTable(root, tomlKeys(expr))
+		case unstable.KeyValue:
+			err = r.keyValue(table, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := r.parser.Error(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return root, nil
+}
+
+// tomlReader builds the values of a TOML file from the expressions of its
+// parser, in order and with their positions.
+type tomlReader struct {
+	name    string
+	content []byte
+	parser  unstable.Parser
+}
+
+// pos returns the position of node, or at if the parser does not tell.
+func (r *tomlReader) pos(node *unstable.Node, at Pos) Pos {
+	if node.Raw.Length == 0 {
+		return at
+	}
+	return position(r.name, r.content, int(node.Raw.Offset))
+}
+
+// tomlKeys returns the parts of the key of a table header or key/value.
+func tomlKeys(expr *unstable.Node) []*unstable.Node {
+	var keys []*unstable.Node
+	for it := expr.Key(); it.Next(); {
+		keys = append(keys, it.Node())
+	}
+	return keys
+}
+
+// descend returns the table named by dotted keys under table, creating the
+// tables that do not exist. A key naming an array of tables stands for its
+// last table.
+func (r *tomlReader) descend(table *value, keys []*unstable.Node) (*value, error) {
+	for _, k := range keys {
+		key, pos := string(k.Data), r.pos(k, table.pos)
+		next := table.lookup(key)
+		switch {
+		case next == nil:
+			next = &value{kind: objectValue, pos: pos}
+			table.fields = append(table.fields, field{key: key, pos: pos, value: next})
+		case next.kind == listValue && len(next.items) > 0 && next.items[len(next.items)-1].kind == objectValue:
+			next = next.items[len(next.items)-1]
+		case next.kind != objectValue:
+			return nil, errorf(pos, "%s is not a table", key)
+		}
+		table = next
+	}
+	return table, nil
+}
+
+// appendTable adds a table to the array of tables named by keys, for a
+// [[header]], and returns it.
+func (r *tomlReader) appendTable(root *value, keys []*unstable.Node) (*value, error) {
+	table, err := r.descend(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	key, pos := string(last.Data), r.pos(last, table.pos)
+	list := table.lookup(key)
+	if list == nil {
+		list = &value{kind: listValue, pos: pos}
+		table.fields = append(table.fields, field{key: key, pos: pos, value: list})
+	} else if list.kind != listValue {
+		return nil, errorf(pos, "%s is not an array of tables", key)
+	}
+	item := &value{kind: objectValue, pos: pos}
+	list.items = append(list.items, item)
+	return item, nil
+}
+
+func (r *tomlReader) keyValue(table *value, expr *unstable.Node) error {
+	keys := tomlKeys(expr)
+	table, err := r.descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	key, pos := string(last.Data), r.pos(last, table.pos)
+	v, err := r.value(expr.Value(), pos)
+	if err != nil {
+		return err
+	}
+	table.fields = append(table.fields, field{key: key, pos: pos, value: v})
+	return nil
+}
+
+// value returns the value of node; at is where it is when the parser does
+// not tell, as for arrays.
+func (r *tomlReader) value(node *unstable.Node, at Pos) (*value, error) {
+	v := &value{pos: r.pos(node, at)}
+	text := string(node.Data)
+	switch node.Kind {
+	case unstable.String:
+		v.kind, v.text = stringValue, text
+	case unstable.Bool:
+		v.kind, v.text = boolValue, text
+	case unstable.Integer:
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 0, 64)
+		if err != nil {
+			return nil, errorf(v.pos, "bad number %s", text)
+		}
+		v.kind, v.text = numberValue, strconv.FormatInt(n, 10)
+	case unstable.Float:
+		// JSON has no infinity or NaN.
+		f, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+		if err != nil || strings.Contains(text, "inf") || strings.Contains(text, "nan") {
+			return nil, errorf(v.pos, "bad number %s", text)
+		}
+		v.kind, v.text = numberValue, strconv.FormatFloat(f, 'g', -1, 64)
+	case unstable.Array:
+		v.kind = listValue
+		for it := node.Children(); it.Next(); {
+			item, err := r.value(it.Node(), v.pos)
+			if err != nil {
+				return nil, err
+			}
+			v.items = append(v.items, item)
+		}
+	case unstable.InlineTable:
+		v.kind = objectValue
+		for it := node.Children(); it.Next(); {
+			if err := r.keyValue(v, it.Node()); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, errorf(v.pos, "dates and times are not supported")
+	}
+	return v, nil
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Pos is a position in a config file.
+type Pos struct {
+	File string
+	Line int // From 1, or 0 if unknown
+	Col  int // From 1
+}
+
+func (p Pos) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// errorf returns an error at a position of a config file.
+func errorf(pos Pos, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, args...))
+}
+
+type valueKind int
+
+const (
+	nullValue valueKind = iota
+	boolValue
+	numberValue
+	stringValue
+	listValue
+	objectValue
+)
+
+var kindDescriptions = [...]string{
+	nullValue:   "null",
+	boolValue:   "a boolean",
+	numberValue: "a number",
+	stringValue: "a string",
+	listValue:   "a list",
+	objectValue: "an object",
+}
+
+// value is a value of a config file, in JSON, YAML or TOML, with its
+// position so that errors can point at it.
+type value struct {
+	kind   valueKind
+	pos    Pos
+	text   string   // The value of a string, or a number or boolean as JSON
+	items  []*value // The items of a list
+	fields []field  // The fields of an object, in order
+}
+
+type field struct {
+	key   string
+	pos   Pos
+	value *value
+}
+
+// lookup returns the value of the field key of an object, or nil.
+func (v *value) lookup(key string) *value {
+	for _, f := range v.fields {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// add adds a field to an object, which has each key once.
+func (v *value) add(f field) error {
+	if v.lookup(f.key) != nil {
+		return errorf(f.pos, "key %s is already defined", f.key)
+	}
+	v.fields = append(v.fields, f)
+	return nil
+}
+
+// json returns the value encoded as JSON.
+func (v *value) json() []byte {
+	var buf bytes.Buffer
+	v.encode(&buf)
+	return buf.Bytes()
+}
+
+func (v *value) encode(buf *bytes.Buffer) {
+	switch v.kind {
+	case nullValue:
+		buf.WriteString("null")
+	case boolValue, numberValue:
+		buf.WriteString(v.text)
+	case stringValue:
+		text, _ := json.Marshal(v.text)
+		buf.Write(text)
+	case listValue:
+		buf.WriteByte('[')
+		for i, item := range v.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			item.encode(buf)
+		}
+		buf.WriteByte(']')
+	case objectValue:
+		buf.WriteByte('{')
+		for i, f := range v.fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.key)
+			buf.Write(key)
+			buf.WriteByte(':')
+			f.value.encode(buf)
+		}
+		buf.WriteByte('}')
+	}
+}
+
+// positioned is implemented by the settings that remember where they are
+// set, to report the errors found once the rules are known.
+type positioned interface {
+	setPos(pos Pos)
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	positionedType  = reflect.TypeOf((*positioned)(nil)).Elem()
+)
+
+// decodeValue decodes a value into the struct out points to, like
+// encoding/json would with its json tags, but returning an error at the
+// position of each unknown key and value of the wrong type.
+func decodeValue(v *value, out any) error {
+	var errs []error
+	decodeInto(v, reflect.ValueOf(out).Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+// decodeInto decodes v into out; name is the path of the setting decoded,
+// such as overrides[0].files, used in errors.
+func decodeInto(v *value, out reflect.Value, name string, errs *[]error) {
+	setting := name
+	if setting == "" {
+		setting = "the config"
+	}
+	mismatch := func(expected string) {
+		*errs = append(*errs, errorf(v.pos, "%s must be %s, not %s", setting, expected, kindDescriptions[v.kind]))
+	}
+
+	if reflect.PointerTo(out.Type()).Implements(unmarshalerType) {
+		if err := out.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(v.json()); err != nil {
+			*errs = append(*errs, errorf(v.pos, "%s: %v", setting, err))
+			return
+		}
+		if reflect.PointerTo(out.Type()).Implements(positionedType) {
+			out.Addr().Interface().(positioned).setPos(v.pos)
+		}
+		return
+	}
+
+	switch out.Kind() {
+	case reflect.Struct:
+		if v.kind != objectValue {
+			mismatch("an object")
+			return
+		}
+		for _, f := range v.fields {
+			index, ok := fieldIndex(out.Type(), f.key)
+			if !ok {
+				*errs = append(*errs, errorf(f.pos, "unknown setting %q%s", f.key, within(name)))
+				continue
+			}
+			decodeInto(f.value, out.Field(index), join(name, f.key), errs)
+		}
+	case reflect.Map:
+		if v.kind != objectValue {
+			mismatch("an object")
+			return
+		}
+		out.Set(reflect.MakeMapWithSize(out.Type(), len(v.fields)))
+		for _, f := range v.fields {
+			elem := reflect.New(out.Type().Elem()).Elem()
+			decodeInto(f.value, elem, join(name, f.key), errs)
+			out.SetMapIndex(reflect.ValueOf(f.key), elem)
+		}
+	case reflect.Slice:
+		if v.kind != listValue {
+			mismatch(typeDescription(out.Type()))
+			return
+		}
+		out.Set(reflect.MakeSlice(out.Type(), len(v.items), len(v.items)))
+		for i, item := range v.items {
+			decodeInto(item, out.Index(i), fmt.Sprintf("%s[%d]", name, i), errs)
+		}
+	case reflect.String:
+		if v.kind != stringValue {
+			mismatch("a string")
+			return
+		}
+		out.SetString(v.text)
+	case reflect.Bool:
+		if v.kind != boolValue {
+			mismatch("a boolean")
+			return
+		}
+		out.SetBool(v.text == "true")
+	default:
+		panic("config: cannot decode into " + out.Type().String())
+	}
+}
+
+// fieldIndex returns the index of the field of a struct named key by its
+// json tag.
+func fieldIndex(t reflect.Type, key string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if jsonName(f) == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// jsonName returns the name of a struct field in the config.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func join(name, key string) string {
+	if name == "" {
+		return key
+	}
+	return name + "." + key
+}
+
+func within(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " in " + name
+}
+
+// typeDescription names the values a Go type is decoded from.
+func typeDescription(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice:
+		return "a list of " + strings.TrimPrefix(strings.TrimPrefix(typeDescription(t.Elem()), "a "), "an ") + "s"
+	}
+	return "an object"
+}
@@ -0,0 +1,49 @@
+// Package glob matches slash-separated paths against the globs of the
+// config and custom rules, in which ** matches any number of directories.
+package glob
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Match reports whether a slash-separated path matches a glob. A glob
+// without a slash matches the base name of the path, so *.php matches
+// every PHP file.
+func Match(glob, name string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+// Validate returns an error if a glob is malformed.
+func Validate(glob string) error {
+	if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+		return fmt.Errorf("bad glob %q", glob)
+	}
+	return nil
+}
+
+func matchSegments(globs, names []string) bool {
+	if len(globs) == 0 {
+		return len(names) == 0
+	}
+	if globs[0] == "**" {
+		for skip := 0; skip <= len(names); skip++ {
+			if matchSegments(globs[1:], names[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(globs[0], names[0]); !ok {
+		return false
+	}
+	return matchSegments(globs[1:], names[1:])
+}
@@ -1,9 +1,24 @@
 package lexer
 
-// peekChar looks ahead in the input without consuming the character.
+// peekChar looks at the byte after the current character without consuming
+// it.
 func (l *Lexer) peekChar() rune {
-	if l.readPosition >= len(l.input) {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt looks n bytes ahead of the current character without consuming
+// anything. peekCharAt(1) is equivalent to peekChar. Lookahead is only ever
+// compared against ASCII, so a byte of a multibyte character is returned as
+// is; it is >= 0x80 and therefore still counts as a letter.
+func (l *Lexer) peekCharAt(n int) rune {
+	i := l.readPosition + n - 1
+	if i >= len(l.input) {
 		return 0
 	}
-	return rune(l.input[l.readPosition])
-}
\ No newline at end of file
+	return rune(l.input[i])
+}
+
+// hasPrefix reports whether the input at the current character starts with s.
+func (l *Lexer) hasPrefix(s string) bool {
+	return len(l.input)-l.position >= len(s) && l.input[l.position:l.position+len(s)] == s
+}
@@ -1,9 +1,11 @@
 package lexer
 
 import (
+	"fmt"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 
+	"github.com/codevault-llc/php-lint/internal/diagnostic"
 	"github.com/codevault-llc/php-lint/internal/token"
 )
 
@@ -13,54 +15,142 @@ type Lexer struct {
 	readPosition int
 	ch           rune
 	line, column int
+	base         int  // offset of input within the original file
+	inHTML       bool // true outside of <?php ... ?> blocks
+	trivia       bool // attach whitespace and comments to tokens, see WithTrivia
+	version      Version
+	errors       []diagnostic.Diagnostic
+	haltIn       int  // tokens left to read of "__halt_compiler();"
+	halted       bool // past "__halt_compiler();", where the rest is data
 }
 
-func New(input string) *Lexer {
-	l := &Lexer{input: input, line: 1, column: 0}
+// Option configures a Lexer.
+type Option func(*Lexer)
+
+// WithTrivia makes the lexer attach whitespace and comments to the tokens
+// around them as token.Trivia instead of skipping whitespace and returning
+// comments as tokens of their own. Together with the tokens' lexemes, the
+// trivia covers every byte of the input, so the source can be reproduced
+// exactly.
+func WithTrivia() Option {
+	return func(l *Lexer) { l.trivia = true }
+}
+
+// New creates a lexer for a complete PHP file. Lexing starts in inline HTML
+// mode, like PHP itself, until the first opening tag.
+func New(input string, opts ...Option) *Lexer {
+	l := &Lexer{input: input, line: 1, column: 0, inHTML: true, version: LatestVersion}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.readChar()
+	return l
+}
+
+// KeepsTrivia reports whether the lexer was created WithTrivia.
+func (l *Lexer) KeepsTrivia() bool {
+	return l.trivia
+}
+
+// Errors returns the errors in the tokens read so far, such as a heredoc
+// body indented less than its closing label, which PHP rejects while
+// lexing.
+func (l *Lexer) Errors() []diagnostic.Diagnostic {
+	return l.errors
+}
+
+func (l *Lexer) errorAt(span token.Span, format string, args ...any) {
+	l.errors = append(l.errors, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	})
+}
+
+// NewFragment creates a lexer for a piece of PHP code that was cut out of a
+// larger file, such as an expression interpolated into a string. Token
+// positions are reported relative to start, the fragment's position in the
+// original file.
+func NewFragment(input string, start token.Pos, opts ...Option) *Lexer {
+	l := &Lexer{input: input, line: start.Line, column: start.Col - 1, version: LatestVersion}
+	for _, opt := range opts {
+		opt(l)
+	}
 	l.readChar()
+	l.base = start.Offset
 	return l
 }
 
+// readChar advances to the next character, decoding UTF-8 so that columns
+// count characters rather than bytes. Invalid bytes are read one at a time
+// as utf8.RuneError.
 func (l *Lexer) readChar() {
+	if l.position < len(l.input) {
+		if l.ch == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+	}
+
+	l.position = l.readPosition
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // EOF
-	} else {
-		l.ch = rune(l.input[l.readPosition])
+		return
 	}
-	l.position = l.readPosition
-	l.readPosition++
-	if l.ch == '\n' {
-		l.line++
-		l.column = 0
-	} else {
-		l.column++
+	r, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	l.ch = r
+	l.readPosition += width
+}
+
+// readChars consumes n characters.
+func (l *Lexer) readChars(n int) {
+	for i := 0; i < n; i++ {
+		l.readChar()
 	}
 }
 
-func (l *Lexer) readString() string {
-	// Consumes the opening '
-	l.readChar()
-	start := l.position
-	for l.ch != '\'' && l.ch != 0 {
+// readTo consumes characters until the byte offset end is reached.
+func (l *Lexer) readTo(end int) {
+	for l.position < end && l.ch != 0 {
 		l.readChar()
 	}
-	end := l.position
-	l.readChar() // Consumes the closing '
-	return l.input[start:end]
 }
 
 func (l *Lexer) skipWhitespace() {
-	for unicode.IsSpace(l.ch) {
+	for isSpace(l.ch) {
 		l.readChar()
 	}
 }
 
+// isSpace reports whether ch is whitespace to PHP. Unlike unicode.IsSpace it
+// does not include non-ASCII spaces, which PHP treats as identifier bytes.
+func isSpace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// isLetter reports whether ch may start a PHP identifier. PHP accepts any
+// byte from 0x80 upwards, so every non-ASCII character counts as a letter.
 func isLetter(ch rune) bool {
-	return unicode.IsLetter(ch) || ch == '_'
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ch == '_' || ch >= 0x80
 }
 
 func isDigit(ch rune) bool {
-	return unicode.IsDigit(ch)
+	return '0' <= ch && ch <= '9'
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+func isOctalDigit(ch rune) bool {
+	return '0' <= ch && ch <= '7'
+}
+
+// pos returns the position of the current character.
+func (l *Lexer) pos() token.Pos {
+	return token.Pos{Line: l.line, Col: l.column, Offset: l.base + l.position}
 }
 
 // newTokenFromPos is a helper to build a token from a starting position.
@@ -71,7 +161,7 @@ func (l *Lexer) newTokenFromPos(kind token.Kind, lexeme string, start token.Pos)
 		Lexeme: lexeme,
 		Span: token.Span{
 			Start: start,
-			End:   token.Pos{Line: l.line, Col: l.column, Offset: l.position},
+			End:   l.pos(),
 		},
 	}
 }
@@ -85,27 +175,292 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[start:l.position]
 }
 
+// readQualifiedName consumes the "\Name" segments of a namespaced name. A
+// trailing backslash, as in the prefix of a group use "Foo\{", is left for
+// the NS_SEPARATOR token.
+func (l *Lexer) readQualifiedName() string {
+	start := l.position
+	for l.ch == '\\' && isLetter(l.peekChar()) {
+		l.readChar() // Consume \
+		l.readIdentifier()
+	}
+	return l.input[start:l.position]
+}
+
+// readVariable consumes a "$name" variable and returns it including the
+// leading dollar sign.
+func (l *Lexer) readVariable() string {
+	start := l.position
+	l.readChar() // Consume $
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[start:l.position]
+}
+
+// readNumber consumes an integer or float literal and reports which of the
+// two it was. Digits may be separated by single underscores, as in 1_000.
+func (l *Lexer) readNumber() (token.Kind, string) {
+	start, startPos := l.position, l.pos()
+	kind := token.Kind(token.INTEGER)
+
+	if l.ch == '0' {
+		var valid func(rune) bool
+		switch l.peekChar() {
+		case 'x', 'X':
+			valid = isHexDigit
+		case 'b', 'B':
+			valid = func(ch rune) bool { return ch == '0' || ch == '1' }
+		case 'o', 'O':
+			valid = isOctalDigit
+		}
+		// Without digits after it, the prefix is a name after the
+		// integer 0, as PHP reads 0x.
+		if valid != nil && valid(l.peekCharAt(2)) {
+			l.readChars(2)
+			l.readDigits(valid)
+			return kind, l.input[start:l.position]
+		}
+	}
+
+	l.readDigits(isDigit)
+	if l.ch == '.' {
+		kind = token.FLOAT
+		l.readChar() // Consume .
+		l.readDigits(isDigit)
+	}
+	if (l.ch == 'e' || l.ch == 'E') && l.isExponent() {
+		kind = token.FLOAT
+		l.readChar() // Consume e
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits(isDigit)
+	}
+	lexeme := l.input[start:l.position]
+	// An integer with a leading 0 is octal, without 8 and 9.
+	if kind == token.INTEGER && lexeme[0] == '0' && strings.ContainsAny(lexeme, "89") {
+		l.errorAt(token.Span{Start: startPos, End: l.pos()}, "Invalid numeric literal")
+	}
+	return kind, lexeme
+}
+
+// readDigits consumes digits accepted by valid, allowing a single
+// underscore between two digits.
+func (l *Lexer) readDigits(valid func(rune) bool) {
+	digits := false
+	for valid(l.ch) || (digits && l.ch == '_' && valid(l.peekChar())) {
+		l.readChar()
+		digits = true
+	}
+}
+
+// isExponent reports whether the 'e' under the cursor starts an exponent,
+// i.e. it is followed by digits with an optional sign.
+func (l *Lexer) isExponent() bool {
+	next := l.peekChar()
+	if next == '+' || next == '-' {
+		return isDigit(l.peekCharAt(2))
+	}
+	return isDigit(next)
+}
+
+// readCast checks whether the '(' under the cursor opens a type cast such as
+// "(int)" or "( string )" and consumes it if so.
+func (l *Lexer) readCast() (token.Kind, string, bool) {
+	i := l.position + 1
+	for i < len(l.input) && (l.input[i] == ' ' || l.input[i] == '\t') {
+		i++
+	}
+	nameStart := i
+	for i < len(l.input) && isLetter(rune(l.input[i])) {
+		i++
+	}
+	name := strings.ToLower(l.input[nameStart:i])
+	for i < len(l.input) && (l.input[i] == ' ' || l.input[i] == '\t') {
+		i++
+	}
+	if i >= len(l.input) || l.input[i] != ')' {
+		return "", "", false
+	}
+	kind, ok := token.LookupCast(name)
+	if !ok {
+		return "", "", false
+	}
+	start := l.position
+	l.readTo(i + 1)
+	return kind, l.input[start:l.position], true
+}
+
+// readYieldFrom checks whether the "yield" just read is followed by "from"
+// and consumes it if so, returning the full lexeme.
+func (l *Lexer) readYieldFrom(start int) (string, bool) {
+	i := l.position
+	for i < len(l.input) && isSpace(rune(l.input[i])) {
+		i++
+	}
+	if i == l.position || i+4 > len(l.input) || !strings.EqualFold(l.input[i:i+4], "from") {
+		return "", false
+	}
+	if i+4 < len(l.input) && (isLetter(rune(l.input[i+4])) || isDigit(rune(l.input[i+4]))) {
+		return "", false
+	}
+	l.readTo(i + 4)
+	return l.input[start:l.position], true
+}
+
+// isEnumDeclaration checks whether the "enum" just read starts an enum
+// declaration. Outside of "enum Name" it is an ordinary identifier.
+func (l *Lexer) isEnumDeclaration() bool {
+	i := l.position
+	for i < len(l.input) && isSpace(rune(l.input[i])) {
+		i++
+	}
+	if i == l.position {
+		return false
+	}
+	start := i
+	for i < len(l.input) && (isLetter(rune(l.input[i])) || isDigit(rune(l.input[i]))) {
+		i++
+	}
+	name := strings.ToLower(l.input[start:i])
+	return name != "" && name != "extends" && name != "implements"
+}
+
+// isCall checks whether the word just read is followed by '(', as the name
+// of a function called or declared is.
+func (l *Lexer) isCall() bool {
+	i := l.position
+	for i < len(l.input) && isSpace(rune(l.input[i])) {
+		i++
+	}
+	return i < len(l.input) && l.input[i] == '('
+}
+
 func (l *Lexer) readBlockComment() string {
-	var sb strings.Builder
-	l.readChar() // Consume opening /*
+	start := l.position
+	l.readChars(2) // Consume /*
 	for l.ch != 0 {
 		if l.ch == '*' && l.peekChar() == '/' {
 			l.readChar() // Consume *
 			l.readChar() // Consume /
 			break
 		}
-		sb.WriteRune(l.ch)
 		l.readChar()
 	}
-	return sb.String()
+	return l.input[start:l.position]
+}
+
+// readInlineHTML consumes the text up to the next opening tag or the end of
+// the input.
+func (l *Lexer) readInlineHTML() string {
+	start := l.position
+	for l.ch != 0 && !l.isOpenTag() {
+		l.readChar()
+	}
+	return l.input[start:l.position]
+}
+
+// isOpenTag reports whether the input at the current character is "<?=" or
+// a "<?php" tag. The latter is case-insensitive and must be followed by
+// whitespace or the end of the input.
+func (l *Lexer) isOpenTag() bool {
+	if l.hasPrefix("<?=") {
+		return true
+	}
+	if len(l.input)-l.position < 5 || !strings.EqualFold(l.input[l.position:l.position+5], "<?php") {
+		return false
+	}
+	next := l.peekCharAt(5)
+	return next == 0 || isSpace(next)
+}
+
+// readCloseTag consumes "?>" together with a single newline directly after
+// it, which PHP swallows as part of the tag.
+func (l *Lexer) readCloseTag() string {
+	start := l.position
+	l.readChars(2)
+	if l.ch == '\n' {
+		l.readChar()
+	} else if l.ch == '\r' && l.peekChar() == '\n' {
+		l.readChars(2)
+	}
+	return l.input[start:l.position]
+}
+
+// isCommentStart reports whether a comment starts at the current character.
+// Since PHP 8.0, a '#' followed by '[' opens an attribute rather than a
+// comment.
+func (l *Lexer) isCommentStart() bool {
+	if l.ch == '#' {
+		return l.peekChar() != '[' || !l.version.AtLeast(8, 0)
+	}
+	return l.hasPrefix("/*") || l.hasPrefix("//")
+}
+
+// readComment consumes the comment at the current character. Block comments
+// opened with "/**" and whitespace are doc comments, as in PHP.
+func (l *Lexer) readComment() (token.Kind, string) {
+	if !l.hasPrefix("/*") {
+		return token.LINE_COMMENT, l.readLineComment()
+	}
+	comment := l.readBlockComment()
+	if len(comment) > 4 && strings.HasPrefix(comment, "/**") && isSpace(rune(comment[3])) {
+		return token.DOC_COMMENT, comment
+	}
+	return token.BLOCK_COMMENT, comment
 }
 
+// readTrivia consumes the whitespace and comments at the current character.
+// Trailing trivia stops after the end of the line, so that a comment on the
+// next line goes to the token it precedes.
+func (l *Lexer) readTrivia(trailing bool) []token.Trivia {
+	var trivia []token.Trivia
+	for {
+		start, startPos := l.position, l.pos()
+		var kind token.Kind
+		endOfLine := false
+		switch {
+		case isSpace(l.ch) && !trailing:
+			kind = token.WHITESPACE
+			l.skipWhitespace()
+		case isSpace(l.ch):
+			kind = token.WHITESPACE
+			for l.ch == ' ' || l.ch == '\t' || (l.ch == '\r' && l.peekChar() != '\n') {
+				l.readChar()
+			}
+			if l.ch == '\r' {
+				l.readChar()
+			}
+			if l.ch == '\n' {
+				l.readChar()
+				endOfLine = true
+			}
+		case l.isCommentStart():
+			kind, _ = l.readComment()
+		default:
+			return trivia
+		}
+		trivia = append(trivia, token.Trivia{
+			Kind: kind,
+			Text: l.input[start:l.position],
+			Span: token.Span{Start: startPos, End: l.pos()},
+		})
+		if endOfLine {
+			return trivia
+		}
+	}
+}
+
+// readLineComment consumes a "//" or "#" comment. The comment ends at the end
+// of the line or right before a closing "?>" tag, as it does in PHP.
 func (l *Lexer) readLineComment() string {
-	var sb strings.Builder
-	l.readChar() 
+	start := l.position
 	for l.ch != 0 && l.ch != '\n' {
-		sb.WriteRune(l.ch)
+		if l.ch == '?' && l.peekChar() == '>' {
+			break
+		}
 		l.readChar()
 	}
-	return sb.String()
-}
\ No newline at end of file
+	return l.input[start:l.position]
+}
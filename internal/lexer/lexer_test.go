@@ -0,0 +1,161 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// kinds returns the kinds and lexemes of tokens, as "KIND lexeme".
+func kinds(tokens []token.Token) []string {
+	list := make([]string, len(tokens))
+	for i, tok := range tokens {
+		list[i] = string(tok.Kind) + " " + tok.Lexeme
+	}
+	return list
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []string
+	}{
+		// The longest operator wins.
+		{"$a **= $b <=> $c ?-> d ?? e", []string{"VARIABLE $a", "**= **=", "VARIABLE $b", "<=> <=>", "VARIABLE $c", "?-> ?->", "IDENT d", "?? ??", "IDENT e"}},
+		{"(int) $a; ( string )$b", []string{"(int) (int)", "VARIABLE $a", "; ;", "(string) ( string )", "VARIABLE $b"}},
+		{"yield from $a; yield\n  from $b", []string{"YIELD_FROM yield from", "VARIABLE $a", "; ;", "YIELD_FROM yield\n  from", "VARIABLE $b"}},
+		{"\\A\\B namespace\\C A\\B", []string{"NAME_FULLY_QUALIFIED \\A\\B", "NAME_RELATIVE namespace\\C", "NAME_QUALIFIED A\\B"}},
+		{"$$a ${'b'}", []string{"$ $", "VARIABLE $a", "$ $", "{ {", "STRING 'b'", "} }"}},
+		{"# a\n// b\n/* c */ /** d */ #[A]", []string{"LINE_COMMENT # a", "LINE_COMMENT // b", "BLOCK_COMMENT /* c */", "DOC_COMMENT /** d */", "#[ #[", "IDENT A", "] ]"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lexAll("<?php " + tt.src)
+		if got := kinds(tokens[1:]); !reflect.DeepEqual(got, tt.tokens) || len(errors) > 0 {
+			t.Errorf("%q: tokens\n got %q\nwant %q\nerrors %q", tt.src, got, tt.tokens, errors)
+		}
+	}
+}
+
+func TestInlineHTML(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []string
+	}{
+		{"a<?= $b ?>c<?php d", []string{"INLINE_HTML a", "<?= <?=", "VARIABLE $b", "?> ?>", "INLINE_HTML c", "<?php <?php", "IDENT d"}},
+		// The new line after a closing tag is part of it.
+		{"<?php a ?>\nb", []string{"<?php <?php", "IDENT a", "?> ?>\n", "INLINE_HTML b"}},
+		{"<?php a ?>", []string{"<?php <?php", "IDENT a", "?> ?>"}},
+		{"a <? b", []string{"INLINE_HTML a <? b"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lexAll(tt.src)
+		if got := kinds(tokens); !reflect.DeepEqual(got, tt.tokens) || len(errors) > 0 {
+			t.Errorf("%q: tokens\n got %q\nwant %q\nerrors %q", tt.src, got, tt.tokens, errors)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []string
+		errors []string
+	}{
+		{"42", []string{"INTEGER 42"}, nil},
+		{"1_000_000", []string{"INTEGER 1_000_000"}, nil},
+		{"0x1F 0X1f", []string{"INTEGER 0x1F", "INTEGER 0X1f"}, nil},
+		{"0b101 0o17 017", []string{"INTEGER 0b101", "INTEGER 0o17", "INTEGER 017"}, nil},
+		{"1.5 .5 1. 1e3 1E-3 1_0.5_0", []string{"FLOAT 1.5", "FLOAT .5", "FLOAT 1.", "FLOAT 1e3", "FLOAT 1E-3", "FLOAT 1_0.5_0"}, nil},
+		{"08.5 09e1", []string{"FLOAT 08.5", "FLOAT 09e1"}, nil},
+		// A prefix without digits is 0 followed by a name.
+		{"0x", []string{"INTEGER 0", "IDENT x"}, nil},
+		{"0b2", []string{"INTEGER 0", "IDENT b2"}, nil},
+		{"0o8", []string{"INTEGER 0", "IDENT o8"}, nil},
+		{"0x_1", []string{"INTEGER 0", "IDENT x_1"}, nil},
+		// An underscore stands between two digits only.
+		{"1._5", []string{"FLOAT 1.", "IDENT _5"}, nil},
+		{"1__2", []string{"INTEGER 1", "IDENT __2"}, nil},
+		{"1_", []string{"INTEGER 1", "IDENT _"}, nil},
+		{"1e_5", []string{"INTEGER 1", "IDENT e_5"}, nil},
+		// Octal integers have no 8 or 9.
+		{"089", []string{"INTEGER 089"}, []string{"1:7 Invalid numeric literal"}},
+		{"0_9", []string{"INTEGER 0_9"}, []string{"1:7 Invalid numeric literal"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lexAll("<?php " + tt.src)
+		if got := kinds(tokens[1:]); !reflect.DeepEqual(got, tt.tokens) {
+			t.Errorf("%q: tokens\n got %q\nwant %q", tt.src, got, tt.tokens)
+		}
+		if tt.errors == nil {
+			tt.errors = []string{}
+		}
+		if !reflect.DeepEqual(errors, tt.errors) {
+			t.Errorf("%q: errors\n got %q\nwant %q", tt.src, errors, tt.errors)
+		}
+	}
+}
+
+func TestShellExec(t *testing.T) {
+	tests := []struct {
+		src   string
+		parts []string // As "kind value"
+	}{
+		{"`ls`", []string{"literal ls"}},
+		{"``", []string{}},
+		{"`ls $dir`", []string{"literal ls ", "var $dir"}},
+		{"`ls {$a->b} -l`", []string{"literal ls ", "expr $a->b", "literal  -l"}},
+		{"`echo \\` \\$a \\n`", []string{"literal echo ` $a \n"}},
+		{"`a\nb`", []string{"literal a\nb"}},
+	}
+	names := map[token.PartKind]string{token.PartLiteral: "literal", token.PartVar: "var", token.PartExpr: "expr", token.PartDollarBrace: "dollar-brace"}
+	for _, tt := range tests {
+		tokens, errors := lexAll("<?php " + tt.src + ";")
+		if len(errors) > 0 || len(tokens) != 3 {
+			t.Errorf("%q: tokens %q, errors %q", tt.src, kinds(tokens), errors)
+			continue
+		}
+		tok := tokens[1]
+		if tok.Kind != token.SHELL_EXEC || tok.Lexeme != tt.src {
+			t.Errorf("%q: got %s %q", tt.src, tok.Kind, tok.Lexeme)
+		}
+		parts := []string{}
+		for _, part := range tok.Parts {
+			parts = append(parts, names[part.Kind]+" "+part.Value)
+		}
+		if !reflect.DeepEqual(parts, tt.parts) {
+			t.Errorf("%q: parts\n got %q\nwant %q", tt.src, parts, tt.parts)
+		}
+	}
+	// Backticks in strings and comments are not commands.
+	tokens, _ := lexAll("<?php '`a`'; \"`b`\"; // `c`\n")
+	for _, tok := range tokens {
+		if tok.Kind == token.SHELL_EXEC || tok.Kind == token.ILLEGAL {
+			t.Errorf("got %s %q", tok.Kind, tok.Lexeme)
+		}
+	}
+}
+
+func TestHaltCompiler(t *testing.T) {
+	tests := []struct {
+		src    string
+		tokens []string
+	}{
+		{"__halt_compiler();", []string{"HALT_COMPILER __halt_compiler", "( (", ") )", "; ;"}},
+		{"__halt_compiler(); `a \"b <?php $c", []string{"HALT_COMPILER __halt_compiler", "( (", ") )", "; ;", "INLINE_HTML  `a \"b <?php $c"}},
+		{"__halt_compiler() ?>\ndata", []string{"HALT_COMPILER __halt_compiler", "( (", ") )", "?> ?>\n", "INLINE_HTML data"}},
+		{"__halt_compiler /* c */ ( ) ;data", []string{"HALT_COMPILER __halt_compiler", "BLOCK_COMMENT /* c */", "( (", ") )", "; ;", "INLINE_HTML data"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lexAll("<?php " + tt.src)
+		if got := kinds(tokens[1:]); !reflect.DeepEqual(got, tt.tokens) || len(errors) > 0 {
+			t.Errorf("%q: tokens\n got %q\nwant %q\nerrors %q", tt.src, got, tt.tokens, errors)
+		}
+	}
+	// With trivia, the data is not read as trailing whitespace or comments.
+	tokens, _ := lexAll("<?php __halt_compiler(); // data\n", WithTrivia())
+	last := tokens[len(tokens)-1]
+	if last.Kind != token.INLINE_HTML || last.Lexeme != " // data\n" || len(tokens[len(tokens)-2].Trailing) > 0 {
+		t.Errorf("got %q, want the data in one INLINE_HTML token", kinds(tokens))
+	}
+}
@@ -0,0 +1,480 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// rawPart is a piece of a string body before escape processing.
+type rawPart struct {
+	kind       token.PartKind
+	raw        string
+	offset     int // start offset within l.input
+	start, end token.Pos
+}
+
+// newRawPart builds a part from the input between offset and the current
+// character.
+func (l *Lexer) newRawPart(kind token.PartKind, offset int, start token.Pos) rawPart {
+	return rawPart{kind: kind, raw: l.input[offset:l.position], offset: offset, start: start, end: l.pos()}
+}
+
+// readSingleQuoted consumes a single-quoted string. Only \' and \\ are escape
+// sequences inside it.
+func (l *Lexer) readSingleQuoted() (string, []token.StringPart) {
+	start := l.position
+	l.readChar() // Consume the opening '
+	bodyStart, bodyOffset := l.pos(), l.position
+	for l.ch != '\'' && l.ch != 0 {
+		if l.ch == '\\' && l.peekChar() != 0 {
+			l.readChar()
+		}
+		l.readChar()
+	}
+	body := l.input[bodyOffset:l.position]
+	bodyEnd := l.pos()
+	if l.ch == '\'' {
+		l.readChar() // Consume the closing '
+	}
+
+	part := token.StringPart{
+		Kind:  token.PartLiteral,
+		Value: unescapeSingle(body),
+		Span:  token.Span{Start: bodyStart, End: bodyEnd},
+	}
+	return l.input[start:l.position], []token.StringPart{part}
+}
+
+// readDoubleQuoted consumes a double-quoted string and splits it into
+// literal and interpolated parts.
+func (l *Lexer) readDoubleQuoted() (token.Kind, string, []token.StringPart) {
+	start := l.position
+	l.readChar() // Consume the opening "
+	raw := l.scanParts(func() bool { return l.ch == '"' })
+	if l.ch == '"' {
+		l.readChar() // Consume the closing "
+	}
+	kind, parts := classifyParts(unescapeParts(raw, '"'))
+	return kind, l.input[start:l.position], parts
+}
+
+// readShellExec consumes a shell command in backticks, which embeds
+// variables like a double-quoted string. Its parts are kept apart even
+// without variables.
+func (l *Lexer) readShellExec() (string, []token.StringPart) {
+	start := l.position
+	l.readChar() // Consume the opening `
+	raw := l.scanParts(func() bool { return l.ch == '`' })
+	if l.ch == '`' {
+		l.readChar() // Consume the closing `
+	}
+	return l.input[start:l.position], unescapeParts(raw, '`')
+}
+
+// unescapeParts turns raw parts into string parts, resolving the escape
+// sequences of the literal ones, quote being the escapable delimiter.
+func unescapeParts(raw []rawPart, quote byte) []token.StringPart {
+	parts := make([]token.StringPart, 0, len(raw))
+	for _, rp := range raw {
+		value := rp.raw
+		if rp.kind == token.PartLiteral {
+			value = unescapeDouble(value, quote)
+		}
+		parts = append(parts, token.StringPart{Kind: rp.kind, Value: value, Span: token.Span{Start: rp.start, End: rp.end}})
+	}
+	return parts
+}
+
+// isHeredocStart reports whether the input at the current character opens a
+// heredoc or nowdoc, i.e. "<<<" followed by an optionally quoted label and a
+// newline.
+func (l *Lexer) isHeredocStart() bool {
+	if !l.hasPrefix("<<<") {
+		return false
+	}
+	_, _, end := l.heredocLabel()
+	return end > 0
+}
+
+// heredocLabel parses the opener under the cursor and returns its label,
+// whether it is a nowdoc, and the offset just past the opener's newline.
+// end is 0 if the opener is malformed.
+func (l *Lexer) heredocLabel() (label string, nowdoc bool, end int) {
+	i := l.position + 3
+	for i < len(l.input) && (l.input[i] == ' ' || l.input[i] == '\t') {
+		i++
+	}
+	var quote byte
+	if i < len(l.input) && (l.input[i] == '\'' || l.input[i] == '"') {
+		quote = l.input[i]
+		i++
+	}
+	labelStart := i
+	for i < len(l.input) && (isLetter(rune(l.input[i])) || (i > labelStart && isDigit(rune(l.input[i])))) {
+		i++
+	}
+	label = l.input[labelStart:i]
+	if label == "" {
+		return "", false, 0
+	}
+	if quote != 0 {
+		if i >= len(l.input) || l.input[i] != quote {
+			return "", false, 0
+		}
+		i++
+	}
+	if strings.HasPrefix(l.input[i:], "\r\n") {
+		i += 2
+	} else if strings.HasPrefix(l.input[i:], "\n") {
+		i++
+	} else {
+		return "", false, 0
+	}
+	return label, quote == '\'', i
+}
+
+// readHeredoc consumes a heredoc or nowdoc. The indentation of the closing
+// label is removed from every line of the body, as PHP 7.3+ does.
+func (l *Lexer) readHeredoc() (token.Kind, string, []token.StringPart) {
+	start := l.position
+	label, nowdoc, bodyOffset := l.heredocLabel()
+	l.readTo(bodyOffset)
+	bodyStart := l.position
+
+	// closingAt reports whether a line starting at offset i holds the
+	// closing label, and returns the length of its indentation.
+	closingAt := func(i int) (int, bool) {
+		j := i
+		for j < len(l.input) && (l.input[j] == ' ' || l.input[j] == '\t') {
+			j++
+		}
+		if !strings.HasPrefix(l.input[j:], label) {
+			return 0, false
+		}
+		k := j + len(label)
+		if k < len(l.input) && (isLetter(rune(l.input[k])) || isDigit(rune(l.input[k]))) {
+			return 0, false
+		}
+		return j - i, true
+	}
+	atEnd := func() bool {
+		if l.position != bodyStart && l.input[l.position-1] != '\n' {
+			return false
+		}
+		_, ok := closingAt(l.position)
+		return ok
+	}
+
+	var raw []rawPart
+	if nowdoc {
+		partStart, partOffset := l.pos(), l.position
+		for l.ch != 0 && !atEnd() {
+			l.readChar()
+		}
+		if l.position > partOffset {
+			raw = append(raw, l.newRawPart(token.PartLiteral, partOffset, partStart))
+		}
+	} else {
+		raw = l.scanParts(atEnd)
+	}
+
+	indent := 0
+	if l.ch != 0 {
+		indent, _ = closingAt(l.position)
+		l.readTo(l.position + indent + len(label))
+	}
+
+	parts := make([]token.StringPart, 0, len(raw))
+	for i, rp := range raw {
+		if rp.kind != token.PartLiteral {
+			parts = append(parts, token.StringPart{Kind: rp.kind, Value: rp.raw, Span: token.Span{Start: rp.start, End: rp.end}})
+			continue
+		}
+		atLineStart := rp.offset == bodyStart || l.input[rp.offset-1] == '\n'
+		l.checkIndentation(rp, indent, atLineStart)
+		value := removeIndentation(rp.raw, indent, atLineStart)
+		if i == len(raw)-1 {
+			// The newline before the closing label is not part of the body.
+			value = strings.TrimSuffix(value, "\n")
+			value = strings.TrimSuffix(value, "\r")
+			if value == "" && len(parts) > 0 {
+				continue
+			}
+		}
+		if !nowdoc {
+			value = unescapeDouble(value, 0)
+		}
+		parts = append(parts, token.StringPart{Kind: rp.kind, Value: value, Span: token.Span{Start: rp.start, End: rp.end}})
+	}
+	kind, parts := classifyParts(parts)
+	return kind, l.input[start:l.position], parts
+}
+
+// scanParts consumes a string body up to (but not including) the position
+// where atEnd returns true, splitting it into literal and interpolated parts.
+// Literal parts are returned raw, with escape sequences still in place.
+func (l *Lexer) scanParts(atEnd func() bool) []rawPart {
+	var parts []rawPart
+	litStart, litOffset := l.pos(), l.position
+	flush := func() {
+		if l.position > litOffset {
+			parts = append(parts, l.newRawPart(token.PartLiteral, litOffset, litStart))
+		}
+	}
+	// embedded consumes an interpolation with read and records it as a part.
+	embedded := func(kind token.PartKind, read func()) {
+		start, offset := l.pos(), l.position
+		read()
+		parts = append(parts, l.newRawPart(kind, offset, start))
+	}
+
+	for l.ch != 0 && !atEnd() {
+		switch {
+		case l.ch == '\\':
+			l.readChar()
+			if l.ch != 0 {
+				l.readChar()
+			}
+		case l.ch == '$' && isLetter(l.peekChar()):
+			flush()
+			embedded(token.PartVar, l.readSimpleInterpolation)
+			litStart, litOffset = l.pos(), l.position
+		case l.ch == '{' && l.peekChar() == '$':
+			flush()
+			l.readChar() // Consume {
+			embedded(token.PartExpr, l.readUntilClosingBrace)
+			if l.ch == '}' {
+				l.readChar()
+			}
+			litStart, litOffset = l.pos(), l.position
+		case l.ch == '$' && l.peekChar() == '{':
+			flush()
+			l.readChars(2) // Consume ${
+			embedded(token.PartDollarBrace, l.readUntilClosingBrace)
+			if l.ch == '}' {
+				l.readChar()
+			}
+			litStart, litOffset = l.pos(), l.position
+		default:
+			l.readChar()
+		}
+	}
+	flush()
+	return parts
+}
+
+// readSimpleInterpolation consumes "$name" plus an optional single "[key]"
+// or "->prop" / "?->prop" suffix, which is all PHP's simple syntax allows.
+func (l *Lexer) readSimpleInterpolation() {
+	l.readVariable()
+	switch {
+	case l.ch == '[':
+		i := l.position + 1
+		if i < len(l.input) && (l.input[i] == '-' || l.input[i] == '$') {
+			i++
+		}
+		keyStart := i
+		for i < len(l.input) && (isLetter(rune(l.input[i])) || isDigit(rune(l.input[i]))) {
+			i++
+		}
+		if i > keyStart && i < len(l.input) && l.input[i] == ']' {
+			l.readTo(i + 1)
+		}
+	case l.ch == '-' && l.peekChar() == '>' && isLetter(l.peekCharAt(2)):
+		l.readChars(2)
+		l.readIdentifier()
+	case l.ch == '?' && l.peekChar() == '-' && l.peekCharAt(2) == '>' && isLetter(l.peekCharAt(3)):
+		l.readChars(3)
+		l.readIdentifier()
+	}
+}
+
+// readUntilClosingBrace consumes an embedded expression up to the '}' that
+// balances the already consumed '{', skipping over nested quoted strings.
+func (l *Lexer) readUntilClosingBrace() {
+	depth := 1
+	for l.ch != 0 {
+		switch l.ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return
+			}
+		case '\'', '"':
+			quote := l.ch
+			l.readChar()
+			for l.ch != quote && l.ch != 0 {
+				if l.ch == '\\' {
+					l.readChar()
+				}
+				l.readChar()
+			}
+		}
+		l.readChar()
+	}
+}
+
+// classifyParts decides whether a string is plain or interpolated. A plain
+// string is collapsed into a single literal part.
+func classifyParts(parts []token.StringPart) (token.Kind, []token.StringPart) {
+	for _, part := range parts {
+		if part.Kind != token.PartLiteral {
+			return token.INTERPOLATED_STRING, parts
+		}
+	}
+	if len(parts) == 1 {
+		return token.STRING, parts
+	}
+
+	var value strings.Builder
+	var span token.Span
+	for i, part := range parts {
+		if i == 0 {
+			span.Start = part.Span.Start
+		}
+		value.WriteString(part.Value)
+		span.End = part.Span.End
+	}
+	return token.STRING, []token.StringPart{{Kind: token.PartLiteral, Value: value.String(), Span: span}}
+}
+
+// checkIndentation records an error for the lines of a literal part of a
+// heredoc body indented less than the closing label, as PHP does. Lines of
+// whitespace only may be indented less.
+func (l *Lexer) checkIndentation(rp rawPart, indent int, atLineStart bool) {
+	if indent == 0 {
+		return
+	}
+	offset := rp.start.Offset
+	for i, line := range strings.Split(rp.raw, "\n") {
+		lineStart := token.Pos{Line: rp.start.Line + i, Col: 1, Offset: offset}
+		if i == 0 {
+			lineStart = rp.start
+		}
+		offset += len(line) + 1
+		if i == 0 && !atLineStart {
+			continue
+		}
+		n := 0
+		for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+			n++
+		}
+		if n < indent && strings.TrimRight(line[n:], "\r") != "" {
+			end := lineStart
+			end.Col += n
+			end.Offset += n
+			l.errorAt(token.Span{Start: lineStart, End: end}, "Invalid body indentation level (expecting an indentation level of at least %d)", indent)
+		}
+	}
+}
+
+// removeIndentation strips up to indent spaces or tabs from the start of
+// every line in s. The first line is only stripped if atLineStart is set.
+func removeIndentation(s string, indent int, atLineStart bool) string {
+	if indent == 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if i == 0 && !atLineStart {
+			continue
+		}
+		n := 0
+		for n < indent && n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+			n++
+		}
+		lines[i] = line[n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unescapeSingle resolves the \' and \\ escapes of a single-quoted string.
+func unescapeSingle(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '\'' || s[i+1] == '\\') {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// unescapeDouble resolves the escape sequences of a double-quoted string or
+// heredoc. quote is the delimiter that may be escaped, or 0 for heredocs.
+// Unknown escape sequences are kept verbatim, as PHP does.
+func unescapeDouble(s string, quote byte) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == 'n':
+			sb.WriteByte('\n')
+		case next == 't':
+			sb.WriteByte('\t')
+		case next == 'r':
+			sb.WriteByte('\r')
+		case next == 'v':
+			sb.WriteByte('\v')
+		case next == 'f':
+			sb.WriteByte('\f')
+		case next == 'e':
+			sb.WriteByte(0x1b)
+		case next == '\\' || next == '$' || (quote != 0 && next == quote):
+			sb.WriteByte(next)
+		case '0' <= next && next <= '7':
+			j := i + 1
+			for j < len(s) && j < i+4 && '0' <= s[j] && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 8, 16)
+			sb.WriteByte(byte(v))
+			i = j - 1
+			continue
+		case next == 'x' && i+2 < len(s) && isHexDigit(rune(s[i+2])):
+			j := i + 2
+			for j < len(s) && j < i+4 && isHexDigit(rune(s[j])) {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i+2:j], 16, 8)
+			sb.WriteByte(byte(v))
+			i = j - 1
+			continue
+		case next == 'u' && i+2 < len(s) && s[i+2] == '{':
+			end := strings.IndexByte(s[i+3:], '}')
+			if end < 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			v, err := strconv.ParseUint(s[i+3:i+3+end], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(v)) {
+				sb.WriteByte(c)
+				continue
+			}
+			sb.WriteRune(rune(v))
+			i += 3 + end
+			continue
+		default:
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return sb.String()
+}
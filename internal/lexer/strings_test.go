@@ -0,0 +1,85 @@
+package lexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// lexAll returns the tokens of src up to the end of file, and the errors
+// found lexing them.
+func lexAll(src string, opts ...Option) ([]token.Token, []string) {
+	l := New(src, opts...)
+	var tokens []token.Token
+	for {
+		tok := l.NextToken()
+		if tok.Kind == token.EOF {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+	errors := []string{}
+	for _, err := range l.Errors() {
+		errors = append(errors, fmt.Sprintf("%s %s", err.Location(), err.Message))
+	}
+	return tokens, errors
+}
+
+// stringValue returns the value of the first string token, with the source
+// of its interpolations.
+func stringValue(tokens []token.Token) string {
+	for _, tok := range tokens {
+		if tok.Kind == token.STRING || tok.Kind == token.INTERPOLATED_STRING {
+			value := ""
+			for _, part := range tok.Parts {
+				value += part.Value
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+func TestHeredoc(t *testing.T) {
+	tests := []struct {
+		src    string
+		value  string
+		errors []string
+	}{
+		{"<<<EOT\na\n  b\nEOT;", "a\n  b", nil},
+		{"<<<EOT\n    a\n      b\n    EOT;", "a\n  b", nil},
+		{"<<<'EOT'\n    a $b\n    EOT;", "a $b", nil},
+		{"<<<\"EOT\"\n  a\\tb\n  EOT;", "a\tb", nil},
+		// Blank lines may be indented less.
+		{"<<<EOT\n    a\n\n  \n    b\n    EOT;", "a\n\n\nb", nil},
+		{
+			"<<<EOT\n    a\n  b\n    EOT;", "a\nb",
+			[]string{"3:1 Invalid body indentation level (expecting an indentation level of at least 4)"},
+		},
+		{
+			"<<<'EOT'\n  a\nb\n   EOT;", "a\nb",
+			[]string{
+				"2:1 Invalid body indentation level (expecting an indentation level of at least 3)",
+				"3:1 Invalid body indentation level (expecting an indentation level of at least 3)",
+			},
+		},
+		{
+			"<<<EOT\n    $a\n  b {$c}\n    EOT;", "$a\nb $c",
+			[]string{"3:1 Invalid body indentation level (expecting an indentation level of at least 4)"},
+		},
+	}
+	for _, tt := range tests {
+		tokens, errors := lexAll("<?php $x = " + tt.src)
+		if tt.errors == nil {
+			tt.errors = []string{}
+		}
+		if !reflect.DeepEqual(errors, tt.errors) {
+			t.Errorf("%q: errors\n got %q\nwant %q", tt.src, errors, tt.errors)
+		}
+		if got := stringValue(tokens); got != tt.value {
+			t.Errorf("%q: value %q, want %q", tt.src, got, tt.value)
+		}
+	}
+}
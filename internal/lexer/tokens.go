@@ -2,73 +2,241 @@ package lexer
 
 import (
 	"log"
+	"strings"
 
 	"github.com/codevault-llc/php-lint/internal/token"
 )
 
+// operators lists every punctuation token, longest first, so that the lexer
+// always takes the longest match ("**=" before "**" before "*").
+var operators = []struct {
+	lexeme string
+	kind   token.Kind
+}{
+	{"<=>", token.SPACESHIP},
+	{"**=", token.POW_ASSIGN},
+	{"...", token.ELLIPSIS},
+	{"<<=", token.SL_ASSIGN},
+	{">>=", token.SR_ASSIGN},
+	{"===", token.IDENTICAL},
+	{"!==", token.NOT_IDENTICAL},
+	{"??=", token.COALESCE_ASSIGN},
+	{"?->", token.NULLSAFE_ARROW},
+	{"==", token.EQ},
+	{"!=", token.NOT_EQ},
+	{"<>", token.NOT_EQ},
+	{"<=", token.LT_EQ},
+	{">=", token.GT_EQ},
+	{"&&", token.BOOLEAN_AND},
+	{"||", token.BOOLEAN_OR},
+	{"++", token.INC},
+	{"--", token.DEC},
+	{"+=", token.PLUS_ASSIGN},
+	{"-=", token.MINUS_ASSIGN},
+	{"*=", token.MUL_ASSIGN},
+	{"/=", token.DIV_ASSIGN},
+	{".=", token.CONCAT_ASSIGN},
+	{"%=", token.MOD_ASSIGN},
+	{"&=", token.AND_ASSIGN},
+	{"|=", token.OR_ASSIGN},
+	{"^=", token.XOR_ASSIGN},
+	{"<<", token.SL},
+	{">>", token.SR},
+	{"??", token.COALESCE},
+	{"**", token.POW},
+	{"->", token.ARROW},
+	{"=>", token.DOUBLE_ARROW},
+	{"::", token.DOUBLE_COLON},
+	{"#[", token.ATTRIBUTE},
+	{"+", token.PLUS},
+	{"-", token.MINUS},
+	{"*", token.ASTERISK},
+	{"/", token.SLASH},
+	{"%", token.PERCENT},
+	{".", token.DOT},
+	{"=", token.ASSIGN},
+	{"<", token.LT},
+	{">", token.GT},
+	{"!", token.BANG},
+	{"&", token.AMPERSAND},
+	{"|", token.PIPE},
+	{"^", token.CARET},
+	{"~", token.TILDE},
+	{"?", token.QUESTION},
+	{":", token.COLON},
+	{"@", token.AT},
+	{"\\", token.NS_SEPARATOR},
+	{"$", token.DOLLAR},
+	{";", token.SEMICOLON},
+	{",", token.COMMA},
+	{"(", token.LPAREN},
+	{")", token.RPAREN},
+	{"{", token.LBRACE},
+	{"}", token.RBRACE},
+	{"[", token.LBRACKET},
+	{"]", token.RBRACKET},
+}
+
+// NextToken returns the next token of the input. After the end of the input
+// it keeps returning EOF.
 func (l *Lexer) NextToken() token.Token {
+	if l.halted {
+		return l.readHalted()
+	}
+	if !l.trivia {
+		tok := l.nextToken()
+		l.countHalt(tok)
+		return tok
+	}
+
+	var leading []token.Trivia
+	if !l.inHTML {
+		leading = l.readTrivia(false)
+	}
+	tok := l.nextToken()
+	tok.Leading = leading
+	l.countHalt(tok)
+	// A closing tag switches back to inline HTML, which has no trivia, and
+	// the data after __halt_compiler(); is not code.
+	if !l.inHTML && !l.halted && tok.Kind != token.EOF {
+		tok.Trailing = l.readTrivia(true)
+	}
+	return tok
+}
+
+// countHalt stops the lexing after "__halt_compiler();" or
+// "__halt_compiler() ?>", as PHP does.
+func (l *Lexer) countHalt(tok token.Token) {
+	switch tok.Kind {
+	case token.HALT_COMPILER:
+		l.haltIn = 3
+	case token.LINE_COMMENT, token.BLOCK_COMMENT, token.DOC_COMMENT:
+	default:
+		if l.haltIn > 0 {
+			l.haltIn--
+			l.halted = l.haltIn == 0
+		}
+	}
+}
+
+// readHalted returns the rest of the input after "__halt_compiler();" as a
+// single INLINE_HTML token, which PHP leaves for the script to read, then
+// EOF.
+func (l *Lexer) readHalted() token.Token {
+	startPos := l.pos()
+	if l.ch == 0 {
+		return l.newTokenFromPos(token.EOF, "", startPos)
+	}
+	start := l.position
+	l.readTo(len(l.input))
+	return l.newTokenFromPos(token.INLINE_HTML, l.input[start:], startPos)
+}
+
+func (l *Lexer) nextToken() token.Token {
+	if l.inHTML {
+		return l.nextHTMLToken()
+	}
+
 	l.skipWhitespace()
 
-	startPos := token.Pos{Line: l.line, Col: l.column, Offset: l.position}
-	var tok token.Token
-
-	switch l.ch {
-	case '<':
-		if l.peekChar() == '?' {
-			// Check for '<?php'
-			if len(l.input) > l.position+4 && l.input[l.position:l.position+5] == "<?php" {
-				// Consume '<?php'
-				l.readChar() // ?
-				l.readChar() // p
-				l.readChar() // h
-				l.readChar() // p
-				tok = l.newTokenFromPos(token.OPEN_TAG, "<?php", startPos)
+	startPos := l.pos()
+
+	switch {
+	case l.ch == 0:
+		return l.newTokenFromPos(token.EOF, "", startPos)
+	case l.hasPrefix("?>"):
+		l.inHTML = true
+		return l.newTokenFromPos(token.CLOSE_TAG, l.readCloseTag(), startPos)
+	case l.isCommentStart():
+		kind, lexeme := l.readComment()
+		return l.newTokenFromPos(kind, lexeme, startPos)
+	case l.ch == '\'':
+		lexeme, parts := l.readSingleQuoted()
+		tok := l.newTokenFromPos(token.STRING, lexeme, startPos)
+		tok.Parts = parts
+		return tok
+	case l.ch == '"':
+		kind, lexeme, parts := l.readDoubleQuoted()
+		tok := l.newTokenFromPos(kind, lexeme, startPos)
+		tok.Parts = parts
+		return tok
+	case l.ch == '`':
+		lexeme, parts := l.readShellExec()
+		tok := l.newTokenFromPos(token.SHELL_EXEC, lexeme, startPos)
+		tok.Parts = parts
+		return tok
+	case l.isHeredocStart():
+		kind, lexeme, parts := l.readHeredoc()
+		tok := l.newTokenFromPos(kind, lexeme, startPos)
+		tok.Parts = parts
+		return tok
+	case l.ch == '$' && isLetter(l.peekChar()):
+		return l.newTokenFromPos(token.VARIABLE, l.readVariable(), startPos)
+	case isDigit(l.ch) || (l.ch == '.' && isDigit(l.peekChar())):
+		kind, lexeme := l.readNumber()
+		return l.newTokenFromPos(kind, lexeme, startPos)
+	case l.ch == '\\' && isLetter(l.peekChar()):
+		return l.newTokenFromPos(token.NAME_FULLY_QUALIFIED, l.readQualifiedName(), startPos)
+	case isLetter(l.ch):
+		identStart := l.position
+		ident := l.readIdentifier()
+		if l.ch == '\\' && isLetter(l.peekChar()) {
+			kind := token.Kind(token.NAME_QUALIFIED)
+			if strings.EqualFold(ident, "namespace") {
+				kind = token.NAME_RELATIVE
 			}
+			return l.newTokenFromPos(kind, ident+l.readQualifiedName(), startPos)
 		}
-	case '?':
-		if l.peekChar() == '>' {
-			l.readChar()
-			tok = l.newTokenFromPos(token.CLOSE_TAG, "?>", startPos)
+		kind := l.lookupIdent(ident)
+		switch {
+		case kind == token.YIELD:
+			if lexeme, ok := l.readYieldFrom(identStart); ok {
+				return l.newTokenFromPos(token.YIELD_FROM, lexeme, startPos)
+			}
+		case kind == token.IDENT && strings.EqualFold(ident, "enum") && l.reserves(token.ENUM) && l.isEnumDeclaration():
+			kind = token.ENUM
+		case kind == token.READONLY && l.isCall():
+			// PHP keeps readonly() a function, as it was before 8.1.
+			kind = token.IDENT
 		}
-	case '(':
-		tok = l.newTokenFromPos(token.LPAREN, "(", startPos)
-	case ')':
-		tok = l.newTokenFromPos(token.RPAREN, ")", startPos)
-	case '{':
-		tok = l.newTokenFromPos(token.LBRACE, "{", startPos)
-	case ',':
-		tok = l.newTokenFromPos(token.COMMA, ",", startPos)
-	case '"':
-		tok = l.newTokenFromPos(token.STRING, l.readString(), startPos)
-	case '}':
-		tok = l.newTokenFromPos(token.RBRACE, "}", startPos)
-	case '/':
-		if l.peekChar() == '*' {
-			l.readChar()
-			tok = l.newTokenFromPos(token.BLOCK_COMMENT, l.readBlockComment(), startPos)
-		} else if l.peekChar() == '/' {
-			l.readChar()
-			tok = l.newTokenFromPos(token.LINE_COMMENT, l.readLineComment(), startPos)
+		return l.newTokenFromPos(kind, ident, startPos)
+	case l.ch == '(':
+		if kind, lexeme, ok := l.readCast(); ok {
+			return l.newTokenFromPos(kind, lexeme, startPos)
 		}
-	case ';':
-		tok = l.newTokenFromPos(token.SEMICOLON, ";", startPos)
-	case '\'':
-		tok = l.newTokenFromPos(token.STRING, l.readString(), startPos)
-	case 0:
-		tok = l.newTokenFromPos(token.EOF, "", startPos)
-	default:
-		if isLetter(l.ch) {
-    ident := l.readIdentifier()
-    tok = l.newTokenFromPos(token.LookupIdent(ident), ident, startPos)
-    
-		return tok
-	} else {
-			log.Println("Illegal character listed !!", string(l.ch))
-			tok = l.newTokenFromPos(token.ILLEGAL, string(l.ch), startPos)
+	}
+
+	for _, op := range operators {
+		if l.hasPrefix(op.lexeme) {
+			l.readChars(len(op.lexeme))
+			return l.newTokenFromPos(op.kind, op.lexeme, startPos)
 		}
 	}
 
+	log.Println("Illegal character listed !!", string(l.ch))
+	illegal := string(l.ch)
 	l.readChar()
-	tok.Span.End = token.Pos{Line: l.line, Col: l.column, Offset: l.position}
-	return tok
-}
\ No newline at end of file
+	return l.newTokenFromPos(token.ILLEGAL, illegal, startPos)
+}
+
+// nextHTMLToken lexes outside of PHP code: a run of inline HTML or the
+// opening tag that switches the lexer back to PHP.
+func (l *Lexer) nextHTMLToken() token.Token {
+	startPos := l.pos()
+
+	switch {
+	case l.ch == 0:
+		return l.newTokenFromPos(token.EOF, "", startPos)
+	case l.hasPrefix("<?="):
+		l.inHTML = false
+		l.readChars(3)
+		return l.newTokenFromPos(token.OPEN_TAG_WITH_ECHO, "<?=", startPos)
+	case l.isOpenTag():
+		l.inHTML = false
+		lexeme := l.input[l.position : l.position+5]
+		l.readChars(5)
+		return l.newTokenFromPos(token.OPEN_TAG, lexeme, startPos)
+	}
+
+	return l.newTokenFromPos(token.INLINE_HTML, l.readInlineHTML(), startPos)
+}
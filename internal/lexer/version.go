@@ -0,0 +1,79 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Version is a PHP language version, such as 8.1.
+type Version struct {
+	Major, Minor int
+}
+
+// LatestVersion is the newest PHP version the lexer and parser know. It is
+// the default target, under which every supported construct is accepted.
+var LatestVersion = Version{8, 4}
+
+// ParseVersion parses a version such as "7.4", "8" or "8.1.2". The patch
+// level is ignored, since it never changes the syntax.
+func ParseVersion(s string) (Version, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ".", 3)
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(parts[0]); err != nil || v.Major < 5 {
+		return Version{}, fmt.Errorf("invalid PHP version %q", s)
+	}
+	if len(parts) > 1 {
+		if v.Minor, err = strconv.Atoi(parts[1]); err != nil || v.Minor < 0 {
+			return Version{}, fmt.Errorf("invalid PHP version %q", s)
+		}
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast reports whether v is major.minor or newer.
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || v.Major == major && v.Minor >= minor
+}
+
+// WithVersion makes the lexer treat the input as code for the given PHP
+// version: words reserved in later versions lex as plain identifiers, and
+// "#[" starts a comment before PHP 8.0, as it did then.
+func WithVersion(v Version) Option {
+	return func(l *Lexer) { l.version = v }
+}
+
+// Version returns the PHP version the lexer targets.
+func (l *Lexer) Version() Version {
+	return l.version
+}
+
+// keywordVersions holds the version that reserved each keyword newer than
+// PHP 7.0. Enum is a keyword only where it starts a declaration.
+var keywordVersions = map[token.Kind]Version{
+	token.FN:       {7, 4},
+	token.MATCH:    {8, 0},
+	token.ENUM:     {8, 1},
+	token.READONLY: {8, 1},
+}
+
+// reserves reports whether kind is a keyword in the targeted version.
+func (l *Lexer) reserves(kind token.Kind) bool {
+	since, ok := keywordVersions[kind]
+	return !ok || l.version.AtLeast(since.Major, since.Minor)
+}
+
+// lookupIdent is token.LookupIdent for the targeted version.
+func (l *Lexer) lookupIdent(ident string) token.Kind {
+	if kind := token.LookupIdent(ident); l.reserves(kind) {
+		return kind
+	}
+	return token.IDENT
+}
@@ -2,43 +2,273 @@ package linter
 
 import (
 	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/codevault-llc/php-lint/internal/config"
+	"github.com/codevault-llc/php-lint/internal/glob"
 	"github.com/codevault-llc/php-lint/internal/lexer"
 	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/plugin"
+	"github.com/codevault-llc/php-lint/internal/resolver"
 	"github.com/codevault-llc/php-lint/internal/rules"
 	"github.com/codevault-llc/php-lint/internal/stubs"
 	"github.com/codevault-llc/php-lint/pkg/types"
 	"github.com/rs/zerolog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// presetConfigs holds the built-in presets a config can extend, such as
+// php-lint:recommended in presets/recommended.json.
+//
+//go:embed presets/*.json
 var presetConfigs embed.FS
 
+// SyntaxErrorRule is the name under which parse errors are reported. It is
+// built in rather than a registered rule, and is enabled unless the config
+// turns it off explicitly.
+const SyntaxErrorRule = "syntax-error"
+
 type Linter struct {
-	config config.Config
-	logger zerolog.Logger
-	rules  []rules.Rule
+	config      config.Config
+	logger      zerolog.Logger
+	cascade     *config.Cascade
+	customRules []rules.Rule
+	plugins     []*plugin.Plugin
+	version     lexer.Version      // The PHP version code is parsed for
+	configDir   string             // The directory of the config file
+	stubs       *stubs.SymbolTable // The symbols declared by the stubs
+
+	mu           sync.Mutex
+	ruleSets     map[string]*ruleSet // By the rule settings they are built from
+	configErrors map[string]bool     // The config errors already logged
+}
+
+// ruleSet is what checks the files given the same rule settings.
+type ruleSet struct {
+	rules        []rules.Rule
+	plugins      []pluginRun
+	severities   map[string]protocol.DiagnosticSeverity // Severities set by the config, by rule
+	syntaxErrors bool
 }
 
 func New(configPath string, logger zerolog.Logger) (*Linter, error) {
-	cfg := config.New()
-	if cfg == nil {
-		logger.Error().Msg("Failed to create default config")
+	presets, err := fs.Sub(presetConfigs, "presets")
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.New(configPath, presets)
+	if err != nil {
+		return nil, err
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return nil, err
+	}
+	symbolTable := loadStubs(cfg.Stubs, configDir, logger)
+	customRules := loadCustomRules(cfg.CustomRules, configDir, logger)
+	plugins := startPlugins(cfg.Plugins, configDir, logger)
+
+	version := lexer.LatestVersion
+	if cfg.PHPVersion != "" {
+		if v, err := lexer.ParseVersion(cfg.PHPVersion); err != nil {
+			logger.Warn().Err(err).Msg("Ignoring php_version, parsing for the latest PHP version")
+		} else {
+			version = v
+		}
+	}
+
+	l := &Linter{
+		config:       *cfg,
+		logger:       logger,
+		cascade:      config.NewCascade(cfg, presets),
+		customRules:  customRules,
+		plugins:      plugins,
+		version:      version,
+		configDir:    configDir,
+		stubs:        symbolTable,
+		ruleSets:     map[string]*ruleSet{},
+		configErrors: map[string]bool{},
+	}
+	// Mistakes in the project's own rules stop the linter; those of the
+	// config files under it are logged as files are linted.
+	set, errs := l.newRuleSet(cfg.Rules)
+	if len(errs) > 0 {
+		l.Close()
+		return nil, errors.Join(errs...)
+	}
+	key, _ := json.Marshal(cfg.Rules)
+	l.ruleSets[string(key)] = set
+	logger.Info().Int("count", len(set.rules)).Msg("Active rules loaded")
+	return l, nil
+}
+
+// rulesFor returns the rule set checking a file, given the config files
+// above it.
+func (l *Linter) rulesFor(path string) *ruleSet {
+	settings, err := l.cascade.Rules(path)
+	if err != nil {
+		l.mu.Lock()
+		if !l.configErrors[err.Error()] {
+			l.configErrors[err.Error()] = true
+			l.logger.Error().Err(err).Msg("Failed to load config, using the project's rules")
+		}
+		l.mu.Unlock()
+		settings = l.config.Rules
+	}
+	return l.ruleSetFor(settings)
+}
+
+// ruleSetFor returns the rule set built from rule settings, building it
+// the first time they are seen.
+func (l *Linter) ruleSetFor(settings map[string]config.RuleSetting) *ruleSet {
+	// Maps encode with sorted keys, so equal settings give equal keys.
+	key, _ := json.Marshal(settings)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if set, ok := l.ruleSets[string(key)]; ok {
+		return set
+	}
+	set, errs := l.newRuleSet(settings)
+	for _, err := range errs {
+		l.logger.Error().Err(err).Msg("Invalid rule setting, the rule is disabled")
 	}
+	l.ruleSets[string(key)] = set
+	return set
+}
 
-	// Parse stubs
+// newRuleSet builds the rule set of rule settings. It returns the errors
+// in the settings too, the rules with errors being left out.
+func (l *Linter) newRuleSet(settings map[string]config.RuleSetting) (*ruleSet, []error) {
+	pluginRules := map[string]bool{}
+	for _, p := range l.plugins {
+		for _, rule := range p.Rules() {
+			pluginRules[rule.Name] = true
+		}
+	}
+
+	// Collect active rules
+	// Rules are taken in the order of the config, for errors to be too.
+	names := make([]string, 0, len(settings))
+	for ruleName := range settings {
+		names = append(names, ruleName)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := settings[names[i]].Pos(), settings[names[j]].Pos()
+		if a != b {
+			return a.File < b.File || a.File == b.File && (a.Line < b.Line || a.Line == b.Line && a.Col < b.Col)
+		}
+		return names[i] < names[j]
+	})
+
+	var errs []error
+	activeRules := []rules.Rule{}
+	for _, ruleName := range names {
+		setting := settings[ruleName]
+		if !setting.Enabled() || ruleName == SyntaxErrorRule || pluginRules[ruleName] {
+			continue
+		}
+		rule, found := rules.Lookup(ruleName)
+		if !found {
+			errs = append(errs, settingError(setting, fmt.Errorf("unknown rule %q", ruleName)))
+			continue
+		}
+		rule, err := rules.Configure(rule, setting.Options)
+		if err != nil {
+			errs = append(errs, settingError(setting, err))
+			continue
+		}
+		activeRules = append(activeRules, rule)
+	}
+	// Custom rules are enabled unless the config turns them off.
+	for _, rule := range l.customRules {
+		if _, configured := settings[rule.Name()]; !configured {
+			activeRules = append(activeRules, rule)
+		}
+	}
+
+	// So are the rules of plugins.
+	var runs []pluginRun
+	for _, p := range l.plugins {
+		run := pluginRun{plugin: p, options: map[string]json.RawMessage{}}
+		for _, rule := range p.Rules() {
+			setting, configured := settings[rule.Name]
+			if configured && !setting.Enabled() {
+				continue
+			}
+			run.rules = append(run.rules, rule.Name)
+			if setting.Options != nil {
+				run.options[rule.Name] = setting.Options
+			}
+		}
+		if len(run.rules) > 0 {
+			runs = append(runs, run)
+		}
+	}
+
+	// Configured severities replace those of the rules.
+	severities := map[string]protocol.DiagnosticSeverity{}
+	for ruleName, setting := range settings {
+		if severity := setting.DiagnosticSeverity(); severity != 0 {
+			severities[ruleName] = severity
+		}
+	}
+
+	syntaxErrors, configured := settings[SyntaxErrorRule]
+	return &ruleSet{
+		rules:        activeRules,
+		plugins:      runs,
+		severities:   severities,
+		syntaxErrors: syntaxErrors.Enabled() || !configured,
+	}, errs
+}
+
+// settingError returns err located where the rule is set in the config.
+func settingError(setting config.RuleSetting, err error) error {
+	if pos := setting.Pos(); pos.File != "" {
+		return fmt.Errorf("%s: %w", pos, err)
+	}
+	return err
+}
+
+// pluginRun is a plugin and the rules it is asked to check.
+type pluginRun struct {
+	plugin  *plugin.Plugin
+	rules   []string
+	options map[string]json.RawMessage // The options of the rules set in the config
+}
+
+// loadStubs returns the symbol table of the functions and classes declared
+// in the stubs, relative to baseDir. The symbols are kept as stubs, for a
+// workspace to add those of the code to.
+func loadStubs(paths []string, baseDir string, logger zerolog.Logger) *stubs.SymbolTable {
 	symbolTable := stubs.NewSymbolTable()
-	logger.Debug().Strs("stubs", cfg.Stubs).Msg("Parsing stubs to build symbol table")
-	for _, stubPath := range cfg.Stubs {
+	logger.Debug().Strs("stubs", paths).Msg("Parsing stubs to build symbol table")
+	for _, stubPath := range paths {
+		if !filepath.IsAbs(stubPath) {
+			stubPath = filepath.Join(baseDir, stubPath)
+		}
 		err := filepath.Walk(stubPath, func(p string, info os.FileInfo, err error) error {
-			if err != nil { return err }
+			if err != nil {
+				return err
+			}
 			if !info.IsDir() && filepath.Ext(p) == ".php" {
-				content, _ := os.ReadFile(p)
-				lxr := lexer.New(string(content))
-				psr := parser.New(lxr)
-				program := psr.ParseProgram()
+				content, err := os.ReadFile(p)
+				if err != nil {
+					return err
+				}
+				program := parser.New(lexer.New(string(content))).ParseProgram()
+				resolver.Resolve(program)
 				symbolTable.AddSymbolsFromAST(program)
 			}
 			return nil
@@ -47,53 +277,140 @@ func New(configPath string, logger zerolog.Logger) (*Linter, error) {
 			logger.Warn().Err(err).Str("path", stubPath).Msg("Failed to walk stub path")
 		}
 	}
+	symbolTable.KeepAsStubs()
+	logger.Debug().Int("functions", symbolTable.FunctionCount()).Int("classes", symbolTable.ClassCount()).Msg("Symbol table built")
+	return symbolTable
+}
 
-	logger.Debug().Int("functions", symbolTable.FunctionCount()).Msg("Symbol table built")
+// startPlugins starts the configured plugins. A plugin that fails to
+// start is left out.
+func startPlugins(configs []config.PluginConfig, configDir string, logger zerolog.Logger) []*plugin.Plugin {
+	var plugins []*plugin.Plugin
+	for _, cfg := range configs {
+		command := cfg.Command
+		if strings.ContainsRune(command, filepath.Separator) && !filepath.IsAbs(command) {
+			command = filepath.Join(configDir, command)
+		}
+		var timeout time.Duration
+		if cfg.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+				logger.Warn().Err(err).Str("plugin", cfg.Command).Msg("Ignoring plugin timeout")
+			}
+		}
 
-	// Collect active rules
-	activeRules := []rules.Rule{}
-	for ruleName, enabled := range cfg.Rules {
-		if enabled {
-			found := false
-			for _, rule := range rules.GetRegistered() {
-				if rule.Name() == ruleName {
-					activeRules = append(activeRules, rule)
-					found = true
-					break
-				}
+		p, err := plugin.Start(plugin.Config{Command: command, Args: cfg.Args, Dir: configDir, Timeout: timeout}, logger)
+		if err != nil {
+			logger.Error().Err(err).Str("plugin", cfg.Command).Msg("Failed to start plugin")
+			continue
+		}
+		logger.Debug().Str("plugin", p.Name()).Msg("Plugin started")
+		plugins = append(plugins, p)
+	}
+	return plugins
+}
+
+// loadCustomRules loads and registers the rules declared in the files
+// matching globs, relative to baseDir. A file that does not load is
+// skipped, as is a rule named like a built-in or another custom rule.
+func loadCustomRules(globs []string, baseDir string, logger zerolog.Logger) []rules.Rule {
+	var loaded []rules.Rule
+	names := map[string]bool{}
+	for _, glob := range globs {
+		if !filepath.IsAbs(glob) {
+			glob = filepath.Join(baseDir, glob)
+		}
+		files, err := filepath.Glob(glob)
+		if err == nil && len(files) == 0 {
+			err = os.ErrNotExist
+		}
+		if err != nil {
+			logger.Error().Err(err).Str("path", glob).Msg("Failed to find custom rules")
+			continue
+		}
+		for _, file := range files {
+			fileRules, err := rules.LoadCustomRules(file, baseDir)
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to load custom rules")
+				continue
 			}
-			if !found {
-				logger.Warn().Str("rule", ruleName).Msg("Configured rule not found in registry")
+			for _, rule := range fileRules {
+				if names[rule.Name()] {
+					logger.Error().Str("rule", rule.Name()).Str("path", file).Msg("Custom rule is declared twice")
+					continue
+				}
+				// A custom rule loaded before, by an earlier linter, is
+				// replaced.
+				if existing, found := rules.Lookup(rule.Name()); found {
+					if _, custom := existing.(*rules.CustomRule); !custom {
+						logger.Error().Str("rule", rule.Name()).Str("path", file).Msg("Custom rule has the name of a built-in rule")
+						continue
+					}
+				}
+				rules.Register(rule)
+				names[rule.Name()] = true
+				loaded = append(loaded, rule)
 			}
 		}
 	}
-	logger.Info().Int("count", len(activeRules)).Msg("Active rules loaded")
-
-	return &Linter{
-		config: *cfg,
-		logger: logger,
-		rules:  activeRules,
-	}, nil
+	if len(loaded) > 0 {
+		logger.Debug().Int("count", len(loaded)).Msg("Custom rules loaded")
+	}
+	return loaded
 }
 
 func (l *Linter) LintFile(path string, content []byte, symbolTable *stubs.SymbolTable) []types.Issue {
-	lxr := lexer.New(string(content))
+	lxr := lexer.New(string(content), lexer.WithVersion(l.version))
 	psr := parser.New(lxr)
 	program := psr.ParseProgram()
+	resolver.Resolve(program)
 
+	set := l.rulesFor(path)
 	var allIssues []types.Issue
 
-	for _, rule := range l.rules {
+	if set.syntaxErrors {
+		for _, err := range psr.Errors() {
+			allIssues = append(allIssues, types.Issue{
+				RuleName: SyntaxErrorRule,
+				Message:  err.Message,
+				Range:    err.Span,
+				Severity: 1,
+				Source:   "php-lint",
+			})
+		}
+	}
+
+	// Rules still run on the statements that did parse.
+	for _, rule := range set.rules {
 		issues := rule.Check(path, content, program, symbolTable)
 		allIssues = append(allIssues, issues...)
 	}
+	// A plugin that fails only loses its own issues.
+	for _, run := range set.plugins {
+		issues, err := run.plugin.Check(path, content, program, l.version, run.rules, run.options)
+		if err != nil {
+			l.logger.Warn().Err(err).Str("plugin", run.plugin.Name()).Str("path", path).Msg("Plugin failed to check file")
+			continue
+		}
+		allIssues = append(allIssues, issues...)
+	}
+	allIssues = suppress(allIssues, program)
 
+	for i := range allIssues {
+		allIssues[i].File = path
+		if severity, ok := set.severities[allIssues[i].RuleName]; ok {
+			allIssues[i].Severity = severity
+		}
+	}
 	return allIssues
 }
 
 func (l *Linter) LintFiles(paths []string, symbolTable *stubs.SymbolTable) []types.Issue {
 	var allIssues []types.Issue
 	for _, path := range paths {
+		if l.Excluded(path) {
+			continue
+		}
 		content, err := os.ReadFile(path)
 		if err != nil {
 			l.logger.Error().Err(err).Str("path", path).Msg("Failed to read file")
@@ -105,6 +422,74 @@ func (l *Linter) LintFiles(paths []string, symbolTable *stubs.SymbolTable) []typ
 	return allIssues
 }
 
+// Close stops the plugins of the linter.
+func (l *Linter) Close() {
+	for _, p := range l.plugins {
+		p.Close()
+	}
+}
+
+// Schema returns the JSON Schema of the config files, knowing the built-in
+// rules and presets.
+func Schema() ([]byte, error) {
+	presets, err := fs.Sub(presetConfigs, "presets")
+	if err != nil {
+		return nil, err
+	}
+	schemas := []config.RuleSchema{{Name: SyntaxErrorRule, Description: "Reports the code that does not parse."}}
+	for _, rule := range rules.GetRegistered() {
+		schema := config.RuleSchema{Name: rule.Name(), Description: rule.Description()}
+		if configurable, ok := rule.(rules.Configurable); ok {
+			schema.Options = configurable.DefaultOptions()
+		}
+		schemas = append(schemas, schema)
+	}
+	return config.Schema(schemas, config.PresetNames(presets))
+}
+
+// Excluded reports whether the excludes of the config leave a file out.
+// An exclude is a glob relative to the config file, matched by the file or
+// a directory it is in, so "vendor" leaves out every vendor directory.
+func (l *Linter) Excluded(file string) bool {
+	name := l.relative(file)
+	for _, exclude := range l.config.Excludes {
+		g := l.relative(exclude)
+		for dir := name; ; dir = path.Dir(dir) {
+			if glob.Match(g, dir) {
+				return true
+			}
+			if parent := path.Dir(dir); parent == dir || parent == "." {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// relative returns a path as slash-separated and relative to the config
+// file, if it is under it.
+func (l *Linter) relative(name string) string {
+	if !filepath.IsAbs(name) {
+		return filepath.ToSlash(name)
+	}
+	if rel, err := filepath.Rel(l.configDir, name); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(name)
+}
+
+// Stubs returns the symbol table of the stubs of the config, for the
+// workspace to start from.
+func (l *Linter) Stubs() *stubs.SymbolTable {
+	return l.stubs
+}
+
 func (l *Linter) Config() *config.Config {
 	return &l.config
-}
\ No newline at end of file
+}
+
+// PHPVersion returns the PHP version files are parsed for, from the
+// php_version setting.
+func (l *Linter) PHPVersion() lexer.Version {
+	return l.version
+}
@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// newLinter returns a linter of a config in a directory holding files,
+// given by their path relative to it.
+func newLinter(t *testing.T, files map[string]string) (*Linter, string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l, err := New(filepath.Join(dir, ".php-lint.json"), zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(l.Close)
+	return l, dir
+}
+
+func TestExcluded(t *testing.T) {
+	l, dir := newLinter(t, map[string]string{
+		".php-lint.json": `{"extends": "php-lint:wordpress", "excludes": ["build/*.php", "/tmp/abs"]}`,
+	})
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{"src/a.php", false},
+		{"vendor/a.php", true},
+		{"src/vendor/lib/a.php", true},
+		{"vendors/a.php", false},
+		{"wp-content/uploads/a.php", true},
+		{"wp-content/plugins/a.php", false},
+		{"build/a.php", true},
+		{"build/sub/a.php", false},
+		{"src/build/a.php", false},
+	}
+	for _, tt := range tests {
+		if got := l.Excluded(filepath.Join(dir, tt.path)); got != tt.excluded {
+			t.Errorf("Excluded(%q) = %v, want %v", tt.path, got, tt.excluded)
+		}
+	}
+	if !l.Excluded("/tmp/abs/a.php") || l.Excluded("/tmp/absent.php") {
+		t.Errorf("absolute excludes match by directory")
+	}
+}
+
+func TestStubs(t *testing.T) {
+	l, dir := newLinter(t, map[string]string{
+		".php-lint.json": `{"stubs": ["stubs"], "rules": {"undefined-function": "error"}}`,
+		"stubs/core.php": "<?php function strlen($s) {} class ArrayObject {}",
+		"src/code.php":   "<?php strlen('a'); missing();",
+	})
+	table := l.Stubs()
+	if !table.IsFunctionDefined("strlen") || !table.IsClassDefined("arrayobject") {
+		t.Fatalf("stub symbols are missing")
+	}
+	// The symbols of the code are forgotten, not those of the stubs.
+	table.AddFunction("local")
+	table.ClearLocalSymbols()
+	if table.IsFunctionDefined("local") || !table.IsFunctionDefined("strlen") {
+		t.Errorf("ClearLocalSymbols kept the code's symbols or lost the stubs'")
+	}
+
+	issues := l.LintFiles([]string{filepath.Join(dir, "src/code.php")}, table)
+	if len(issues) != 1 || issues[0].RuleName != "undefined-function" {
+		t.Fatalf("got issues %+v, want missing() undefined", issues)
+	}
+}
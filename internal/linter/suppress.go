@@ -0,0 +1,111 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/rules"
+	"github.com/codevault-llc/php-lint/internal/token"
+	"github.com/codevault-llc/php-lint/pkg/types"
+)
+
+// NoLintAttribute is the attribute that suppresses issues inside the
+// declaration it is put on: #[NoLint('undefined-function', 'no-eval')]
+// suppresses the named rules and #[NoLint] all of them except syntax
+// errors. Only the short class name is compared, so projects may declare
+// the attribute class in any namespace, or not at all.
+const NoLintAttribute = "NoLint"
+
+// suppression is a region of a file in which some rules are turned off.
+type suppression struct {
+	span  token.Span
+	rules []string // nil for all rules
+}
+
+// covers reports whether issue is suppressed by s.
+func (s suppression) covers(issue types.Issue) bool {
+	offset := issue.Range.Start.Offset
+	if offset < s.span.Start.Offset || offset >= s.span.End.Offset {
+		return false
+	}
+	if s.rules == nil {
+		return issue.RuleName != SyntaxErrorRule
+	}
+	for _, rule := range s.rules {
+		if rule == issue.RuleName {
+			return true
+		}
+	}
+	return false
+}
+
+type suppressionVisitor struct {
+	suppressions []suppression
+}
+
+func (v *suppressionVisitor) enter(node ast.Node, ancestors []ast.Node) ast.Action {
+	for _, attr := range rules.Attributes(node) {
+		name := attr.Name.FullName()
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		if !strings.EqualFold(name, NoLintAttribute) {
+			continue
+		}
+		v.suppressions = append(v.suppressions, suppression{
+			span:  token.Span{Start: node.Pos(), End: node.End()},
+			rules: suppressedRules(attr),
+		})
+	}
+	return ast.Continue
+}
+
+// suppressedRules returns the rule names given to a NoLint attribute, as
+// strings or arrays of strings, or nil if it has no arguments.
+func suppressedRules(attr *ast.Attribute) []string {
+	if len(attr.Arguments) == 0 {
+		return nil
+	}
+	names := []string{}
+	var collect func(expr ast.Expr)
+	collect = func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case *ast.StringLiteral:
+			names = append(names, e.Value)
+		case *ast.ArrayLiteral:
+			for _, item := range e.Items {
+				if item != nil {
+					collect(item.Value)
+				}
+			}
+		}
+	}
+	for _, arg := range attr.Arguments {
+		collect(arg.Value)
+	}
+	return names
+}
+
+// suppress drops the issues turned off by NoLint attributes in program.
+func suppress(issues []types.Issue, program *ast.Program) []types.Issue {
+	visitor := &suppressionVisitor{}
+	ast.Inspect(program, visitor.enter)
+	if len(visitor.suppressions) == 0 {
+		return issues
+	}
+
+	kept := issues[:0]
+	for _, issue := range issues {
+		suppressed := false
+		for _, s := range visitor.suppressions {
+			if s.covers(issue) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
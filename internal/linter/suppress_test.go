@@ -0,0 +1,88 @@
+package linter
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNoLint(t *testing.T) {
+	l, dir := newLinter(t, map[string]string{
+		".php-lint.json": `{"rules": {"security-no-eval": "error", "undefined-function": "error"}}`,
+	})
+	tests := []struct {
+		name string
+		src  string
+		want []string // "line rule" of the issues kept
+	}{
+		{
+			"function",
+			"#[NoLint]\nfunction f() {\n    eval('1');\n    missing();\n}\neval('2');\n",
+			[]string{"6 security-no-eval"},
+		},
+		{
+			"function, one rule",
+			"#[NoLint('security-no-eval')]\nfunction f() {\n    eval('1');\n    missing();\n}\neval('2');\n",
+			[]string{"4 undefined-function", "6 security-no-eval"},
+		},
+		{
+			"method",
+			"class C {\n    #[NoLint('undefined-function')]\n    function a() { missing(); }\n    function b() { missing(); }\n}\n",
+			[]string{"4 undefined-function"},
+		},
+		{
+			"method, all rules",
+			"class C {\n    #[NoLint]\n    function a() { missing(); eval('1'); }\n    function b() { eval('2'); }\n}\n",
+			[]string{"4 security-no-eval"},
+		},
+		{
+			"class",
+			"#[NoLint]\nclass C {\n    function a() { missing(); }\n    function b() { eval('1'); }\n}\nmissing();\n",
+			[]string{"6 undefined-function"},
+		},
+		{
+			"class, several rules",
+			"#[NoLint(['security-no-eval', 'undefined-function'])]\nclass C {\n    function a() { missing(); eval('1'); }\n}\nfunction f() { eval('2'); }\n",
+			[]string{"5 security-no-eval"},
+		},
+		{
+			// Only the short name counts, in any case.
+			"namespaced",
+			"#[\\Tools\\Lint\\nolint]\nfunction f() { eval('1'); }\n",
+			nil,
+		},
+		{
+			"other attribute",
+			"#[Pure]\nfunction f() { eval('1'); }\n",
+			[]string{"2 security-no-eval"},
+		},
+		{
+			"other rule",
+			"#[NoLint('style-function-case')]\nfunction f() { eval('1'); }\n",
+			[]string{"2 security-no-eval"},
+		},
+		{
+			// Syntax errors are never suppressed.
+			"syntax error",
+			"#[NoLint]\nfunction f() { $a = ; }\n",
+			[]string{"2 syntax-error"},
+		},
+	}
+	for _, tt := range tests {
+		issues := l.LintFile(filepath.Join(dir, "a.php"), []byte("<?php\n"+tt.src), l.Stubs())
+		got := []string{}
+		for _, issue := range issues {
+			got = append(got, fmt.Sprintf("%d %s", issue.Range.Start.Line-1, issue.RuleName))
+		}
+		sort.Strings(got)
+		if tt.want == nil {
+			tt.want = []string{}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, strings.Join(got, ", "), strings.Join(tt.want, ", "))
+		}
+	}
+}
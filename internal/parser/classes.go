@@ -0,0 +1,495 @@
+package parser
+
+import (
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// isDeclaration reports whether curTok starts a function, class, interface,
+// trait or enum declaration.
+func (p *Parser) isDeclaration() bool {
+	switch p.curTok.Kind {
+	case token.FUNCTION:
+		return p.isFunctionDeclaration()
+	case token.INTERFACE, token.TRAIT, token.ENUM:
+		return true
+	case token.ABSTRACT, token.FINAL, token.READONLY, token.CLASS:
+		return p.isClassDeclaration()
+	}
+	return false
+}
+
+// parseDeclaration parses the declaration starting at curTok, see
+// isDeclaration.
+func (p *Parser) parseDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	switch p.curTok.Kind {
+	case token.FUNCTION:
+		return p.parseFunctionDeclaration(attrs)
+	case token.INTERFACE:
+		return p.parseInterfaceDeclaration(attrs)
+	case token.TRAIT:
+		return p.parseTraitDeclaration(attrs)
+	case token.ENUM:
+		return p.parseEnumDeclaration(attrs)
+	}
+	return p.parseClassDeclaration(attrs)
+}
+
+// isClassDeclaration reports whether curTok starts "modifiers? class Name".
+// A bare 'readonly' may also be a call to a function of that name.
+func (p *Parser) isClassDeclaration() bool {
+	n := 0
+	tok := p.curTok
+	for tok.Kind == token.ABSTRACT || tok.Kind == token.FINAL || tok.Kind == token.READONLY {
+		n++
+		tok = p.peekAt(n)
+	}
+	return tok.Kind == token.CLASS && p.peekAt(n+1).Kind == token.IDENT
+}
+
+// isMemberName reports whether tok can name a method, class constant or enum
+// case. Unlike other names these may be reserved words, as in
+// "function list()", or words reserved in later versions only.
+func isMemberName(tok token.Token) bool {
+	return tok.Kind == token.IDENT || tok.Kind == token.ENUM || token.LookupIdent(tok.Lexeme) == tok.Kind
+}
+
+// parseMemberName turns curTok into an identifier if it can name a member.
+func (p *Parser) parseMemberName() *ast.Identifier {
+	if !isMemberName(p.curTok) {
+		p.unexpected(p.curTok, token.IDENT)
+		return nil
+	}
+	return p.parseIdentifier().(*ast.Identifier)
+}
+
+// parseNameList parses "A, B, C" with curTok on the first name and leaves
+// curTok on the last one.
+func (p *Parser) parseNameList() []*ast.Identifier {
+	if !isName(p.curTok.Kind) {
+		p.unexpected(p.curTok, token.IDENT)
+		return nil
+	}
+	names := []*ast.Identifier{p.parseIdentifier().(*ast.Identifier)}
+	for p.peekTok.Kind == token.COMMA && isName(p.peekAt(2).Kind) {
+		p.nextToken()
+		p.nextToken()
+		names = append(names, p.parseIdentifier().(*ast.Identifier))
+	}
+	return names
+}
+
+// declStart returns where a declaration starting at curTok begins, including
+// its attributes.
+func (p *Parser) declStart(attrs []*ast.AttributeGroup) token.Pos {
+	if len(attrs) > 0 {
+		return attrs[0].Pos()
+	}
+	return p.curTok.Span.Start
+}
+
+// parseClassDeclaration parses
+// "modifiers? class Name extends Parent implements A, B { members }".
+func (p *Parser) parseClassDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	decl := &ast.ClassDecl{Attributes: attrs}
+	decl.S = p.declStart(attrs)
+
+	for p.curTok.Kind != token.CLASS {
+		if p.curTok.Kind == token.READONLY {
+			p.requireVersion(p.curTok, 8, 2, "readonly classes")
+		}
+		mod, _ := modifierOf(p.curTok.Kind)
+		decl.Modifiers |= mod
+		p.nextToken()
+	}
+	decl.Token = p.curTok
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	decl.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if !p.parseClassTail(decl) {
+		return nil
+	}
+	return decl
+}
+
+// parseClassTail parses "extends Parent implements A, B { members }" after
+// the name of a named class or the arguments of an anonymous class.
+func (p *Parser) parseClassTail(decl *ast.ClassDecl) bool {
+	if p.expectPeek(token.EXTENDS) {
+		if !p.expectName() {
+			return false
+		}
+		decl.Extends = p.parseIdentifier().(*ast.Identifier)
+	}
+	if p.expectPeek(token.IMPLEMENTS) {
+		p.nextToken()
+		decl.Implements = p.parseNameList()
+	}
+
+	if !p.expect(token.LBRACE) {
+		return false
+	}
+	decl.Members = p.parseClassBody()
+	decl.E = p.curTok.Span.End
+	return true
+}
+
+// parseInterfaceDeclaration parses "interface Name extends A, B { members }".
+func (p *Parser) parseInterfaceDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	decl := &ast.InterfaceDecl{Token: p.curTok, Attributes: attrs}
+	decl.S = p.declStart(attrs)
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	decl.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if p.expectPeek(token.EXTENDS) {
+		p.nextToken()
+		decl.Extends = p.parseNameList()
+	}
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	decl.Members = p.parseClassBody()
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseTraitDeclaration parses "trait Name { members }".
+func (p *Parser) parseTraitDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	decl := &ast.TraitDecl{Token: p.curTok, Attributes: attrs}
+	decl.S = p.declStart(attrs)
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	decl.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	decl.Members = p.parseClassBody()
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseEnumDeclaration parses "enum Name: type implements A, B { members }".
+func (p *Parser) parseEnumDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	decl := &ast.EnumDecl{Token: p.curTok, Attributes: attrs}
+	p.requireVersion(p.curTok, 8, 1, "enums")
+	decl.S = p.declStart(attrs)
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	decl.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if p.expectPeek(token.COLON) {
+		p.nextToken()
+		decl.BackingType = p.parseType()
+	}
+	if p.expectPeek(token.IMPLEMENTS) {
+		p.nextToken()
+		decl.Implements = p.parseNameList()
+	}
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	decl.Members = p.parseClassBody()
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseClassBody parses the members up to the '}' matching the '{' in
+// curTok. Members that cannot be parsed are skipped up to the next ';'.
+func (p *Parser) parseClassBody() []ast.Stmt {
+	members := []ast.Stmt{}
+
+	p.nextToken()
+	for p.curTok.Kind != token.RBRACE && p.curTok.Kind != token.EOF {
+		start := p.curTok
+		if member := p.parseClassMember(); member != nil {
+			p.attachDoc(member)
+			members = append(members, member)
+		} else {
+			p.synchronize(start)
+		}
+		p.nextToken()
+	}
+	if p.curTok.Kind == token.EOF {
+		p.unexpected(p.curTok, token.RBRACE)
+	}
+	return members
+}
+
+// parseClassMember parses a single member of a class-like body: a trait use,
+// an enum case, a constant, a method or a property declaration.
+func (p *Parser) parseClassMember() ast.Stmt {
+	start := p.curTok.Span.Start
+
+	var attrs []*ast.AttributeGroup
+	if p.curTok.Kind == token.ATTRIBUTE {
+		attrs = p.parseAttributeGroups()
+		p.nextToken()
+	}
+
+	switch p.curTok.Kind {
+	case token.USE:
+		return p.parseTraitUse()
+	case token.CASE:
+		return p.parseEnumCase(attrs, start)
+	}
+
+	var mods ast.Modifiers
+	for {
+		if p.curTok.Kind == token.VAR {
+			mods |= ast.ModPublic
+			p.nextToken()
+			continue
+		}
+		p.promoteModifier()
+		mod, ok := modifierOf(p.curTok.Kind)
+		if !ok {
+			break
+		}
+		p.checkModifierVersion(p.curTok, mod)
+		mods |= mod
+		p.nextToken()
+	}
+
+	switch p.curTok.Kind {
+	case token.CONST:
+		return p.parseClassConst(attrs, mods, start)
+	case token.FUNCTION:
+		return p.parseMethod(attrs, mods, start)
+	}
+	return p.parsePropertyDecl(attrs, mods, start)
+}
+
+// parseMethod parses "function &name(params): type { body }" with curTok on
+// the 'function' keyword. Abstract and interface methods end with ';'
+// instead of a body.
+func (p *Parser) parseMethod(attrs []*ast.AttributeGroup, mods ast.Modifiers, start token.Pos) ast.Stmt {
+	method := &ast.MethodDecl{Token: p.curTok, Attributes: attrs, Modifiers: mods}
+	method.S = start
+
+	if p.peekTok.Kind == token.AMPERSAND {
+		p.nextToken()
+		method.ByRef = true
+	}
+	p.nextToken()
+	if method.Name = p.parseMemberName(); method.Name == nil {
+		return nil
+	}
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	method.Params = p.parseParams()
+	method.ReturnType = p.parseReturnType()
+
+	if p.expectPeek(token.LBRACE) {
+		method.Body = p.parseBlockStatement()
+	} else if !p.expectPeek(token.SEMICOLON) {
+		p.peekError(token.LBRACE, token.SEMICOLON)
+		return nil
+	}
+	method.E = p.curTok.Span.End
+	return method
+}
+
+// parsePropertyDecl parses "type? $a = default, $b;" with curTok on the
+// type or the first variable; the modifiers were already consumed.
+func (p *Parser) parsePropertyDecl(attrs []*ast.AttributeGroup, mods ast.Modifiers, start token.Pos) ast.Stmt {
+	decl := &ast.PropertyDecl{Attributes: attrs, Modifiers: mods}
+	decl.S = start
+
+	if p.curTok.Kind != token.VARIABLE {
+		p.requireVersion(p.curTok, 7, 4, "typed properties")
+		if decl.Type = p.parseType(); decl.Type == nil {
+			return nil
+		}
+		p.nextToken()
+	}
+
+	for p.curTok.Kind == token.VARIABLE {
+		item := &ast.PropertyItem{Name: p.parseVariable().(*ast.Variable)}
+		item.S = p.curTok.Span.Start
+		if p.expectPeek(token.ASSIGN) {
+			item.Default = p.parseInitializer()
+		}
+		item.E = p.curTok.Span.End
+		decl.Props = append(decl.Props, item)
+
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if len(decl.Props) == 0 {
+		p.unexpected(p.curTok, token.VARIABLE)
+		return nil
+	}
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseClassConst parses "const type? NAME = value, ...;" with curTok on the
+// 'const' keyword.
+func (p *Parser) parseClassConst(attrs []*ast.AttributeGroup, mods ast.Modifiers, start token.Pos) ast.Stmt {
+	decl := &ast.ClassConstDecl{Token: p.curTok, Attributes: attrs, Modifiers: mods}
+	decl.S = start
+	if mods&(ast.ModPublic|ast.ModProtected|ast.ModPrivate) != 0 {
+		p.requireVersion(p.curTok, 7, 1, "class constant visibility modifiers")
+	}
+	if mods&ast.ModFinal != 0 {
+		p.requireVersion(p.curTok, 8, 1, "final class constants")
+	}
+
+	// A typed constant has a type between 'const' and the name.
+	if p.peekAt(2).Kind != token.ASSIGN {
+		p.requireVersion(p.peekTok, 8, 3, "typed class constants")
+		p.nextToken()
+		if decl.Type = p.parseType(); decl.Type == nil {
+			return nil
+		}
+	}
+
+	if decl.Consts = p.parseConstItems(); decl.Consts == nil {
+		return nil
+	}
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseConstItems parses "NAME = value, ..." with curTok on the token
+// before the first name and leaves curTok on the last value.
+func (p *Parser) parseConstItems() []*ast.ConstItem {
+	items := []*ast.ConstItem{}
+	for {
+		p.nextToken()
+		item := &ast.ConstItem{}
+		item.S = p.curTok.Span.Start
+		if item.Name = p.parseMemberName(); item.Name == nil {
+			return nil
+		}
+		if !p.expect(token.ASSIGN) {
+			return nil
+		}
+		item.Value = p.parseInitializer()
+		item.E = p.curTok.Span.End
+		items = append(items, item)
+
+		if !p.expectPeek(token.COMMA) {
+			return items
+		}
+	}
+}
+
+// parseEnumCase parses "case Name = value;" with curTok on 'case'.
+func (p *Parser) parseEnumCase(attrs []*ast.AttributeGroup, start token.Pos) ast.Stmt {
+	decl := &ast.EnumCaseDecl{Token: p.curTok, Attributes: attrs}
+	decl.S = start
+
+	p.nextToken()
+	if decl.Name = p.parseMemberName(); decl.Name == nil {
+		return nil
+	}
+	if p.expectPeek(token.ASSIGN) {
+		decl.Value = p.parseInitializer()
+	}
+
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+	decl.E = p.curTok.Span.End
+	return decl
+}
+
+// parseTraitUse parses "use A, B;" or "use A, B { adaptations }" with curTok
+// on 'use'.
+func (p *Parser) parseTraitUse() ast.Stmt {
+	stmt := &ast.TraitUseStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if stmt.Traits = p.parseNameList(); stmt.Traits == nil {
+		return nil
+	}
+
+	if p.expectPeek(token.LBRACE) {
+		p.nextToken()
+		for p.curTok.Kind != token.RBRACE && p.curTok.Kind != token.EOF {
+			if adaptation := p.parseTraitAdaptation(); adaptation != nil {
+				stmt.Adaptations = append(stmt.Adaptations, adaptation)
+			} else {
+				p.skipUntilPeek(token.SEMICOLON)
+				p.expectPeek(token.SEMICOLON)
+			}
+			p.nextToken()
+		}
+	} else if !p.expectPeek(token.SEMICOLON) {
+		p.peekError(token.LBRACE, token.SEMICOLON)
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseTraitAdaptation parses one rule inside a trait use block:
+// "A::foo insteadof B;", "foo as bar;" or "A::foo as protected bar;".
+func (p *Parser) parseTraitAdaptation() *ast.TraitAdaptation {
+	adaptation := &ast.TraitAdaptation{}
+	adaptation.S = p.curTok.Span.Start
+
+	if isName(p.curTok.Kind) && p.peekTok.Kind == token.DOUBLE_COLON {
+		adaptation.Trait = p.parseIdentifier().(*ast.Identifier)
+		p.nextToken()
+		p.nextToken()
+	}
+	if adaptation.Method = p.parseMemberName(); adaptation.Method == nil {
+		return nil
+	}
+
+	switch {
+	case p.expectPeek(token.INSTEADOF):
+		p.nextToken()
+		if adaptation.Insteadof = p.parseNameList(); adaptation.Insteadof == nil {
+			return nil
+		}
+	case p.expectPeek(token.AS):
+		p.nextToken()
+		if mod, ok := modifierOf(p.curTok.Kind); ok {
+			adaptation.Modifiers = mod
+			if p.peekTok.Kind == token.SEMICOLON {
+				break
+			}
+			p.nextToken()
+		}
+		if adaptation.Alias = p.parseMemberName(); adaptation.Alias == nil {
+			return nil
+		}
+	default:
+		p.peekError(token.INSTEADOF, token.AS)
+		return nil
+	}
+
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+	adaptation.E = p.curTok.Span.End
+	return adaptation
+}
@@ -0,0 +1,369 @@
+package parser
+
+import (
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// isFunctionDeclaration reports whether the 'function' keyword in curTok
+// starts a named declaration rather than a closure.
+func (p *Parser) isFunctionDeclaration() bool {
+	next := p.peekTok
+	if next.Kind == token.AMPERSAND {
+		next = p.peekAt(2)
+	}
+	return next.Kind == token.IDENT
+}
+
+// parseFunctionDeclaration parses "function name(params): type { body }"
+// with curTok on the 'function' keyword.
+func (p *Parser) parseFunctionDeclaration(attrs []*ast.AttributeGroup) ast.Stmt {
+	stmt := &ast.FunctionDeclStmt{Token: p.curTok, Attributes: attrs}
+	stmt.S = p.curTok.Span.Start
+	if len(attrs) > 0 {
+		stmt.S = attrs[0].Pos()
+	}
+
+	if p.peekTok.Kind == token.AMPERSAND {
+		p.nextToken()
+		stmt.ByRef = true
+	}
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	stmt.Params = p.parseParams()
+	stmt.ReturnType = p.parseReturnType()
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseClosureExpression parses a closure with curTok on 'function'.
+func (p *Parser) parseClosureExpression() ast.Expr {
+	return p.parseClosure(nil, false, p.curTok.Span.Start)
+}
+
+// parseArrowFunctionExpression parses an arrow function with curTok on 'fn'.
+func (p *Parser) parseArrowFunctionExpression() ast.Expr {
+	return p.parseArrowFunction(nil, false, p.curTok.Span.Start)
+}
+
+// parseStaticExpression parses a static closure or arrow function, or
+// otherwise the 'static' of static::name.
+func (p *Parser) parseStaticExpression() ast.Expr {
+	if p.peekTok.Kind != token.FUNCTION && p.peekTok.Kind != token.FN {
+		return p.parseIdentifier()
+	}
+	start := p.curTok.Span.Start
+	p.nextToken()
+	return p.parseFunctionExpression(nil, true, start)
+}
+
+// parseAttributedExpression parses a closure or arrow function preceded by
+// attributes, e.g. #[Pure] fn($a) => $a.
+func (p *Parser) parseAttributedExpression() ast.Expr {
+	attrs := p.parseAttributeGroups()
+	start := attrs[0].Pos()
+	p.nextToken()
+
+	static := p.curTok.Kind == token.STATIC
+	if static {
+		p.nextToken()
+	}
+	if p.curTok.Kind != token.FUNCTION && p.curTok.Kind != token.FN {
+		p.unexpected(p.curTok, token.FUNCTION, token.FN)
+		return nil
+	}
+	return p.parseFunctionExpression(attrs, static, start)
+}
+
+// isAttributedExpression reports whether the attributes starting at curTok
+// belong to a closure or arrow function rather than a declaration.
+func (p *Parser) isAttributedExpression() bool {
+	n, depth := 1, 1
+	for depth > 0 {
+		switch p.peekAt(n).Kind {
+		case token.ATTRIBUTE, token.LBRACKET:
+			depth++
+		case token.RBRACKET:
+			depth--
+		case token.EOF:
+			return false
+		}
+		n++
+		if depth == 0 && p.peekAt(n).Kind == token.ATTRIBUTE {
+			depth++
+			n++
+		}
+	}
+
+	switch p.peekAt(n).Kind {
+	case token.FN:
+		return true
+	case token.STATIC:
+		next := p.peekAt(n + 1).Kind
+		return next == token.FUNCTION || next == token.FN
+	case token.FUNCTION:
+		next := p.peekAt(n + 1)
+		if next.Kind == token.AMPERSAND {
+			next = p.peekAt(n + 2)
+		}
+		return next.Kind == token.LPAREN
+	}
+	return false
+}
+
+func (p *Parser) parseFunctionExpression(attrs []*ast.AttributeGroup, static bool, start token.Pos) ast.Expr {
+	if p.curTok.Kind == token.FN {
+		return p.parseArrowFunction(attrs, static, start)
+	}
+	return p.parseClosure(attrs, static, start)
+}
+
+// parseClosure parses "function (params) use (vars): type { body }" with
+// curTok on the 'function' keyword.
+func (p *Parser) parseClosure(attrs []*ast.AttributeGroup, static bool, start token.Pos) ast.Expr {
+	expr := &ast.ClosureExpr{Token: p.curTok, Attributes: attrs, Static: static}
+	expr.S = start
+
+	if p.peekTok.Kind == token.AMPERSAND {
+		p.nextToken()
+		expr.ByRef = true
+	}
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	expr.Params = p.parseParams()
+
+	if p.expectPeek(token.USE) {
+		if !p.expect(token.LPAREN) {
+			return nil
+		}
+		for p.peekTok.Kind != token.RPAREN && p.peekTok.Kind != token.EOF {
+			p.nextToken()
+			use := &ast.ClosureUse{}
+			use.S = p.curTok.Span.Start
+			if p.curTok.Kind == token.AMPERSAND {
+				use.ByRef = true
+				p.nextToken()
+			}
+			if p.curTok.Kind != token.VARIABLE {
+				p.unexpected(p.curTok, token.VARIABLE)
+				return nil
+			}
+			use.Var = p.parseVariable().(*ast.Variable)
+			use.E = p.curTok.Span.End
+			expr.Uses = append(expr.Uses, use)
+			if !p.expectPeek(token.COMMA) {
+				break
+			}
+		}
+		if !p.expect(token.RPAREN) {
+			return nil
+		}
+	}
+	expr.ReturnType = p.parseReturnType()
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	expr.Body = p.parseBlockStatement()
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseArrowFunction parses "fn(params): type => expr" with curTok on the
+// 'fn' keyword. The body extends as far to the right as possible.
+func (p *Parser) parseArrowFunction(attrs []*ast.AttributeGroup, static bool, start token.Pos) ast.Expr {
+	expr := &ast.ArrowFuncExpr{Token: p.curTok, Attributes: attrs, Static: static}
+	expr.S = start
+	p.requireVersion(p.curTok, 7, 4, "arrow functions")
+
+	if p.peekTok.Kind == token.AMPERSAND {
+		p.nextToken()
+		expr.ByRef = true
+	}
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	expr.Params = p.parseParams()
+	expr.ReturnType = p.parseReturnType()
+
+	if !p.expect(token.DOUBLE_ARROW) {
+		return nil
+	}
+	p.nextToken()
+	if expr.Expr = p.parseExpression(LOWEST); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseReturnType parses an optional ": type" after a parameter list.
+func (p *Parser) parseReturnType() ast.Type {
+	if p.peekTok.Kind != token.COLON {
+		return nil
+	}
+	p.nextToken()
+	p.nextToken()
+	return p.parseType()
+}
+
+// parseParams parses a parameter list with curTok on the opening '(' and
+// leaves curTok on the closing ')'.
+func (p *Parser) parseParams() []*ast.Param {
+	params := []*ast.Param{}
+	for p.peekTok.Kind != token.RPAREN && !p.peekEndsStatement() {
+		p.nextToken()
+		if param := p.parseParam(); param != nil {
+			params = append(params, param)
+		}
+		p.skipUntilPeek(token.COMMA, token.RPAREN)
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	p.expect(token.RPAREN)
+	return params
+}
+
+// parseParam parses a single parameter:
+// attributes? modifiers? type? '&'? '...'? $name ('=' default)?
+func (p *Parser) parseParam() *ast.Param {
+	param := &ast.Param{}
+	param.S = p.curTok.Span.Start
+
+	if p.curTok.Kind == token.ATTRIBUTE {
+		param.Attributes = p.parseAttributeGroups()
+		p.nextToken()
+	}
+	for {
+		p.promoteModifier()
+		mod, ok := modifierOf(p.curTok.Kind)
+		if !ok {
+			break
+		}
+		if param.Modifiers == 0 {
+			p.requireVersion(p.curTok, 8, 0, "promoted constructor properties")
+		}
+		p.checkModifierVersion(p.curTok, mod)
+		param.Modifiers |= mod
+		p.nextToken()
+	}
+	if p.curTok.Kind != token.AMPERSAND && p.curTok.Kind != token.ELLIPSIS && p.curTok.Kind != token.VARIABLE {
+		if !isTypeStart(p.curTok.Kind) {
+			p.unexpected(p.curTok, token.VARIABLE)
+			return nil
+		}
+		if param.Type = p.parseType(); param.Type == nil {
+			return nil
+		}
+		p.nextToken()
+	}
+	if p.curTok.Kind == token.AMPERSAND {
+		param.ByRef = true
+		p.nextToken()
+	}
+	if p.curTok.Kind == token.ELLIPSIS {
+		param.Variadic = true
+		p.nextToken()
+	}
+	if p.curTok.Kind != token.VARIABLE {
+		p.unexpected(p.curTok, token.VARIABLE)
+		return nil
+	}
+	param.Name = p.parseVariable().(*ast.Variable)
+
+	if p.expectPeek(token.ASSIGN) {
+		param.Default = p.parseInitializer()
+	}
+	param.E = p.curTok.Span.End
+	return param
+}
+
+// parseInitializer parses the value after the '=' in curTok of a default
+// value or constant and leaves curTok on its last token. Tokens the
+// expression parser does not understand are skipped up to the next ',', ';'
+// or closing bracket.
+func (p *Parser) parseInitializer() ast.Expr {
+	p.nextToken()
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		switch p.curTok.Kind {
+		case token.LPAREN, token.LBRACKET, token.LBRACE, token.ATTRIBUTE:
+			p.skipUntilPeek()
+			p.nextToken()
+		}
+	}
+	p.skipUntilPeek(token.COMMA, token.SEMICOLON)
+	return expr
+}
+
+// modifierOf maps a modifier keyword to its ast.Modifiers flag.
+func modifierOf(kind token.Kind) (ast.Modifiers, bool) {
+	switch kind {
+	case token.PUBLIC:
+		return ast.ModPublic, true
+	case token.PROTECTED:
+		return ast.ModProtected, true
+	case token.PRIVATE:
+		return ast.ModPrivate, true
+	case token.STATIC:
+		return ast.ModStatic, true
+	case token.ABSTRACT:
+		return ast.ModAbstract, true
+	case token.FINAL:
+		return ast.ModFinal, true
+	case token.READONLY:
+		return ast.ModReadonly, true
+	}
+	return 0, false
+}
+
+// parseAttributeGroups parses consecutive "#[...]" groups with curTok on the
+// first '#[' and leaves curTok on the last ']'.
+func (p *Parser) parseAttributeGroups() []*ast.AttributeGroup {
+	groups := []*ast.AttributeGroup{p.parseAttributeGroup()}
+	for p.peekTok.Kind == token.ATTRIBUTE {
+		p.nextToken()
+		groups = append(groups, p.parseAttributeGroup())
+	}
+	return groups
+}
+
+func (p *Parser) parseAttributeGroup() *ast.AttributeGroup {
+	group := &ast.AttributeGroup{Token: p.curTok}
+	group.S = p.curTok.Span.Start
+
+	for p.peekTok.Kind != token.RBRACKET && p.peekTok.Kind != token.EOF {
+		if !p.expectName() {
+			p.skipUntilPeek(token.RBRACKET)
+			break
+		}
+		attr := &ast.Attribute{Name: p.parseIdentifier().(*ast.Identifier)}
+		attr.S = p.curTok.Span.Start
+		if p.peekTok.Kind == token.LPAREN {
+			p.nextToken()
+			attr.Arguments = p.parseCallArguments()
+		}
+		attr.E = p.curTok.Span.End
+		group.Attributes = append(group.Attributes, attr)
+
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	p.expect(token.RBRACKET)
+	group.E = p.curTok.Span.End
+	return group
+}
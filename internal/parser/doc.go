@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// docComment is a doc comment found while lexing.
+type docComment struct {
+	comment token.Token
+	next    int // Offset of the token after the comment
+}
+
+// lastDocComment returns the last doc comment in trivia as a token.
+func lastDocComment(trivia []token.Trivia) (token.Token, bool) {
+	for i := len(trivia) - 1; i >= 0; i-- {
+		if trivia[i].Kind == token.DOC_COMMENT {
+			return token.Token{Kind: trivia[i].Kind, Lexeme: trivia[i].Text, Span: trivia[i].Span}, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// attachDoc sets the doc comment of a declaration or an expression statement
+// such as "/** @var Foo $foo */ $foo = make();". The comment must come
+// directly before the statement or, if it has attributes, between the
+// attributes and the keyword.
+func (p *Parser) attachDoc(stmt ast.Stmt) {
+	switch n := stmt.(type) {
+	case *ast.FunctionDeclStmt:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.ClassDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.InterfaceDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.TraitDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.EnumDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.EnumCaseDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.MethodDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.ClassConstDecl:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.ConstStmt:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.ExpressionStatement:
+		n.Doc = p.docFor(n, n.Token)
+	case *ast.PropertyDecl:
+		// A property has no keyword; its modifiers come first.
+		var first token.Token
+		if len(n.Props) > 0 {
+			first.Span.Start = n.Props[0].Pos()
+		}
+		n.Doc = p.docFor(n, first)
+	}
+}
+
+// docFor finds the doc comment of node, whose keyword is keyword, and
+// parses it. It returns nil if there is none.
+func (p *Parser) docFor(node ast.Node, keyword token.Token) *phpdoc.Comment {
+	start := node.Pos().Offset
+	i := sort.Search(len(p.docs), func(i int) bool {
+		return p.docs[i].next >= start
+	})
+	// Doc comments between the attributes and the keyword come after the
+	// one before the node, so the last one wins, as in PHP.
+	found := -1
+	for ; i < len(p.docs) && p.docs[i].next <= keyword.Span.Start.Offset; i++ {
+		found = i
+	}
+	if found < 0 {
+		return nil
+	}
+	doc := p.docs[found].comment
+	return phpdoc.Parse(doc.Lexeme, doc.Span)
+}
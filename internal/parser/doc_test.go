@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+)
+
+// docSummaries lists the nodes of program that have a doc comment, with the
+// comment's summary.
+func docSummaries(program *ast.Program) []string {
+	list := []string{}
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		field := reflect.ValueOf(node).Elem().FieldByName("Doc")
+		if !field.IsValid() {
+			return ast.Continue
+		}
+		if doc, ok := field.Interface().(*phpdoc.Comment); ok && doc != nil {
+			list = append(list, fmt.Sprintf("%T %s", node, doc.Summary))
+		}
+		return ast.Continue
+	})
+	return list
+}
+
+func TestDocComments(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"/** F */ function f() {}", []string{"*ast.FunctionDeclStmt F"}},
+		{"/** C */ final class C {}", []string{"*ast.ClassDecl C"}},
+		{"/** I */ interface I {} /** T */ trait T {}", []string{"*ast.InterfaceDecl I", "*ast.TraitDecl T"}},
+		{"/** E */ enum E { /** A */ case A; }", []string{"*ast.EnumDecl E", "*ast.EnumCaseDecl A"}},
+		{
+			"class C { /** K */ const K = 1; /** P */ public int $p; /** M */ public static function m() {} }",
+			[]string{"*ast.ClassConstDecl K", "*ast.PropertyDecl P", "*ast.MethodDecl M"},
+		},
+		{"/** K */ const K = 1;", []string{"*ast.ConstStmt K"}},
+		{"/**\n * Made here.\n * @var Foo $foo\n */\n$foo = make();", []string{"*ast.ExpressionStatement Made here."}},
+		// The comment may come before or after the attributes; the last one
+		// wins.
+		{"/** F */ #[A] function f() {}", []string{"*ast.FunctionDeclStmt F"}},
+		{"#[A] /** F */ function f() {}", []string{"*ast.FunctionDeclStmt F"}},
+		{"/** first */ #[A] /** second */ function f() {}", []string{"*ast.FunctionDeclStmt second"}},
+		{"/** first */ /** second */ function f() {}", []string{"*ast.FunctionDeclStmt second"}},
+		// Other comments do not separate a doc comment from its declaration.
+		{"/** F */\n// note\nfunction f() {}", []string{"*ast.FunctionDeclStmt F"}},
+		// A doc comment belongs to the next statement only.
+		{"/** x */ $a = 1; function f() {}", []string{"*ast.ExpressionStatement x"}},
+		{"/** x */ if ($a) {} function f() {}", nil},
+		{"function f() { /** x */ }", nil},
+	}
+	for _, tt := range tests {
+		p, program := parse("<?php "+tt.src, lexer.LatestVersion)
+		if errs := errorList(p); len(errs) > 0 {
+			t.Errorf("%s: %v", tt.src, errs)
+			continue
+		}
+		if tt.want == nil {
+			tt.want = []string{}
+		}
+		if got := docSummaries(program); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/diagnostic"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// maxErrors bounds the errors kept for one file; past it, the rest of a
+// badly broken file is mostly noise.
+const maxErrors = 100
+
+// Errors returns the syntax errors found while parsing, those of the lexer
+// included, in source order.
+// Of the syntax errors found at the same place, only the first is
+// reported, since the ones after it are usually caused by it. Constructs
+// the target version lacks are reported apart, being correct syntax.
+func (p *Parser) Errors() []diagnostic.Diagnostic {
+	errors := []diagnostic.Diagnostic{}
+	seen := map[token.Pos]bool{}
+	for _, err := range append(p.l.Errors(), p.errors...) {
+		if seen[err.Span.Start] {
+			continue
+		}
+		seen[err.Span.Start] = true
+		errors = append(errors, err)
+	}
+	errors = append(errors, p.versionErrors...)
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Span.Start.Offset < errors[j].Span.Start.Offset
+	})
+	if len(errors) > maxErrors {
+		errors = errors[:maxErrors]
+	}
+	return errors
+}
+
+func (p *Parser) errorAt(span token.Span, format string, args ...any) {
+	p.errors = append(p.errors, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	})
+}
+
+// unexpected records a syntax error for tok, naming the kinds of token that
+// would have been valid in its place, if any.
+func (p *Parser) unexpected(tok token.Token, expected ...token.Kind) {
+	msg := "syntax error, unexpected " + describeToken(tok)
+	if tok.Kind == token.EOF && p.fragmentEnd != "" {
+		msg = fmt.Sprintf("syntax error, unexpected token %q", p.fragmentEnd)
+	}
+	if len(expected) > 0 {
+		names := make([]string, 0, len(expected))
+		for _, kind := range expected {
+			names = append(names, describeKind(kind))
+		}
+		msg += ", expecting " + strings.Join(names, " or ")
+	}
+	p.errorAt(tok.Span, "%s", msg)
+}
+
+// peekError records that peekTok is not one of the expected kinds.
+func (p *Parser) peekError(expected ...token.Kind) {
+	p.unexpected(p.peekTok, expected...)
+}
+
+// expect is expectPeek for a token the grammar requires: it records a
+// syntax error if the next token is of another kind.
+func (p *Parser) expect(kind token.Kind) bool {
+	if p.expectPeek(kind) {
+		return true
+	}
+	p.peekError(kind)
+	return false
+}
+
+// expectName is expectPeekName for a required name.
+func (p *Parser) expectName() bool {
+	if p.expectPeekName() {
+		return true
+	}
+	p.peekError(token.IDENT)
+	return false
+}
+
+// synchronize skips the rest of a statement starting at start that failed
+// to parse, so that parsing resumes at the next one. It stops after a ';'
+// or a closed block, or before the '}' or closing tag that ends the
+// enclosing block, and leaves curTok on the last skipped token.
+func (p *Parser) synchronize(start token.Token) {
+	// A statement that cannot even start skips just its first token.
+	if p.curTok.Span.Start == start.Span.Start {
+		return
+	}
+	// A parse function may have stepped onto the '}' that made it fail or
+	// onto a closing tag; put it back so that it still ends the enclosing
+	// block or the PHP code.
+	if p.curTok.Kind == token.CLOSE_TAG || p.curTok.Kind == token.RBRACE && p.failedAt(p.curTok) {
+		p.backup()
+		return
+	}
+	if p.curTok.Kind == token.SEMICOLON {
+		return
+	}
+
+	depth := 0
+	for p.peekTok.Kind != token.EOF {
+		switch p.peekTok.Kind {
+		case token.RBRACE, token.CLOSE_TAG:
+			if depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+		switch p.curTok.Kind {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth--; depth == 0 {
+				return
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// failedAt reports whether the last syntax error was recorded at tok.
+func (p *Parser) failedAt(tok token.Token) bool {
+	return len(p.errors) > 0 && p.errors[len(p.errors)-1].Span.Start == tok.Span.Start
+}
+
+// parseStatementOrSync parses the statement at curTok like parseStatement,
+// but skips over the rest of it when it fails with a syntax error. It also
+// attaches the statement's doc comment.
+func (p *Parser) parseStatementOrSync() ast.Stmt {
+	n, start := len(p.errors), p.curTok
+	stmt := p.parseStatement()
+	if stmt == nil {
+		if len(p.errors) > n {
+			p.synchronize(start)
+		}
+		return nil
+	}
+	p.attachDoc(stmt)
+	return stmt
+}
+
+// describeToken names tok the way PHP's parse errors do, e.g.
+// variable "$a", token "}" or end of file.
+func describeToken(tok token.Token) string {
+	switch tok.Kind {
+	case token.EOF:
+		return "end of file"
+	case token.IDENT, token.NAME_QUALIFIED, token.NAME_FULLY_QUALIFIED, token.NAME_RELATIVE:
+		return fmt.Sprintf("identifier %q", tok.Lexeme)
+	case token.VARIABLE:
+		return fmt.Sprintf("variable %q", tok.Lexeme)
+	case token.INTEGER:
+		return fmt.Sprintf("integer %q", tok.Lexeme)
+	case token.FLOAT:
+		return fmt.Sprintf("floating-point number %q", tok.Lexeme)
+	case token.STRING, token.INTERPOLATED_STRING:
+		return "string " + truncate(tok.Lexeme)
+	case token.SHELL_EXEC:
+		return fmt.Sprintf("token %q", "`")
+	case token.INLINE_HTML:
+		return "inline HTML"
+	case token.ILLEGAL:
+		return fmt.Sprintf("character %q", tok.Lexeme)
+	}
+	return fmt.Sprintf("token %q", tok.Lexeme)
+}
+
+// describeKind names a kind of token in an "expecting" list.
+func describeKind(kind token.Kind) string {
+	switch kind {
+	case token.EOF:
+		return "end of file"
+	case token.IDENT:
+		return "identifier"
+	case token.VARIABLE:
+		return "variable"
+	}
+	if keyword := strings.ToLower(string(kind)); token.LookupIdent(keyword) == kind {
+		return fmt.Sprintf("%q", keyword)
+	}
+	return fmt.Sprintf("%q", string(kind))
+}
+
+// truncate shortens a long or multi-line string literal for a message.
+func truncate(lexeme string) string {
+	const max = 30
+	if first, _, found := strings.Cut(lexeme, "\n"); found {
+		lexeme = first + "..."
+	}
+	if runes := []rune(lexeme); len(runes) > max {
+		return string(runes[:max]) + "..."
+	}
+	return lexeme
+}
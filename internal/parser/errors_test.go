@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+)
+
+func parse(src string, version lexer.Version) (*Parser, *ast.Program) {
+	p := New(lexer.New(src, lexer.WithVersion(version)))
+	return p, p.ParseProgram()
+}
+
+func errorList(p *Parser) []string {
+	list := []string{}
+	for _, err := range p.Errors() {
+		list = append(list, fmt.Sprintf("%s %s", err.Location(), err.Message))
+	}
+	return list
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		src    string
+		errors []string
+		stmts  int // Statements left in the program
+	}{
+		{
+			src:    "<?php\nfoo(;\nfunction ok(){}\n$y=2;",
+			errors: []string{`2:5 syntax error, unexpected token ";", expecting ")"`},
+			stmts:  3,
+		},
+		{
+			src:    "<?php $a = [1, 2; $b = 3;",
+			errors: []string{`1:17 syntax error, unexpected token ";", expecting "]"`},
+			stmts:  1,
+		},
+		{
+			src:    "<?php if ($a) { foo(; } $z = 1;",
+			errors: []string{`1:21 syntax error, unexpected token ";", expecting ")"`},
+			stmts:  2,
+		},
+		{
+			src:    "<?php foo(1, 2 $x); bar();",
+			errors: []string{`1:16 syntax error, unexpected variable "$x", expecting ")"`},
+			stmts:  2,
+		},
+		{
+			// Errors of the lexer are reported with those of the parser.
+			src:    "<?php $a = <<<EOT\n  a\n b\n  EOT;\n$b = 1;",
+			errors: []string{`3:1 Invalid body indentation level (expecting an indentation level of at least 2)`},
+			stmts:  2,
+		},
+		{
+			src:    "<?php foo(\n?>html",
+			errors: []string{`2:1 syntax error, unexpected token "?>", expecting ")"`},
+			stmts:  2,
+		},
+	}
+	for _, tt := range tests {
+		p, program := parse(tt.src, lexer.LatestVersion)
+		if got := errorList(p); !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%q: errors\n got %q\nwant %q", tt.src, got, tt.errors)
+		}
+		if got := len(program.Stmts); got != tt.stmts {
+			t.Errorf("%q: %d statements, want %d", tt.src, got, tt.stmts)
+		}
+	}
+}
+
+func TestErrorsOnOneLine(t *testing.T) {
+	tests := []struct {
+		src     string
+		version lexer.Version
+		errors  []string
+	}{
+		{
+			// Syntax errors at different places of a line are all kept.
+			src:     "<?php $a = ; $b = ;",
+			version: lexer.LatestVersion,
+			errors: []string{
+				`1:12 syntax error, unexpected token ";"`,
+				`1:19 syntax error, unexpected token ";"`,
+			},
+		},
+		{
+			// A version error does not hide a syntax error on its line.
+			src:     "<?php $f = fn() => 1; $b = ;",
+			version: lexer.Version{Major: 7, Minor: 3},
+			errors: []string{
+				`1:12 arrow functions require PHP 7.4 or later, but the target version is 7.3`,
+				`1:28 syntax error, unexpected token ";"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		p, _ := parse(tt.src, tt.version)
+		if got := errorList(p); !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%q: errors\n got %q\nwant %q", tt.src, got, tt.errors)
+		}
+	}
+}
+
+func TestDeclareAndHaltCompiler(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string // The program, printed
+	}{
+		{"declare(strict_types=1);", "declare(strict_types = 1);"},
+		{"declare(ticks=1) { echo 1; }", "declare(ticks = 1) { echo 1; }"},
+		{"declare(ticks=1) echo 1;", "declare(ticks = 1) { echo 1; }"},
+		{"declare(ticks=1): echo 1; echo 2; enddeclare; echo 3;", "declare(ticks = 1) { echo 1; echo 2; }echo 3;"},
+		{"declare(ticks=1) ?>", "declare(ticks = 1);"},
+		{"echo 1; __halt_compiler(); } not { php", "echo 1;__halt_compiler(); } not { php"},
+		{"__halt_compiler() ?>data", "__halt_compiler();data"},
+	}
+	for _, tt := range tests {
+		p, program := parse("<?php "+tt.src, lexer.LatestVersion)
+		if errors := errorList(p); len(errors) > 0 {
+			t.Errorf("%q: errors %q", tt.src, errors)
+		}
+		if got := program.String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,767 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Operator precedences, from lowest to highest, following PHP's
+// precedence table.
+const (
+	_ int = iota
+	LOWEST
+	LOGICAL_OR  // or
+	LOGICAL_XOR // xor
+	LOGICAL_AND // and
+	ASSIGN      // = += -= ... ??=
+	TERNARY     // ? :
+	COALESCE    // ??
+	BOOLEAN_OR  // ||
+	BOOLEAN_AND // &&
+	BIT_OR      // |
+	BIT_XOR     // ^
+	BIT_AND     // &
+	EQUALITY    // == != === !== <=>
+	COMPARISON  // < <= > >=
+	CONCAT      // .
+	SHIFT       // << >>
+	SUM         // + -
+	PRODUCT     // * / %
+	NOT         // !
+	INSTANCEOF  // instanceof
+	PREFIX      // -$a +$a ~$a ++$a (int)$a @$a
+	POW         // **
+	CLONE       // clone, new
+	POSTFIX     // f() $a[] $a->b A::b $a++
+)
+
+var precedences = map[token.Kind]int{
+	token.LOGICAL_OR:      LOGICAL_OR,
+	token.LOGICAL_XOR:     LOGICAL_XOR,
+	token.LOGICAL_AND:     LOGICAL_AND,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.MUL_ASSIGN:      ASSIGN,
+	token.DIV_ASSIGN:      ASSIGN,
+	token.MOD_ASSIGN:      ASSIGN,
+	token.POW_ASSIGN:      ASSIGN,
+	token.CONCAT_ASSIGN:   ASSIGN,
+	token.AND_ASSIGN:      ASSIGN,
+	token.OR_ASSIGN:       ASSIGN,
+	token.XOR_ASSIGN:      ASSIGN,
+	token.SL_ASSIGN:       ASSIGN,
+	token.SR_ASSIGN:       ASSIGN,
+	token.COALESCE_ASSIGN: ASSIGN,
+	token.QUESTION:        TERNARY,
+	token.COALESCE:        COALESCE,
+	token.BOOLEAN_OR:      BOOLEAN_OR,
+	token.BOOLEAN_AND:     BOOLEAN_AND,
+	token.PIPE:            BIT_OR,
+	token.CARET:           BIT_XOR,
+	token.AMPERSAND:       BIT_AND,
+	token.EQ:              EQUALITY,
+	token.NOT_EQ:          EQUALITY,
+	token.IDENTICAL:       EQUALITY,
+	token.NOT_IDENTICAL:   EQUALITY,
+	token.SPACESHIP:       EQUALITY,
+	token.LT:              COMPARISON,
+	token.LT_EQ:           COMPARISON,
+	token.GT:              COMPARISON,
+	token.GT_EQ:           COMPARISON,
+	token.DOT:             CONCAT,
+	token.SL:              SHIFT,
+	token.SR:              SHIFT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.ASTERISK:        PRODUCT,
+	token.SLASH:           PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.INSTANCEOF:      INSTANCEOF,
+	token.POW:             POW,
+	token.LPAREN:          POSTFIX,
+	token.LBRACKET:        POSTFIX,
+	token.LBRACE:          POSTFIX,
+	token.ARROW:           POSTFIX,
+	token.NULLSAFE_ARROW:  POSTFIX,
+	token.DOUBLE_COLON:    POSTFIX,
+	token.INC:             POSTFIX,
+	token.DEC:             POSTFIX,
+}
+
+// rightAssociative lists the binary operators that group from the right.
+var rightAssociative = map[token.Kind]bool{
+	token.COALESCE: true,
+	token.POW:      true,
+}
+
+func isAssignment(kind token.Kind) bool {
+	return precedences[kind] == ASSIGN
+}
+
+// isVariable reports whether expr can be assigned to.
+func isVariable(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Variable, *ast.VariableVariable, *ast.IndexExpr, *ast.PropertyFetchExpr, *ast.StaticPropertyFetchExpr:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) peekPrecedence() int {
+	if precedence, ok := precedences[p.peekTok.Kind]; ok {
+		return precedence
+	}
+	return LOWEST
+}
+
+func (p *Parser) curPrecedence() int {
+	if precedence, ok := precedences[p.curTok.Kind]; ok {
+		return precedence
+	}
+	return LOWEST
+}
+
+// parseExpression parses an expression with curTok on its first token and
+// leaves curTok on its last token. Only operators binding tighter than
+// precedence are consumed.
+func (p *Parser) parseExpression(precedence int) ast.Expr {
+	if p.curTok.Kind == token.IDENT {
+		p.promoteKeyword()
+	}
+	prefix := p.prefixParseFns[p.curTok.Kind]
+	if prefix == nil {
+		p.unexpected(p.curTok)
+		return nil
+	}
+	left := prefix()
+
+	for left != nil && p.peekTok.Kind != token.SEMICOLON {
+		infix := p.infixParseFns[p.peekTok.Kind]
+		if infix == nil {
+			break
+		}
+		// An assignment always takes the variable on its left, so that
+		// !$a = f() is !($a = f()) as in PHP.
+		if precedence >= p.peekPrecedence() && !(isAssignment(p.peekTok.Kind) && isVariable(left)) {
+			break
+		}
+		// A '{' is an offset only right after a variable, as in $a{0};
+		// elsewhere it opens a block.
+		if p.peekTok.Kind == token.LBRACE && !isVariable(left) {
+			break
+		}
+		p.nextToken()
+		p.checkNewAccess(left)
+		left = infix(left)
+	}
+	return left
+}
+
+// parseGroupedExpression parses "(expr)". The parentheses only group and
+// do not produce a node.
+func (p *Parser) parseGroupedExpression() ast.Expr {
+	p.nextToken()
+	expr := p.parseExpression(LOWEST)
+	if expr == nil || !p.expect(token.RPAREN) {
+		return nil
+	}
+	return expr
+}
+
+// parseVariableVariable parses $$name and ${expr} with curTok on the '$'.
+func (p *Parser) parseVariableVariable() ast.Expr {
+	expr := &ast.VariableVariable{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	switch p.peekTok.Kind {
+	case token.LBRACE:
+		p.nextToken()
+		p.nextToken()
+		expr.Name = p.parseExpression(LOWEST)
+		if expr.Name == nil || !p.expect(token.RBRACE) {
+			return nil
+		}
+	case token.VARIABLE:
+		p.nextToken()
+		expr.Name = p.parseVariable()
+	case token.DOLLAR:
+		p.nextToken()
+		if expr.Name = p.parseVariableVariable(); expr.Name == nil {
+			return nil
+		}
+	default:
+		p.peekError(token.VARIABLE, token.LBRACE)
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expr {
+	expr := &ast.PrefixExpr{Token: p.curTok, Operator: p.curTok.Lexeme}
+	expr.S = p.curTok.Span.Start
+
+	precedence := PREFIX
+	if p.curTok.Kind == token.BANG {
+		precedence = NOT
+	}
+	p.nextToken()
+	if expr.Right = p.parseExpression(precedence); expr.Right == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expr) ast.Expr {
+	return &ast.PostfixExpr{
+		Base:     ast.Base{S: left.Pos(), E: p.curTok.Span.End},
+		Token:    p.curTok,
+		Left:     left,
+		Operator: p.curTok.Lexeme,
+	}
+}
+
+func (p *Parser) parseCastExpression() ast.Expr {
+	expr := &ast.CastExpr{Token: p.curTok, Type: strings.Trim(string(p.curTok.Kind), "()")}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(PREFIX); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parseBinaryExpression(left ast.Expr) ast.Expr {
+	expr := &ast.BinaryExpr{Token: p.curTok, Left: left, Operator: p.curTok.Lexeme}
+	expr.S = left.Pos()
+
+	precedence := p.curPrecedence()
+	if rightAssociative[p.curTok.Kind] {
+		precedence--
+	}
+	p.nextToken()
+	if expr.Right = p.parseExpression(precedence); expr.Right == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseAssignExpression parses the right-hand side of an assignment,
+// including the by-reference form $a = &$b.
+func (p *Parser) parseAssignExpression(left ast.Expr) ast.Expr {
+	expr := &ast.AssignExpr{Token: p.curTok, Left: left, Operator: p.curTok.Lexeme}
+	expr.S = left.Pos()
+	if p.curTok.Kind == token.COALESCE_ASSIGN {
+		p.requireVersion(p.curTok, 7, 4, "null coalescing assignments")
+	}
+
+	if p.curTok.Kind == token.ASSIGN && p.peekTok.Kind == token.AMPERSAND {
+		p.nextToken()
+		expr.ByRef = true
+	}
+	p.nextToken()
+	if expr.Right = p.parseExpression(ASSIGN - 1); expr.Right == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseTernaryExpression parses "? then : else" and the short "?: else".
+func (p *Parser) parseTernaryExpression(condition ast.Expr) ast.Expr {
+	expr := &ast.TernaryExpr{Token: p.curTok, Condition: condition}
+	expr.S = condition.Pos()
+
+	// $a ? $b : $c ? $d : $e groups from the left, unlike in other
+	// languages, and needs parentheses since PHP 8.0. Only chains of short
+	// ternaries, $a ?: $b ?: $c, are still allowed.
+	if inner, ok := condition.(*ast.TernaryExpr); ok && p.prevTok.Span.End == inner.End() &&
+		(inner.Then != nil || p.peekTok.Kind != token.COLON) {
+		p.removedIn(p.curTok, 8, 0, "unparenthesized nested ternaries")
+	}
+
+	if !p.expectPeek(token.COLON) {
+		p.nextToken()
+		if expr.Then = p.parseExpression(LOWEST); expr.Then == nil {
+			return nil
+		}
+		if !p.expect(token.COLON) {
+			return nil
+		}
+	}
+	p.nextToken()
+	if expr.Else = p.parseExpression(TERNARY); expr.Else == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parseInstanceofExpression(left ast.Expr) ast.Expr {
+	expr := &ast.InstanceofExpr{Token: p.curTok, Expr: left}
+	expr.S = left.Pos()
+
+	p.nextToken()
+	if isName(p.curTok.Kind) || p.curTok.Kind == token.STATIC {
+		expr.Class = p.parseIdentifier()
+	} else if expr.Class = p.parseExpression(INSTANCEOF); expr.Class == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseArrayLiteral parses "[...]", "array(...)" or "list(...)" with curTok
+// on the first token. Empty items, as in list(, $b), are kept as nil.
+func (p *Parser) parseArrayLiteral() ast.Expr {
+	arr := &ast.ArrayLiteral{Token: p.curTok, Items: []*ast.ArrayItem{}}
+	arr.S = p.curTok.Span.Start
+
+	closing := token.Kind(token.RBRACKET)
+	if p.curTok.Kind != token.LBRACKET {
+		if !p.expect(token.LPAREN) {
+			return nil
+		}
+		closing = token.RPAREN
+	}
+
+	for p.peekTok.Kind != closing && !p.peekEndsStatement() {
+		if p.peekTok.Kind == token.COMMA {
+			p.nextToken()
+			arr.Items = append(arr.Items, nil)
+			continue
+		}
+		p.nextToken()
+		if item := p.parseArrayItem(); item != nil {
+			arr.Items = append(arr.Items, item)
+		} else {
+			p.skipUntilPeek(token.COMMA, closing)
+		}
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.expect(closing) {
+		return nil
+	}
+	arr.E = p.curTok.Span.End
+	return arr
+}
+
+// parseArrayItem parses "value", "key => value", "&$value", "key => &$value"
+// or "...$values".
+func (p *Parser) parseArrayItem() *ast.ArrayItem {
+	item := &ast.ArrayItem{}
+	item.S = p.curTok.Span.Start
+
+	switch p.curTok.Kind {
+	case token.ELLIPSIS:
+		p.requireVersion(p.curTok, 7, 4, "spread operators in arrays")
+		item.Unpack = true
+		p.nextToken()
+	case token.AMPERSAND:
+		item.ByRef = true
+		p.nextToken()
+	}
+	if item.Value = p.parseExpression(LOWEST); item.Value == nil {
+		return nil
+	}
+
+	if !item.Unpack && !item.ByRef && p.expectPeek(token.DOUBLE_ARROW) {
+		item.Key = item.Value
+		p.nextToken()
+		if p.curTok.Kind == token.AMPERSAND {
+			item.ByRef = true
+			p.nextToken()
+		}
+		if item.Value = p.parseExpression(LOWEST); item.Value == nil {
+			return nil
+		}
+	}
+	item.E = p.curTok.Span.End
+	return item
+}
+
+// parseIndexExpression parses "[index]" or the append form "[]" after left.
+func (p *Parser) parseIndexExpression(left ast.Expr) ast.Expr {
+	expr := &ast.IndexExpr{Token: p.curTok, Left: left}
+	expr.S = left.Pos()
+
+	if !p.expectPeek(token.RBRACKET) {
+		p.nextToken()
+		if expr.Index = p.parseExpression(LOWEST); expr.Index == nil {
+			return nil
+		}
+		if !p.expect(token.RBRACKET) {
+			return nil
+		}
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseCurlyIndexExpression parses the offset "{expr}" of the PHP 7 form
+// $a{0}, the same as $a[0].
+func (p *Parser) parseCurlyIndexExpression(left ast.Expr) ast.Expr {
+	p.removedIn(p.curTok, 8, 0, "curly brace offsets")
+	expr := &ast.IndexExpr{Token: p.curTok, Left: left}
+	expr.S = left.Pos()
+
+	p.nextToken()
+	if expr.Index = p.parseExpression(LOWEST); expr.Index == nil {
+		return nil
+	}
+	if !p.expect(token.RBRACE) {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseMemberAccess parses "->name" or "?->name" after object, followed by
+// an argument list for method calls.
+func (p *Parser) parseMemberAccess(object ast.Expr) ast.Expr {
+	if p.curTok.Kind == token.NULLSAFE_ARROW {
+		p.requireVersion(p.curTok, 8, 0, "nullsafe operators")
+	}
+	fetch := p.parsePropertyFetch(object)
+	if fetch == nil {
+		return nil
+	}
+	if !p.expectPeek(token.LPAREN) {
+		return fetch
+	}
+
+	call := &ast.MethodCallExpr{
+		Token:    fetch.Token,
+		Object:   object,
+		Method:   fetch.Property,
+		NullSafe: fetch.NullSafe,
+	}
+	call.S = fetch.S
+	if call.Callable = p.parseCallablePlaceholder(); !call.Callable {
+		call.Arguments = p.parseCallArguments()
+	}
+	call.E = p.curTok.Span.End
+	return call
+}
+
+func (p *Parser) parsePropertyFetch(object ast.Expr) *ast.PropertyFetchExpr {
+	fetch := &ast.PropertyFetchExpr{
+		Token:    p.curTok,
+		Object:   object,
+		NullSafe: p.curTok.Kind == token.NULLSAFE_ARROW,
+	}
+	fetch.S = object.Pos()
+
+	p.nextToken()
+	if fetch.Property = p.parseMemberExpr(); fetch.Property == nil {
+		return nil
+	}
+	fetch.E = p.curTok.Span.End
+	return fetch
+}
+
+// parseMemberExpr parses the member name after '->' or '::' with curTok on
+// it: a name, which may be a reserved word, a variable or a braced
+// expression.
+func (p *Parser) parseMemberExpr() ast.Expr {
+	switch {
+	case p.curTok.Kind == token.VARIABLE:
+		return p.parseVariable()
+	case p.curTok.Kind == token.DOLLAR:
+		return p.parseVariableVariable()
+	case p.curTok.Kind == token.LBRACE:
+		p.nextToken()
+		expr := p.parseExpression(LOWEST)
+		if expr == nil || !p.expect(token.RBRACE) {
+			return nil
+		}
+		return expr
+	case isMemberName(p.curTok):
+		return p.parseIdentifier()
+	}
+	p.unexpected(p.curTok, token.IDENT)
+	return nil
+}
+
+// parseStaticAccess parses what follows "::": a static method call, a
+// static property or a class constant, including Foo::class.
+func (p *Parser) parseStaticAccess(class ast.Expr) ast.Expr {
+	tok := p.curTok
+	base := ast.Base{S: class.Pos()}
+
+	p.nextToken()
+	member := p.parseMemberExpr()
+	if member == nil {
+		return nil
+	}
+
+	if p.expectPeek(token.LPAREN) {
+		call := &ast.StaticCallExpr{Base: base, Token: tok, Class: class, Method: member}
+		if call.Callable = p.parseCallablePlaceholder(); !call.Callable {
+			call.Arguments = p.parseCallArguments()
+		}
+		call.E = p.curTok.Span.End
+		return call
+	}
+	base.E = p.curTok.Span.End
+
+	switch m := member.(type) {
+	case *ast.Variable, *ast.VariableVariable:
+		return &ast.StaticPropertyFetchExpr{Base: base, Token: tok, Class: class, Property: m}
+	case *ast.Identifier:
+		return &ast.ClassConstFetchExpr{Base: base, Token: tok, Class: class, Name: m}
+	}
+	return nil
+}
+
+func (p *Parser) parseCallExpression(function ast.Expr) ast.Expr {
+	expr := &ast.CallExpr{
+		Base:     ast.Base{S: function.Pos()},
+		Token:    p.curTok,
+		Function: function,
+	}
+
+	if expr.Callable = p.parseCallablePlaceholder(); !expr.Callable {
+		expr.Arguments = p.parseCallArguments()
+	}
+	expr.E = p.curTok.Span.End
+
+	return expr
+}
+
+// parseCallablePlaceholder advances over the "...)" of the first-class
+// callable syntax strlen(...) with curTok on the '(' and reports whether
+// it did.
+func (p *Parser) parseCallablePlaceholder() bool {
+	if p.peekTok.Kind != token.ELLIPSIS || p.peekAt(2).Kind != token.RPAREN {
+		return false
+	}
+	p.requireVersion(p.peekTok, 8, 1, "first-class callables")
+	p.nextToken()
+	p.nextToken()
+	return true
+}
+
+// parseCallArguments parses an argument list with curTok on the opening '('
+// and leaves curTok on the closing ')'.
+func (p *Parser) parseCallArguments() []*ast.Argument {
+	args := []*ast.Argument{}
+	for p.peekTok.Kind != token.RPAREN && !p.peekEndsStatement() {
+		p.nextToken()
+		if arg := p.parseArgument(); arg != nil {
+			args = append(args, arg)
+		} else {
+			p.skipUntilPeek(token.COMMA, token.RPAREN)
+		}
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	if !p.expect(token.RPAREN) {
+		p.skipUntilPeek(token.RPAREN)
+		p.expectPeek(token.RPAREN)
+	}
+	return args
+}
+
+// parseArgument parses "value", "...values" or the named "name: value".
+func (p *Parser) parseArgument() *ast.Argument {
+	arg := &ast.Argument{}
+	arg.S = p.curTok.Span.Start
+
+	switch {
+	case p.curTok.Kind == token.ELLIPSIS:
+		arg.Unpack = true
+		p.nextToken()
+	case isMemberName(p.curTok) && p.peekTok.Kind == token.COLON:
+		p.requireVersion(p.curTok, 8, 0, "named arguments")
+		arg.Name = p.parseIdentifier().(*ast.Identifier)
+		p.nextToken()
+		p.nextToken()
+	}
+	if arg.Value = p.parseExpression(LOWEST); arg.Value == nil {
+		return nil
+	}
+	arg.E = p.curTok.Span.End
+	return arg
+}
+
+// parseNewExpression parses "new Foo(args)", "new $class", "new (expr)" and
+// anonymous classes "new class(args) extends Foo { ... }".
+func (p *Parser) parseNewExpression() ast.Expr {
+	expr := &ast.NewExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	switch {
+	case p.curTok.Kind == token.CLASS:
+		return p.parseAnonymousClass(expr)
+	case isName(p.curTok.Kind) || p.curTok.Kind == token.STATIC:
+		expr.Class = p.parseIdentifier()
+	case p.curTok.Kind == token.LPAREN:
+		expr.Class = p.parseGroupedExpression()
+	default:
+		expr.Class = p.parseNewClassExpression()
+	}
+	if expr.Class == nil {
+		return nil
+	}
+
+	if p.expectPeek(token.LPAREN) {
+		expr.Arguments = p.parseCallArguments()
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseNewClassExpression parses a dynamic class name after 'new', such as
+// $class or $this->classes['name']. Unlike elsewhere, a '(' after it opens
+// the constructor arguments rather than a call.
+func (p *Parser) parseNewClassExpression() ast.Expr {
+	var class ast.Expr
+	switch p.curTok.Kind {
+	case token.VARIABLE:
+		class = p.parseVariable()
+	case token.DOLLAR:
+		class = p.parseVariableVariable()
+	default:
+		p.unexpected(p.curTok, token.IDENT)
+	}
+
+	for class != nil {
+		switch p.peekTok.Kind {
+		case token.LBRACKET:
+			p.nextToken()
+			class = p.parseIndexExpression(class)
+		case token.ARROW, token.NULLSAFE_ARROW:
+			p.nextToken()
+			fetch := p.parsePropertyFetch(class)
+			if fetch == nil {
+				return nil
+			}
+			class = fetch
+		default:
+			return class
+		}
+	}
+	return nil
+}
+
+// parseAnonymousClass parses "class(args) extends Foo implements Bar { ... }"
+// with curTok on 'class'.
+func (p *Parser) parseAnonymousClass(expr *ast.NewExpr) ast.Expr {
+	decl := &ast.ClassDecl{Token: p.curTok}
+	decl.S = p.curTok.Span.Start
+
+	if p.expectPeek(token.LPAREN) {
+		expr.Arguments = p.parseCallArguments()
+	}
+	if !p.parseClassTail(decl) {
+		return nil
+	}
+	expr.AnonClass = decl
+	expr.E = decl.E
+	return expr
+}
+
+func (p *Parser) parseCloneExpression() ast.Expr {
+	expr := &ast.CloneExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(CLONE); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parsePrintExpression parses "print expr". print binds more loosely than
+// assignments but more tightly than "and", "or" and "xor".
+func (p *Parser) parsePrintExpression() ast.Expr {
+	expr := &ast.PrintExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(LOGICAL_AND); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseYieldExpression parses "yield", "yield value" and
+// "yield key => value".
+func (p *Parser) parseYieldExpression() ast.Expr {
+	expr := &ast.YieldExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+	expr.E = p.curTok.Span.End
+
+	if p.prefixParseFns[p.peekTok.Kind] == nil {
+		return expr // A bare yield, e.g. $x = yield;
+	}
+	p.nextToken()
+	if expr.Value = p.parseExpression(LOGICAL_AND); expr.Value == nil {
+		return nil
+	}
+	if p.expectPeek(token.DOUBLE_ARROW) {
+		expr.Key = expr.Value
+		p.nextToken()
+		if expr.Value = p.parseExpression(LOGICAL_AND); expr.Value == nil {
+			return nil
+		}
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parseYieldFromExpression() ast.Expr {
+	expr := &ast.YieldFromExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(LOGICAL_AND); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseThrowExpression parses "throw expr", an expression since PHP 8, so
+// that it can appear in e.g. $a ?? throw new Exception().
+func (p *Parser) parseThrowExpression() ast.Expr {
+	expr := &ast.ThrowExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+	if expr.S != p.exprStmtStart {
+		p.requireVersion(p.curTok, 8, 0, "throw expressions")
+	}
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(LOWEST); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+func (p *Parser) parseIncludeExpression() ast.Expr {
+	expr := &ast.IncludeExpr{Token: p.curTok}
+	expr.S = p.curTok.Span.Start
+
+	p.nextToken()
+	if expr.Expr = p.parseExpression(LOWEST); expr.Expr == nil {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// parseNamespace parses "namespace Name;" or "namespace Name { ... }" with
+// curTok on the 'namespace' keyword. An unbraced declaration takes every
+// statement up to the next namespace declaration or the end of the file.
+func (p *Parser) parseNamespace() ast.Stmt {
+	stmt := &ast.NamespaceStmt{Token: p.curTok, Stmts: []ast.Stmt{}}
+	stmt.S = p.curTok.Span.Start
+
+	if p.expectPeekName() {
+		stmt.Name = p.parseIdentifier().(*ast.Identifier)
+	}
+
+	if p.expectPeek(token.LBRACE) {
+		stmt.Braced = true
+		stmt.Stmts = p.parseBlockStatement().Stmts
+		stmt.E = p.curTok.Span.End
+		return stmt
+	}
+	if stmt.Name == nil {
+		p.peekError(token.IDENT, token.LBRACE)
+		return nil
+	}
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+
+	for p.peekTok.Kind != token.EOF && p.peekTok.Kind != token.NAMESPACE {
+		p.nextToken()
+		if s := p.parseStatementOrSync(); s != nil {
+			stmt.Stmts = append(stmt.Stmts, s)
+		}
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseUseStatement parses an import with curTok on the 'use' keyword:
+// "use A\B as C, D;", "use function A\b;", "use const A\B;" or a group use
+// such as "use A\{B, function c, const D};".
+func (p *Parser) parseUseStatement() ast.Stmt {
+	stmt := &ast.UseStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	stmt.Kind = useKindOf(p.peekTok.Kind)
+	if stmt.Kind != ast.UseClass {
+		p.nextToken()
+	}
+
+	if !p.expectName() {
+		return nil
+	}
+	if p.peekTok.Kind == token.NS_SEPARATOR && p.peekAt(2).Kind == token.LBRACE {
+		stmt.Prefix = p.parseIdentifier().(*ast.Identifier)
+		p.nextToken()
+		p.nextToken()
+		for p.peekTok.Kind != token.RBRACE && p.peekTok.Kind != token.EOF {
+			p.nextToken()
+			use := p.parseUseClause(stmt.Kind == ast.UseClass)
+			if use == nil {
+				return nil
+			}
+			stmt.Uses = append(stmt.Uses, use)
+			if !p.expectPeek(token.COMMA) {
+				break
+			}
+		}
+		if !p.expect(token.RBRACE) {
+			return nil
+		}
+	} else {
+		for {
+			use := p.parseUseClause(false)
+			if use == nil {
+				return nil
+			}
+			stmt.Uses = append(stmt.Uses, use)
+			if !p.expectPeek(token.COMMA) || !p.expectName() {
+				break
+			}
+		}
+	}
+
+	if !p.expect(token.SEMICOLON) {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseUseClause parses "Name as Alias" with curTok on the name. In a mixed
+// group use each clause may start with 'function' or 'const'.
+func (p *Parser) parseUseClause(mixed bool) *ast.UseClause {
+	use := &ast.UseClause{}
+	use.S = p.curTok.Span.Start
+
+	if mixed {
+		if use.Kind = useKindOf(p.curTok.Kind); use.Kind != ast.UseClass {
+			p.nextToken()
+		}
+	}
+	if !isName(p.curTok.Kind) {
+		p.unexpected(p.curTok, token.IDENT)
+		return nil
+	}
+	use.Name = p.parseIdentifier().(*ast.Identifier)
+
+	if p.expectPeek(token.AS) {
+		if !p.expect(token.IDENT) {
+			return nil
+		}
+		use.Alias = p.parseIdentifier().(*ast.Identifier)
+	}
+	use.E = p.curTok.Span.End
+	return use
+}
+
+func useKindOf(kind token.Kind) ast.UseKind {
+	switch kind {
+	case token.FUNCTION:
+		return ast.UseFunction
+	case token.CONST:
+		return ast.UseConst
+	}
+	return ast.UseClass
+}
@@ -1,7 +1,13 @@
 package parser
 
 import (
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
 	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/diagnostic"
 	"github.com/codevault-llc/php-lint/internal/lexer"
 	"github.com/codevault-llc/php-lint/internal/token"
 )
@@ -12,9 +18,27 @@ type (
 )
 
 type Parser struct {
-	l      *lexer.Lexer
-	curTok token.Token
+	l       *lexer.Lexer
+	prevTok token.Token // The token before curTok, see backup
+	curTok  token.Token
 	peekTok token.Token
+	ahead   []token.Token // Tokens already read past peekTok, see peekAt
+
+	errors        []diagnostic.Diagnostic
+	versionErrors []diagnostic.Diagnostic // Constructs the target version lacks, see requireVersion
+
+	// tokens records every token read up to EOF when the lexer keeps
+	// trivia, see ast.Program.Tokens.
+	tokens []token.Token
+	docs   []docComment // Doc comments in source order, see lexToken
+
+	// exprStmtStart is where the innermost expression statement being
+	// parsed starts. A throw there is a statement, which PHP 7 allows.
+	exprStmtStart token.Pos
+
+	// fragmentEnd is the token closing the interpolation of a string that
+	// a fragment parser reads, reported in place of its end of file.
+	fragmentEnd string
 
 	prefixParseFns map[token.Kind]prefixParseFn
 	infixParseFns  map[token.Kind]infixParseFn
@@ -24,18 +48,74 @@ func New(l *lexer.Lexer) *Parser {
 	p := &Parser{l: l}
 	p.prefixParseFns = make(map[token.Kind]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
-	p.registerPrefix(token.EVAL, p.parseIdentifier) // Treat keywords like identifiers for parsing
-	p.registerPrefix(token.EXIT, p.parseIdentifier)
-	p.registerPrefix(token.DIE, p.parseIdentifier)
-	p.registerPrefix(token.SHELL_EXEC, p.parseIdentifier)
-	p.registerPrefix(token.EXEC, p.parseIdentifier)
-	p.registerPrefix(token.PASSTHRU, p.parseIdentifier)
-	p.registerPrefix(token.SYSTEM, p.parseIdentifier)
-	p.registerPrefix(token.ECHO, p.parseIdentifier)
-	p.registerPrefix(token.OPEN_TAG, func() ast.Expr { return nil })
+	p.registerPrefix(token.NAME_QUALIFIED, p.parseIdentifier)
+	p.registerPrefix(token.NAME_FULLY_QUALIFIED, p.parseIdentifier)
+	p.registerPrefix(token.NAME_RELATIVE, p.parseIdentifier)
+	p.registerPrefix(token.VARIABLE, p.parseVariable)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERPOLATED_STRING, p.parseInterpolatedString)
+	p.registerPrefix(token.SHELL_EXEC, p.parseShellExec)
+	p.registerPrefix(token.INTEGER, p.parseNumberLiteral)
+	p.registerPrefix(token.FLOAT, p.parseNumberLiteral)
+	p.registerPrefix(token.DOLLAR, p.parseVariableVariable)
+	// Language constructs that look like calls are parsed as identifiers,
+	// so that eval($code) becomes a call of "eval".
+	for _, kind := range []token.Kind{token.EVAL, token.EXIT, token.DIE, token.ISSET, token.EMPTY} {
+		p.registerPrefix(kind, p.parseIdentifier)
+	}
+	for _, kind := range []token.Kind{
+		token.MAGIC_CLASS, token.MAGIC_DIR, token.MAGIC_FILE, token.MAGIC_FUNCTION, token.MAGIC_LINE,
+		token.MAGIC_METHOD, token.MAGIC_NAMESPACE, token.MAGIC_TRAIT, token.MAGIC_PROPERTY,
+	} {
+		p.registerPrefix(kind, p.parseIdentifier)
+	}
+	p.registerPrefix(token.STATIC, p.parseStaticExpression)
+	p.registerPrefix(token.FUNCTION, p.parseClosureExpression)
+	p.registerPrefix(token.FN, p.parseArrowFunctionExpression)
+	p.registerPrefix(token.ATTRIBUTE, p.parseAttributedExpression)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.ARRAY, p.parseArrayLiteral)
+	p.registerPrefix(token.LIST, p.parseArrayLiteral)
+	for _, kind := range []token.Kind{token.BANG, token.MINUS, token.PLUS, token.TILDE, token.AT, token.INC, token.DEC} {
+		p.registerPrefix(kind, p.parsePrefixExpression)
+	}
+	for _, kind := range []token.Kind{
+		token.INT_CAST, token.FLOAT_CAST, token.STRING_CAST, token.BOOL_CAST,
+		token.ARRAY_CAST, token.OBJECT_CAST, token.UNSET_CAST,
+	} {
+		p.registerPrefix(kind, p.parseCastExpression)
+	}
+	p.registerPrefix(token.NEW, p.parseNewExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.CLONE, p.parseCloneExpression)
+	p.registerPrefix(token.PRINT, p.parsePrintExpression)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.YIELD_FROM, p.parseYieldFromExpression)
+	p.registerPrefix(token.THROW, p.parseThrowExpression)
+	for _, kind := range []token.Kind{token.INCLUDE, token.INCLUDE_ONCE, token.REQUIRE, token.REQUIRE_ONCE} {
+		p.registerPrefix(kind, p.parseIncludeExpression)
+	}
 
 	p.infixParseFns = make(map[token.Kind]infixParseFn)
+	for kind, precedence := range precedences {
+		switch {
+		case isAssignment(kind):
+			p.registerInfix(kind, p.parseAssignExpression)
+		case precedence < POSTFIX:
+			p.registerInfix(kind, p.parseBinaryExpression)
+		}
+	}
+	p.registerInfix(token.QUESTION, p.parseTernaryExpression)
+	p.registerInfix(token.INSTANCEOF, p.parseInstanceofExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.LBRACE, p.parseCurlyIndexExpression)
+	p.registerInfix(token.ARROW, p.parseMemberAccess)
+	p.registerInfix(token.NULLSAFE_ARROW, p.parseMemberAccess)
+	p.registerInfix(token.DOUBLE_COLON, p.parseStaticAccess)
+	p.registerInfix(token.INC, p.parsePostfixExpression)
+	p.registerInfix(token.DEC, p.parsePostfixExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -51,71 +131,304 @@ func (p *Parser) registerInfix(kind token.Kind, fn infixParseFn) {
 }
 
 func (p *Parser) nextToken() {
+	p.prevTok = p.curTok
 	p.curTok = p.peekTok
-	p.peekTok = p.l.NextToken()
+	if len(p.ahead) > 0 {
+		p.peekTok = p.ahead[0]
+		p.ahead = p.ahead[1:]
+		return
+	}
+	p.peekTok = p.lexToken()
+}
+
+// lexToken reads the next token from the lexer, skipping comments, which
+// carry no meaning for the grammar. Doc comments are kept aside for the
+// declaration they precede, see attachDoc.
+func (p *Parser) lexToken() token.Token {
+	tok := p.l.NextToken()
+	var doc *token.Token
+	for tok.Kind == token.LINE_COMMENT || tok.Kind == token.BLOCK_COMMENT || tok.Kind == token.DOC_COMMENT {
+		if tok.Kind == token.DOC_COMMENT {
+			comment := tok
+			doc = &comment
+		}
+		tok = p.l.NextToken()
+	}
+	// With trivia, a doc comment may also trail the previous token, as in
+	// "{ /** doc */".
+	if n := len(p.tokens); n > 0 {
+		if comment, ok := lastDocComment(p.tokens[n-1].Trailing); ok {
+			doc = &comment
+		}
+	}
+	if comment, ok := lastDocComment(tok.Leading); ok {
+		doc = &comment
+	}
+	if doc != nil {
+		p.docs = append(p.docs, docComment{comment: *doc, next: tok.Span.Start.Offset})
+	}
+
+	if p.l.KeepsTrivia() && (len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Kind != token.EOF) {
+		p.tokens = append(p.tokens, tok)
+	}
+	return tok
+}
+
+// backup undoes the last nextToken. It can only go back one token.
+func (p *Parser) backup() {
+	p.ahead = append([]token.Token{p.peekTok}, p.ahead...)
+	p.peekTok = p.curTok
+	p.curTok = p.prevTok
+}
+
+// peekAt returns the token n positions after curTok without consuming
+// anything; peekAt(1) is peekTok.
+func (p *Parser) peekAt(n int) token.Token {
+	if n <= 1 {
+		return p.peekTok
+	}
+	for len(p.ahead) < n-1 {
+		p.ahead = append(p.ahead, p.lexToken())
+	}
+	return p.ahead[n-2]
+}
+
+// expectPeek advances if the next token is of the given kind and reports
+// whether it did.
+func (p *Parser) expectPeek(kind token.Kind) bool {
+	if p.peekTok.Kind == kind {
+		p.nextToken()
+		return true
+	}
+	return false
+}
+
+// expectPeekName advances if the next token is a name, see isName.
+func (p *Parser) expectPeekName() bool {
+	if isName(p.peekTok.Kind) {
+		p.nextToken()
+		return true
+	}
+	return false
+}
+
+// isName reports whether kind is a class, function or constant name,
+// whether unqualified, qualified, fully qualified or relative.
+func isName(kind token.Kind) bool {
+	switch kind {
+	case token.IDENT, token.NAME_QUALIFIED, token.NAME_FULLY_QUALIFIED, token.NAME_RELATIVE:
+		return true
+	}
+	return false
+}
+
+// peekEndsStatement reports whether peekTok ends the statement, so that a
+// list being parsed, left unclosed, cannot go on.
+func (p *Parser) peekEndsStatement() bool {
+	switch p.peekTok.Kind {
+	case token.SEMICOLON, token.RBRACE, token.CLOSE_TAG, token.EOF:
+		return true
+	}
+	return false
+}
+
+// skipUntilPeek advances until the next token is one of kinds, outside of
+// any parentheses, brackets or braces opened on the way. It stops before
+// what ends the statement too: a ';', the '}' of the enclosing block or the
+// end of the PHP code.
+func (p *Parser) skipUntilPeek(kinds ...token.Kind) {
+	depth := 0
+	for p.curTok.Kind != token.CLOSE_TAG && p.peekTok.Kind != token.EOF && p.peekTok.Kind != token.CLOSE_TAG {
+		if depth == 0 {
+			for _, kind := range kinds {
+				if p.peekTok.Kind == kind {
+					return
+				}
+			}
+		}
+		switch p.peekTok.Kind {
+		case token.LPAREN, token.LBRACKET, token.LBRACE, token.ATTRIBUTE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
 }
 
+// ParseProgram parses a whole file. Inline HTML between PHP blocks becomes
+// InlineHTMLStmt nodes, so every PHP block of a template is part of the
+// same program.
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{Stmts: []ast.Stmt{}}
-	if p.curTok.Kind != token.OPEN_TAG {
-		return program
-	}
+	program.S = p.curTok.Span.Start
 
-	p.nextToken()
-	
-	for p.curTok.Kind != token.EOF && p.curTok.Kind != token.CLOSE_TAG {
-		stmt := p.parseStatement()
+	for p.curTok.Kind != token.EOF {
+		stmt := p.parseStatementOrSync()
 		if stmt != nil {
 			program.Stmts = append(program.Stmts, stmt)
-			// Ensure we advance the token stream after parsing a statement.
-			// Some parse functions may not consume tokens fully, so explicitly move to the next token
-			// to avoid an infinite loop that grows the statements slice without bound.
-			p.nextToken()
 		}
+		// Parse functions leave the last token of their statement in
+		// curTok, so always move on to avoid looping on the same token.
+		p.nextToken()
 	}
+	program.E = p.curTok.Span.End
+	program.Tokens = p.tokens
 
 	return program
 }
 
 func (p *Parser) parseStatement() ast.Stmt {
-	var stmt ast.Stmt
 	switch p.curTok.Kind {
-	/*case token.FUNCTION:
-		stmt = p.parseFunctionDeclaration()*/
-	default:
-		stmt = p.parseExpressionStatement()
+	case token.OPEN_TAG, token.CLOSE_TAG, token.SEMICOLON:
+		// A closing tag implies a semicolon; none of these produce a node.
+		return nil
+	case token.INLINE_HTML:
+		return p.parseInlineHTML()
+	case token.ECHO, token.OPEN_TAG_WITH_ECHO:
+		return p.parseEchoStatement()
+	case token.LBRACE:
+		return p.parseBlockStatement()
+	case token.NAMESPACE:
+		return p.parseNamespace()
+	case token.USE:
+		return p.parseUseStatement()
+	case token.IF:
+		return p.parseIfStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.DO:
+		return p.parseDoWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.FOREACH:
+		return p.parseForeachStatement()
+	case token.SWITCH:
+		return p.parseSwitchStatement()
+	case token.TRY:
+		return p.parseTryStatement()
+	case token.RETURN:
+		return p.parseReturnStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.GLOBAL:
+		return p.parseGlobalStatement()
+	case token.UNSET:
+		return p.parseUnsetStatement()
+	case token.DECLARE:
+		return p.parseDeclareStatement()
+	case token.HALT_COMPILER:
+		return p.parseHaltCompilerStatement()
+	case token.CONST:
+		return p.parseConstStatement()
+	case token.GOTO:
+		return p.parseGotoStatement()
+	case token.STATIC:
+		// "static $a;" declares static variables; static::f() and
+		// static closures are expressions.
+		if p.peekTok.Kind == token.VARIABLE {
+			return p.parseStaticStatement()
+		}
+	case token.IDENT:
+		if p.peekTok.Kind == token.COLON {
+			return p.parseLabelStatement()
+		}
+		p.promoteKeyword()
+	case token.ATTRIBUTE:
+		if p.isAttributedExpression() {
+			break
+		}
+		attrs := p.parseAttributeGroups()
+		p.nextToken()
+		if p.isDeclaration() {
+			return p.parseDeclaration(attrs)
+		}
+		p.unexpected(p.curTok)
+		return nil
 	}
-	return stmt
+
+	if p.isDeclaration() {
+		return p.parseDeclaration(nil)
+	}
+
+	return p.parseExpressionStatement()
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curTok}
-	stmt.Expression = p.parseExpression()
+// parseBlockStatement parses statements up to the '}' matching the '{' in
+// curTok.
+func (p *Parser) parseBlockStatement() *ast.BlockStmt {
+	block := &ast.BlockStmt{Token: p.curTok, Stmts: []ast.Stmt{}}
+	block.S = p.curTok.Span.Start
 
-	if p.peekTok.Kind == token.SEMICOLON {
+	p.nextToken()
+	for p.curTok.Kind != token.RBRACE && p.curTok.Kind != token.EOF {
+		if stmt := p.parseStatementOrSync(); stmt != nil {
+			block.Stmts = append(block.Stmts, stmt)
+		}
 		p.nextToken()
 	}
-	return stmt
+	if p.curTok.Kind == token.EOF {
+		p.unexpected(p.curTok, token.RBRACE)
+	}
+	block.E = p.curTok.Span.End
+	return block
 }
 
-func (p *Parser) parseExpression() ast.Expr {
-	prefix := p.prefixParseFns[p.curTok.Kind]
-	if prefix == nil {
-		return nil // No prefix parsing function found
+func (p *Parser) parseInlineHTML() *ast.InlineHTMLStmt {
+	return &ast.InlineHTMLStmt{
+		Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+		Token: p.curTok,
+		Value: p.curTok.Lexeme,
 	}
-	leftExp := prefix()
+}
 
-	// This is the core of the Pratt parser for infix operators (like function calls)
-	for p.peekTok.Kind != token.SEMICOLON {
-		infix := p.infixParseFns[p.peekTok.Kind]
-		if infix == nil {
-			return leftExp
-		}
+// parseEchoStatement parses "echo a, b;" as well as the "<?= a ?>" short
+// form, which PHP treats as an echo statement.
+func (p *Parser) parseEchoStatement() ast.Stmt {
+	stmt := &ast.EchoStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	for {
 		p.nextToken()
-		leftExp = infix(leftExp)
+		expr := p.parseExpression(LOWEST)
+		if expr == nil {
+			return nil
+		}
+		stmt.Expressions = append(stmt.Expressions, expr)
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseExpressionStatement() ast.Stmt {
+	stmt := &ast.ExpressionStatement{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+	p.exprStmtStart = stmt.S
+	if stmt.Expression = p.parseExpression(LOWEST); stmt.Expression == nil {
+		return nil
 	}
 
-	return leftExp
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
 }
 
 func (p *Parser) parseIdentifier() ast.Expr {
@@ -126,72 +439,163 @@ func (p *Parser) parseIdentifier() ast.Expr {
 	}
 }
 
-func (p *Parser) parseCallExpression(function ast.Expr) ast.Expr {
-	expr := &ast.CallExpr{
-		Base:     ast.Base{S: function.Pos()},
-		Token:    p.curTok,
-		Function: function,
-	}
+// parseNumberLiteral parses integer and float literals. Integers that do not
+// fit into 64 bits become floats, as in PHP.
+func (p *Parser) parseNumberLiteral() ast.Expr {
+	base := ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End}
+	p.checkNumberVersion(p.curTok)
+	digits := strings.ReplaceAll(p.curTok.Lexeme, "_", "")
 
-	expr.Arguments = p.parseCallArguments()
-	expr.E = p.curTok.Span.End
+	if p.curTok.Kind == token.INTEGER {
+		if len(digits) > 1 && digits[0] == '0' && isDigits(digits[1:]) {
+			digits = "0o" + digits[1:] // Legacy octal notation, e.g. 0755
+		}
+		if value, err := strconv.ParseInt(digits, 0, 64); err == nil {
+			return &ast.IntegerLiteral{Base: base, Token: p.curTok, Value: value}
+		}
+		value, _ := new(big.Float).SetString(digits)
+		if value != nil {
+			f, _ := value.Float64()
+			return &ast.FloatLiteral{Base: base, Token: p.curTok, Value: f}
+		}
+	}
 
-	return expr
+	value, _ := strconv.ParseFloat(digits, 64)
+	return &ast.FloatLiteral{Base: base, Token: p.curTok, Value: value}
 }
 
-func (p *Parser) parseCallArguments() []ast.Expr {
-	args := []ast.Expr{}
-	if p.peekTok.Kind == token.RPAREN {
-		p.nextToken()
-		return args
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
-	p.nextToken()
-	// For now, we don't parse arguments, just acknowledge the call
-	for p.curTok.Kind != token.RPAREN && p.curTok.Kind != token.EOF {
-		// A real implementation would call p.parseExpression() here
-		p.nextToken()
+	return true
+}
+
+func (p *Parser) parseVariable() ast.Expr {
+	return &ast.Variable{
+		Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+		Token: p.curTok,
+		Name:  strings.TrimPrefix(p.curTok.Lexeme, "$"),
 	}
-	return args
 }
 
-/*
-func (p *Parser) parseFunctionDeclaration() ast.Expr {
-	stmt := &ast.FunctionDeclStmt{Token: p.curTok}
+func (p *Parser) parseStringLiteral() ast.Expr {
+	lit := &ast.StringLiteral{
+		Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+		Token: p.curTok,
+	}
+	for _, part := range p.curTok.Parts {
+		lit.Value += part.Value
+	}
+	return lit
+}
 
-	// Expect the function name (an identifier)
-	if !p.expectPeek(token.IDENT) {
-		return nil // Not a valid function declaration
+// parseInterpolatedString parses a double-quoted string or heredoc that
+// embeds variables.
+func (p *Parser) parseInterpolatedString() ast.Expr {
+	return &ast.InterpolatedString{
+		Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+		Token: p.curTok,
+		Parts: p.parseStringParts(p.curTok.Parts),
 	}
-	stmt.Name = &ast.Identifier{Token: p.curTok, Value: p.curTok.Lexeme}
+}
 
-	// Expect the opening parenthesis for arguments
-	if !p.expectPeek(token.LPAREN) {
-		return nil
+// parseShellExec parses a shell command in backticks.
+func (p *Parser) parseShellExec() ast.Expr {
+	return &ast.ShellExecExpr{
+		Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+		Token: p.curTok,
+		Parts: p.parseStringParts(p.curTok.Parts),
 	}
+}
 
-	// For now, we just skip over the arguments until we find the closing parenthesis.
-	// A more advanced parser would parse each argument here.
-	for p.curTok.Kind != token.RPAREN && p.curTok.Kind != token.EOF {
-		p.nextToken()
+// parseStringParts turns the parts of a string token into literal and
+// expression nodes. Interpolated pieces are parsed with a separate parser
+// over the piece's source.
+func (p *Parser) parseStringParts(parts []token.StringPart) []ast.Expr {
+	var exprs []ast.Expr
+	for _, part := range parts {
+		var expr ast.Expr
+		switch part.Kind {
+		case token.PartLiteral:
+			expr = &ast.StringLiteral{
+				Base:  ast.Base{S: part.Span.Start, E: part.Span.End},
+				Token: token.Token{Kind: token.STRING, Lexeme: part.Value, Span: part.Span, Parts: []token.StringPart{part}},
+				Value: part.Value,
+			}
+		case token.PartDollarBrace:
+			// "${name}" is the variable $name and "${name[key]}" an element
+			// of it, anything else is an expression whose value names the
+			// variable.
+			if isPlainName(part.Value) {
+				expr = &ast.Variable{
+					Base:  ast.Base{S: part.Span.Start, E: part.Span.End},
+					Token: token.Token{Kind: token.VARIABLE, Lexeme: "$" + part.Value, Span: part.Span},
+					Name:  part.Value,
+				}
+			} else if i := strings.IndexByte(part.Value, '['); i > 0 && isPlainName(part.Value[:i]) {
+				expr = p.parseFragment(part)
+				if index, ok := expr.(*ast.IndexExpr); ok {
+					if name, ok := index.Left.(*ast.Identifier); ok {
+						index.Left = &ast.Variable{
+							Base:  name.Base,
+							Token: token.Token{Kind: token.VARIABLE, Lexeme: "$" + name.Value, Span: name.Token.Span},
+							Name:  name.Value,
+						}
+					}
+				}
+			} else {
+				expr = p.parseFragment(part)
+			}
+		default:
+			expr = p.parseFragment(part)
+		}
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
 	}
+	return exprs
+}
 
-	// Expect the opening curly brace for the function body
-	if !p.expectPeek(token.LBRACE) {
-		return nil
+// parseFragment parses the source of an interpolated string part as an
+// expression.
+func (p *Parser) parseFragment(part token.StringPart) ast.Expr {
+	sub := New(lexer.NewFragment(part.Value, part.Span.Start, lexer.WithVersion(p.l.Version())))
+	if part.Kind != token.PartVar {
+		sub.fragmentEnd = "}"
+	}
+	expr := sub.parseExpression(LOWEST)
+	if expr != nil && sub.peekTok.Kind != token.EOF {
+		sub.peekError()
+		expr = nil
 	}
+	p.errors = append(append(p.errors, sub.l.Errors()...), sub.errors...)
+	p.versionErrors = append(p.versionErrors, sub.versionErrors...)
 
-	// Smartly skip the function body by tracking the nesting of curly braces.
-	// This prevents the parser from stopping on a '}' inside a nested block.
-	braceDepth := 1
-	for braceDepth > 0 && p.curTok.Kind != token.EOF {
-		p.nextToken()
-		if p.curTok.Kind == token.LBRACE {
-			braceDepth++
+	// In simple interpolation an unquoted key is a string, so "$a[key]"
+	// reads $a['key'] rather than the constant key.
+	if index, ok := expr.(*ast.IndexExpr); ok && part.Kind == token.PartVar {
+		if key, ok := index.Index.(*ast.Identifier); ok {
+			index.Index = &ast.StringLiteral{
+				Base:  key.Base,
+				Token: token.Token{Kind: token.STRING, Lexeme: key.Value, Span: key.Token.Span, Parts: []token.StringPart{{Kind: token.PartLiteral, Value: key.Value, Span: key.Token.Span}}},
+				Value: key.Value,
+			}
 		}
-		if p.curTok.Kind == token.RBRACE {
-			braceDepth--
+	}
+	return expr
+}
+
+func isPlainName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || unicode.IsLetter(r) || r >= 0x80 || (i > 0 && unicode.IsDigit(r))) {
+			return false
 		}
 	}
-	
-	return stmt
-}*/
\ No newline at end of file
+	return true
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+)
+
+// printTest is a program that parses without errors, and how it prints,
+// with its grouping.
+type printTest struct {
+	src  string
+	want string
+}
+
+func testPrinted(t *testing.T, tests []printTest) {
+	for _, tt := range tests {
+		p, program := parse("<?php "+tt.src, lexer.LatestVersion)
+		if errors := errorList(p); len(errors) > 0 {
+			t.Errorf("%q: errors %q", tt.src, errors)
+		}
+		if got := program.String(); got != tt.want {
+			t.Errorf("%q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	testPrinted(t, []printTest{
+		{"function f(int $a = 1, ...$b): ?int { return $a; }", "function f(int $a = 1, ...$b): ?int { return $a; }"},
+		{"function &f(array &$a, ?B ...$c): static {}", "function &f(array &$a, ?B ...$c): static { }"},
+		{"function f(#[A] int|string $a = 1) {}", "function f(#[A] int|string $a = 1) { }"},
+	})
+}
+
+func TestParseClasses(t *testing.T) {
+	testPrinted(t, []printTest{
+		{"class C extends B { public const X = 1; private ?int $p = null; public function __construct(private readonly int $x) {} }",
+			"class C extends B { public const X = 1; private ?int $p = null; public function __construct(private readonly int $x) { } }"},
+		{"abstract class A implements I { abstract protected function m(): void; public static $s = []; }",
+			"abstract class A implements I { abstract protected function m(): void; public static $s = []; }"},
+		{"interface I extends J, K { public function m(); }", "interface I extends J, K { public function m(); }"},
+		{"trait T { use U { m as protected n; } }", "trait T { use U { m as protected n; } }"},
+		{"enum E: string { case A = \"a\"; }", "enum E: string { case A = 'a'; }"},
+		{"enum E: int implements I { case A = 1; const B = self::A; }", "enum E: int implements I { case A = 1; const B = self::A; }"},
+	})
+}
+
+func TestParseExpressions(t *testing.T) {
+	testPrinted(t, []printTest{
+		// Precedence and associativity.
+		{"$a = 1 + 2 * 3;", "$a = (1 + (2 * 3))"},
+		{"$a = (1 + 2) * 3;", "$a = ((1 + 2) * 3)"},
+		{"$a = -$b ** 2;", "$a = (-($b ** 2))"},
+		{"$a = !$b instanceof C;", "$a = (!($b instanceof C))"},
+		{"$a = $b ?: $c ?? $d;", "$a = ($b ?: ($c ?? $d))"},
+		{"$a = $b and $c;", "($a = $b and $c)"},
+		// Accesses and arguments.
+		{"$a?->b()::C;", "$a?->b()::C"},
+		{"$a = f(...$b, c: 1);", "$a = f(...$b, c: 1)"},
+		{"list($a, [$b, $c]) = $d;", "list($a, [$b, $c]) = $d"},
+	})
+}
+
+func TestParseStatements(t *testing.T) {
+	testPrinted(t, []printTest{
+		// Both syntaxes of control structures.
+		{"if ($a): echo 1; elseif ($b): echo 2; else: echo 3; endif;", "if ($a) { echo 1; } elseif ($b) { echo 2; } else { echo 3; }"},
+		{"while ($a): endwhile; do { } while ($a);", "while ($a) { }do { } while ($a);"},
+		{"foreach ($a as $k => &$v) {}", "foreach ($a as $k => &$v) { }"},
+		{"for ($i = 0; $i < 3; $i++) { continue 2; }", "for ($i = 0; ($i < 3); ($i++)) { continue 2; }"},
+		{"switch ($a) { case 1: break; default: }", "switch ($a) { case 1: break; default: }"},
+		{"try { f(); } catch (A|B $e) { } finally { }", "try { f() } catch (A | B $e) { } finally { }"},
+		{"echo match ($a) { 1, 2 => \"a\", default => \"b\" };", "echo match ($a) { 1, 2 => 'a', default => 'b' };"},
+		{"goto a; a: echo 1;", "goto a;a:echo 1;"},
+	})
+}
+
+func TestParseClosures(t *testing.T) {
+	testPrinted(t, []printTest{
+		{"$f = fn($x) => $x * 2;", "$f = fn($x) => ($x * 2)"},
+		{"$f = static fn(int $x): int => $x;", "$f = static fn(int $x): int => $x"},
+		{"$f = function ($a) use (&$b): int { return $a; };", "$f = function ($a) use (&$b): int { return $a; }"},
+		{"$f = strlen(...);", "$f = strlen(...)"},
+	})
+}
@@ -0,0 +1,627 @@
+package parser
+
+import (
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// expectStatementEnd advances over the ';' that ends a statement. A closing
+// tag also ends a statement; it is left in peekTok for ParseProgram.
+func (p *Parser) expectStatementEnd() bool {
+	if p.expectPeek(token.SEMICOLON) || p.peekTok.Kind == token.CLOSE_TAG {
+		return true
+	}
+	p.peekError(token.SEMICOLON)
+	return false
+}
+
+// isStatementEnd reports whether peekTok ends the current statement.
+func (p *Parser) isStatementEnd() bool {
+	switch p.peekTok.Kind {
+	case token.SEMICOLON, token.CLOSE_TAG, token.EOF:
+		return true
+	}
+	return false
+}
+
+// parseCondition parses "(expr)" with the '(' in peekTok and leaves curTok on
+// the ')'.
+func (p *Parser) parseCondition() ast.Expr {
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	cond := p.parseExpression(LOWEST)
+	if cond == nil || !p.expect(token.RPAREN) {
+		return nil
+	}
+	return cond
+}
+
+// parseBody parses the body of a control structure after its header: a
+// block, a single statement, or with the alternative syntax the statements
+// following ':' up to one of ends, which is left in peekTok. It reports
+// whether the alternative syntax was used.
+func (p *Parser) parseBody(ends ...token.Kind) (*ast.BlockStmt, bool) {
+	if len(ends) > 0 && p.expectPeek(token.COLON) {
+		block := &ast.BlockStmt{Token: p.curTok, Stmts: []ast.Stmt{}}
+		block.S = p.curTok.Span.Start
+		for !p.peekIsOneOf(ends...) && p.peekTok.Kind != token.EOF {
+			p.nextToken()
+			if stmt := p.parseStatementOrSync(); stmt != nil {
+				block.Stmts = append(block.Stmts, stmt)
+			}
+		}
+		block.E = p.curTok.Span.End
+		return block, true
+	}
+
+	p.nextToken()
+	if p.curTok.Kind == token.LBRACE {
+		return p.parseBlockStatement(), false
+	}
+	block := &ast.BlockStmt{Token: p.curTok, Stmts: []ast.Stmt{}}
+	block.S = p.curTok.Span.Start
+	if stmt := p.parseStatementOrSync(); stmt != nil {
+		block.Stmts = append(block.Stmts, stmt)
+	}
+	block.E = p.curTok.Span.End
+	return block, false
+}
+
+func (p *Parser) peekIsOneOf(kinds ...token.Kind) bool {
+	for _, kind := range kinds {
+		if p.peekTok.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAltEnd consumes the "endif;"-style keyword closing a control
+// structure written in the alternative syntax.
+func (p *Parser) parseAltEnd(end token.Kind) bool {
+	return p.expect(end) && p.expectStatementEnd()
+}
+
+// parseIfStatement parses if/elseif/else in both the brace and the
+// alternative "if (...): ... endif;" syntax.
+func (p *Parser) parseIfStatement() ast.Stmt {
+	stmt := &ast.IfStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if stmt.Condition = p.parseCondition(); stmt.Condition == nil {
+		return nil
+	}
+	stmt.Body, stmt.AltSyntax = p.parseBody(token.ELSEIF, token.ELSE, token.ENDIF)
+
+	for p.expectPeek(token.ELSEIF) {
+		clause := &ast.ElseIfClause{Token: p.curTok}
+		clause.S = p.curTok.Span.Start
+		if clause.Condition = p.parseCondition(); clause.Condition == nil {
+			return nil
+		}
+		clause.Body, _ = p.parseBody(token.ELSEIF, token.ELSE, token.ENDIF)
+		clause.E = p.curTok.Span.End
+		stmt.ElseIfs = append(stmt.ElseIfs, clause)
+	}
+
+	if p.expectPeek(token.ELSE) {
+		clause := &ast.ElseClause{Token: p.curTok}
+		clause.S = p.curTok.Span.Start
+		clause.Body, _ = p.parseBody(token.ENDIF)
+		clause.E = p.curTok.Span.End
+		stmt.Else = clause
+	}
+
+	if stmt.AltSyntax && !p.parseAltEnd(token.ENDIF) {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Stmt {
+	stmt := &ast.WhileStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if stmt.Condition = p.parseCondition(); stmt.Condition == nil {
+		return nil
+	}
+	stmt.Body, stmt.AltSyntax = p.parseBody(token.ENDWHILE)
+	if stmt.AltSyntax && !p.parseAltEnd(token.ENDWHILE) {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseDoWhileStatement() ast.Stmt {
+	stmt := &ast.DoWhileStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	stmt.Body, _ = p.parseBody()
+	if !p.expect(token.WHILE) {
+		return nil
+	}
+	if stmt.Condition = p.parseCondition(); stmt.Condition == nil {
+		return nil
+	}
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseForStatement parses for (init; cond; step) with curTok on 'for'.
+func (p *Parser) parseForStatement() ast.Stmt {
+	stmt := &ast.ForStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	var ok bool
+	if stmt.Init, ok = p.parseExpressionList(token.SEMICOLON); !ok {
+		return nil
+	}
+	if stmt.Condition, ok = p.parseExpressionList(token.SEMICOLON); !ok {
+		return nil
+	}
+	if stmt.Step, ok = p.parseExpressionList(token.RPAREN); !ok {
+		return nil
+	}
+
+	stmt.Body, stmt.AltSyntax = p.parseBody(token.ENDFOR)
+	if stmt.AltSyntax && !p.parseAltEnd(token.ENDFOR) {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseExpressionList parses a possibly empty comma-separated list of
+// expressions followed by end, and leaves curTok on end.
+func (p *Parser) parseExpressionList(end token.Kind) ([]ast.Expr, bool) {
+	exprs := []ast.Expr{}
+	for p.peekTok.Kind != end && p.peekTok.Kind != token.EOF {
+		p.nextToken()
+		expr := p.parseExpression(LOWEST)
+		if expr == nil {
+			return nil, false
+		}
+		exprs = append(exprs, expr)
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	return exprs, p.expect(end)
+}
+
+// parseForeachStatement parses foreach ($expr as $key => &$value).
+func (p *Parser) parseForeachStatement() ast.Stmt {
+	stmt := &ast.ForeachStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	if stmt.Expr = p.parseExpression(LOWEST); stmt.Expr == nil {
+		return nil
+	}
+	if !p.expect(token.AS) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.ByRef, stmt.Value = p.parseForeachTarget()
+	if stmt.Value == nil {
+		return nil
+	}
+	if !stmt.ByRef && p.expectPeek(token.DOUBLE_ARROW) {
+		stmt.Key = stmt.Value
+		p.nextToken()
+		if stmt.ByRef, stmt.Value = p.parseForeachTarget(); stmt.Value == nil {
+			return nil
+		}
+	}
+	if !p.expect(token.RPAREN) {
+		return nil
+	}
+
+	stmt.Body, stmt.AltSyntax = p.parseBody(token.ENDFOREACH)
+	if stmt.AltSyntax && !p.parseAltEnd(token.ENDFOREACH) {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseForeachTarget() (bool, ast.Expr) {
+	byRef := false
+	if p.curTok.Kind == token.AMPERSAND {
+		byRef = true
+		p.nextToken()
+	}
+	return byRef, p.parseExpression(LOWEST)
+}
+
+// parseSwitchStatement parses a switch with its case and default labels in
+// the brace or the alternative "switch (...): ... endswitch;" syntax.
+func (p *Parser) parseSwitchStatement() ast.Stmt {
+	stmt := &ast.SwitchStmt{Token: p.curTok, Cases: []*ast.SwitchCase{}}
+	stmt.S = p.curTok.Span.Start
+
+	if stmt.Subject = p.parseCondition(); stmt.Subject == nil {
+		return nil
+	}
+	end := token.Kind(token.RBRACE)
+	if p.expectPeek(token.COLON) {
+		stmt.AltSyntax = true
+		end = token.ENDSWITCH
+	} else if !p.expectPeek(token.LBRACE) {
+		p.peekError(token.LBRACE, token.COLON)
+		return nil
+	}
+	p.expectPeek(token.SEMICOLON) // PHP allows "switch ($a) {;"
+
+	for p.peekTok.Kind == token.CASE || p.peekTok.Kind == token.DEFAULT {
+		p.nextToken()
+		c := &ast.SwitchCase{Token: p.curTok, Body: []ast.Stmt{}}
+		c.S = p.curTok.Span.Start
+		if p.curTok.Kind == token.CASE {
+			p.nextToken()
+			if c.Value = p.parseExpression(LOWEST); c.Value == nil {
+				return nil
+			}
+		}
+		// A case label may end with ';' as well as ':'.
+		if !p.expectPeek(token.COLON) && !p.expectPeek(token.SEMICOLON) {
+			p.peekError(token.COLON, token.SEMICOLON)
+			return nil
+		}
+		for !p.peekIsOneOf(token.CASE, token.DEFAULT, end, token.EOF) {
+			p.nextToken()
+			if s := p.parseStatementOrSync(); s != nil {
+				c.Body = append(c.Body, s)
+			}
+		}
+		c.E = p.curTok.Span.End
+		stmt.Cases = append(stmt.Cases, c)
+	}
+
+	if !p.expect(end) {
+		return nil
+	}
+	if stmt.AltSyntax && !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseMatchExpression parses match ($subject) { a, b => x, default => y }.
+func (p *Parser) parseMatchExpression() ast.Expr {
+	expr := &ast.MatchExpr{Token: p.curTok, Arms: []*ast.MatchArm{}}
+	expr.S = p.curTok.Span.Start
+	p.requireVersion(p.curTok, 8, 0, "match expressions")
+
+	if expr.Subject = p.parseCondition(); expr.Subject == nil {
+		return nil
+	}
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+
+	for p.peekTok.Kind != token.RBRACE && p.peekTok.Kind != token.EOF {
+		p.nextToken()
+		arm := &ast.MatchArm{}
+		arm.S = p.curTok.Span.Start
+		if p.curTok.Kind == token.DEFAULT {
+			p.expectPeek(token.COMMA)
+		} else {
+			for {
+				cond := p.parseExpression(LOWEST)
+				if cond == nil {
+					return nil
+				}
+				arm.Conditions = append(arm.Conditions, cond)
+				if !p.expectPeek(token.COMMA) || p.peekTok.Kind == token.DOUBLE_ARROW {
+					break
+				}
+				p.nextToken()
+			}
+		}
+		if !p.expect(token.DOUBLE_ARROW) {
+			return nil
+		}
+		p.nextToken()
+		if arm.Body = p.parseExpression(LOWEST); arm.Body == nil {
+			return nil
+		}
+		arm.E = p.curTok.Span.End
+		expr.Arms = append(expr.Arms, arm)
+
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+
+	if !p.expect(token.RBRACE) {
+		return nil
+	}
+	expr.E = p.curTok.Span.End
+	return expr
+}
+
+// parseTryStatement parses try/catch/finally. A catch may list several
+// exception types separated by '|' and may omit the variable.
+func (p *Parser) parseTryStatement() ast.Stmt {
+	stmt := &ast.TryStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	for p.expectPeek(token.CATCH) {
+		clause := &ast.CatchClause{Token: p.curTok}
+		clause.S = p.curTok.Span.Start
+		if !p.expect(token.LPAREN) || !p.expectName() {
+			return nil
+		}
+		clause.Types = append(clause.Types, p.parseIdentifier().(*ast.Identifier))
+		for p.expectPeek(token.PIPE) {
+			p.requireVersion(p.curTok, 7, 1, "multi-catch clauses")
+			if !p.expectName() {
+				return nil
+			}
+			clause.Types = append(clause.Types, p.parseIdentifier().(*ast.Identifier))
+		}
+		if p.expectPeek(token.VARIABLE) {
+			clause.Var = p.parseVariable().(*ast.Variable)
+		} else {
+			p.requireVersion(p.peekTok, 8, 0, "catch clauses without a variable")
+		}
+		if !p.expect(token.RPAREN) || !p.expect(token.LBRACE) {
+			return nil
+		}
+		clause.Body = p.parseBlockStatement()
+		clause.E = p.curTok.Span.End
+		stmt.Catches = append(stmt.Catches, clause)
+	}
+
+	if p.expectPeek(token.FINALLY) {
+		if !p.expect(token.LBRACE) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+	if stmt.Catches == nil && stmt.Finally == nil {
+		p.peekError(token.CATCH, token.FINALLY)
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseReturnStatement() ast.Stmt {
+	stmt := &ast.ReturnStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.isStatementEnd() {
+		p.nextToken()
+		if stmt.Value = p.parseExpression(LOWEST); stmt.Value == nil {
+			return nil
+		}
+	}
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseLoopLevels parses the optional level of break N and continue N.
+func (p *Parser) parseLoopLevels() (ast.Expr, bool) {
+	var levels ast.Expr
+	if !p.isStatementEnd() {
+		p.nextToken()
+		if levels = p.parseExpression(LOWEST); levels == nil {
+			return nil, false
+		}
+	}
+	return levels, p.expectStatementEnd()
+}
+
+func (p *Parser) parseBreakStatement() ast.Stmt {
+	stmt := &ast.BreakStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	var ok bool
+	if stmt.Levels, ok = p.parseLoopLevels(); !ok {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() ast.Stmt {
+	stmt := &ast.ContinueStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	var ok bool
+	if stmt.Levels, ok = p.parseLoopLevels(); !ok {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseGlobalStatement() ast.Stmt {
+	stmt := &ast.GlobalStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	for {
+		p.nextToken()
+		var v ast.Expr
+		switch p.curTok.Kind {
+		case token.VARIABLE:
+			v = p.parseVariable()
+		case token.DOLLAR:
+			v = p.parseVariableVariable()
+		default:
+			p.unexpected(p.curTok, token.VARIABLE)
+		}
+		if v == nil {
+			return nil
+		}
+		stmt.Vars = append(stmt.Vars, v)
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseStaticStatement parses static $a = 1, $b; with curTok on 'static'.
+func (p *Parser) parseStaticStatement() ast.Stmt {
+	stmt := &ast.StaticStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	for p.expectPeek(token.VARIABLE) {
+		v := &ast.StaticVar{Name: p.parseVariable().(*ast.Variable)}
+		v.S = p.curTok.Span.Start
+		if p.expectPeek(token.ASSIGN) {
+			p.nextToken()
+			if v.Default = p.parseExpression(LOWEST); v.Default == nil {
+				return nil
+			}
+		}
+		v.E = p.curTok.Span.End
+		stmt.Vars = append(stmt.Vars, v)
+		if !p.expectPeek(token.COMMA) {
+			break
+		}
+	}
+	if stmt.Vars == nil || !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseUnsetStatement() ast.Stmt {
+	stmt := &ast.UnsetStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	var ok bool
+	if stmt.Vars, ok = p.parseExpressionList(token.RPAREN); !ok {
+		return nil
+	}
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseDeclareStatement parses declare(strict_types=1); as well as the block
+// and "declare(...): ... enddeclare;" forms.
+func (p *Parser) parseDeclareStatement() ast.Stmt {
+	stmt := &ast.DeclareStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LPAREN) {
+		return nil
+	}
+	if stmt.Directives = p.parseConstItems(); stmt.Directives == nil {
+		return nil
+	}
+	if !p.expect(token.RPAREN) {
+		return nil
+	}
+
+	// A declare without a body applies to the rest of the file.
+	if p.isStatementEnd() {
+		if !p.expectStatementEnd() {
+			return nil
+		}
+	} else {
+		stmt.Body, stmt.AltSyntax = p.parseBody(token.ENDDECLARE)
+		if stmt.AltSyntax && !p.parseAltEnd(token.ENDDECLARE) {
+			return nil
+		}
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseHaltCompilerStatement parses "__halt_compiler();", after which the
+// lexer returns the rest of the file as a single INLINE_HTML token.
+func (p *Parser) parseHaltCompilerStatement() ast.Stmt {
+	stmt := &ast.HaltCompilerStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.LPAREN) || !p.expect(token.RPAREN) || !p.expectStatementEnd() {
+		return nil
+	}
+	if p.peekTok.Kind == token.CLOSE_TAG {
+		p.nextToken()
+	}
+	if p.peekTok.Kind == token.INLINE_HTML {
+		p.nextToken()
+		stmt.Data = p.curTok.Lexeme
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseConstStatement parses const A = 1, B = 2; outside of a class.
+func (p *Parser) parseConstStatement() ast.Stmt {
+	stmt := &ast.ConstStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if stmt.Consts = p.parseConstItems(); stmt.Consts == nil {
+		return nil
+	}
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+func (p *Parser) parseGotoStatement() ast.Stmt {
+	stmt := &ast.GotoStmt{Token: p.curTok}
+	stmt.S = p.curTok.Span.Start
+
+	if !p.expect(token.IDENT) {
+		return nil
+	}
+	stmt.Label = p.parseIdentifier().(*ast.Identifier)
+	if !p.expectStatementEnd() {
+		return nil
+	}
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
+
+// parseLabelStatement parses a goto target "name:" with curTok on the name.
+func (p *Parser) parseLabelStatement() ast.Stmt {
+	stmt := &ast.LabelStmt{Name: p.parseIdentifier().(*ast.Identifier)}
+	stmt.S = p.curTok.Span.Start
+	p.nextToken()
+	stmt.E = p.curTok.Span.End
+	return stmt
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+)
+
+func TestInterpolation(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`"$a"`, `"{$a}"`},
+		{`"$a[k]"`, `"{$a['k']}"`},
+		{`"$a[0] $a[$i]"`, `"{$a[0]} {$a[$i]}"`},
+		{`"$a->b"`, `"{$a->b}"`},
+		{`"{$a->b()}"`, `"{$a->b()}"`},
+		{`"${a}"`, `"{$a}"`},
+		// A name followed by [ is the variable, not a constant.
+		{`"${a[1]}"`, `"{$a[1]}"`},
+		{`"${a['k']}"`, `"{$a['k']}"`},
+		{`"${a}[1]"`, `"{$a}[1]"`},
+		{"`ls`", "`ls`"},
+		{"`ls $dir {$a->b}`", "`ls {$dir} {$a->b}`"},
+	}
+	for _, tt := range tests {
+		p, program := parse("<?php echo "+tt.src+";", lexer.LatestVersion)
+		if errs := errorList(p); len(errs) > 0 {
+			t.Errorf("%s: %v", tt.src, errs)
+			continue
+		}
+		if got := program.String(); got != "echo "+tt.want+";" {
+			t.Errorf("%s: got %s, want echo %s;", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		errors []string
+	}{
+		{`"abc {$a->} def"`, []string{`1:17 syntax error, unexpected token "}", expecting identifier`}},
+		{`"{$a + }"`, []string{`1:14 syntax error, unexpected token "}"`}},
+		{`"{$a b}"`, []string{`1:12 syntax error, unexpected identifier "b"`}},
+		{`"${a + }"`, []string{`1:14 syntax error, unexpected token "}"`}},
+		{`"${}"`, []string{`1:9 syntax error, unexpected token "}"`}},
+		{"`ls {$a->}`", []string{`1:16 syntax error, unexpected token "}", expecting identifier`}},
+		// The code after the string is still parsed.
+		{`"{$a + }"; $b = ;`, []string{`1:14 syntax error, unexpected token "}"`, `1:23 syntax error, unexpected token ";"`}},
+	}
+	for _, tt := range tests {
+		p, _ := parse("<?php "+tt.src+";", lexer.LatestVersion)
+		if got := errorList(p); !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%s: errors\n got %q\nwant %q", tt.src, got, tt.errors)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// parseType parses a type declaration with curTok on its first token and
+// leaves curTok on its last token. It handles nullable (?T), union (A|B),
+// intersection (A&B) and DNF ((A&B)|null) types.
+func (p *Parser) parseType() ast.Type {
+	if p.curTok.Kind == token.QUESTION {
+		nullable := &ast.NullableType{Token: p.curTok}
+		nullable.S = p.curTok.Span.Start
+		p.requireVersion(p.curTok, 7, 1, "nullable types")
+		p.nextToken()
+		nullable.Type = p.parseNamedType()
+		if nullable.Type == nil {
+			return nil
+		}
+		nullable.E = nullable.Type.End()
+		return nullable
+	}
+
+	first := p.parseUnionMember()
+	if first == nil || p.peekTok.Kind != token.PIPE {
+		return first
+	}
+	union := &ast.UnionType{Types: []ast.Type{first}}
+	union.S = first.Pos()
+	p.requireVersion(p.peekTok, 8, 0, "union types")
+	for p.peekTok.Kind == token.PIPE {
+		p.nextToken()
+		p.nextToken()
+		member := p.parseUnionMember()
+		if member == nil {
+			return nil
+		}
+		union.Types = append(union.Types, member)
+	}
+	union.E = p.curTok.Span.End
+	return union
+}
+
+// parseUnionMember parses a named type, an intersection, or a parenthesised
+// intersection inside a DNF type.
+func (p *Parser) parseUnionMember() ast.Type {
+	if p.curTok.Kind != token.LPAREN {
+		return p.parseIntersection()
+	}
+	p.requireVersion(p.curTok, 8, 2, "DNF types")
+	p.nextToken()
+	t := p.parseIntersection()
+	if t == nil || !p.expect(token.RPAREN) {
+		return nil
+	}
+	return t
+}
+
+func (p *Parser) parseIntersection() ast.Type {
+	first := p.parseNamedType()
+	if first == nil || !p.isIntersectionAmpersand() {
+		return first
+	}
+	intersection := &ast.IntersectionType{Types: []ast.Type{first}}
+	intersection.S = first.Pos()
+	p.requireVersion(p.peekTok, 8, 1, "intersection types")
+	for p.isIntersectionAmpersand() {
+		p.nextToken()
+		p.nextToken()
+		member := p.parseNamedType()
+		if member == nil {
+			return nil
+		}
+		intersection.Types = append(intersection.Types, member)
+	}
+	intersection.E = p.curTok.Span.End
+	return intersection
+}
+
+// isIntersectionAmpersand tells an intersection "A&B" apart from a by-ref
+// parameter "A &$b" or "A &...$b".
+func (p *Parser) isIntersectionAmpersand() bool {
+	if p.peekTok.Kind != token.AMPERSAND {
+		return false
+	}
+	after := p.peekAt(2).Kind
+	return after != token.VARIABLE && after != token.ELLIPSIS
+}
+
+// isTypeStart reports whether a type declaration can start with kind.
+func isTypeStart(kind token.Kind) bool {
+	switch kind {
+	case token.QUESTION, token.LPAREN, token.ARRAY, token.CALLABLE, token.STATIC:
+		return true
+	}
+	return isName(kind)
+}
+
+func (p *Parser) parseNamedType() ast.Type {
+	switch p.curTok.Kind {
+	case token.IDENT, token.NAME_QUALIFIED, token.NAME_FULLY_QUALIFIED, token.NAME_RELATIVE,
+		token.ARRAY, token.CALLABLE, token.STATIC:
+		return &ast.NamedType{
+			Base:  ast.Base{S: p.curTok.Span.Start, E: p.curTok.Span.End},
+			Token: p.curTok,
+			Name:  p.curTok.Lexeme,
+		}
+	}
+	p.errorAt(p.curTok.Span, "syntax error, unexpected %s, expecting type", describeToken(p.curTok))
+	return nil
+}
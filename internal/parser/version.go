@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/diagnostic"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// requireVersion records an error at tok if the targeted PHP version is older
+// than major.minor, the version that introduced feature.
+func (p *Parser) requireVersion(tok token.Token, major, minor int, feature string) {
+	if v := p.l.Version(); !v.AtLeast(major, minor) {
+		p.versionErrors = append(p.versionErrors, diagnostic.Diagnostic{
+			Severity: diagnostic.Error,
+			Message:  fmt.Sprintf("%s require PHP %d.%d or later, but the target version is %s", feature, major, minor, v),
+			Span:     tok.Span,
+		})
+	}
+}
+
+// removedIn records an error at tok if the targeted PHP version is major.minor
+// or later, the version that removed feature.
+func (p *Parser) removedIn(tok token.Token, major, minor int, feature string) {
+	if v := p.l.Version(); v.AtLeast(major, minor) {
+		p.versionErrors = append(p.versionErrors, diagnostic.Diagnostic{
+			Severity: diagnostic.Error,
+			Message:  fmt.Sprintf("%s are not supported since PHP %d.%d, and the target version is %s", feature, major, minor, v),
+			Span:     tok.Span,
+		})
+	}
+}
+
+// checkNewAccess reports a member access, call or offset on "new C()" that
+// is not wrapped in parentheses, with curTok on the token after it. PHP 8.4
+// allows it when the arguments are given; "new C->a" is never allowed.
+func (p *Parser) checkNewAccess(left ast.Expr) {
+	expr, ok := left.(*ast.NewExpr)
+	if !ok || p.prevTok.Span.End != expr.End() {
+		return
+	}
+	switch p.curTok.Kind {
+	case token.ARROW, token.NULLSAFE_ARROW, token.DOUBLE_COLON, token.LBRACKET, token.LPAREN:
+	default:
+		return
+	}
+	if expr.Arguments == nil && expr.AnonClass == nil {
+		p.unexpected(p.curTok)
+		return
+	}
+	p.requireVersion(p.curTok, 8, 4, "member accesses on new without parentheses")
+}
+
+// promoteKeyword turns the identifier in curTok back into the keyword it is
+// in later PHP versions when it is used like one, as in "match ($a) {" or
+// "fn($a) =>" under PHP 7.3, so that the construct is reported as
+// unavailable rather than as a confusing syntax error.
+func (p *Parser) promoteKeyword() {
+	var kind token.Kind
+	switch strings.ToLower(p.curTok.Lexeme) {
+	case "match":
+		kind = token.MATCH
+	case "fn":
+		kind = token.FN
+	case "enum":
+		// enum Name {, enum Name: string { or enum Name implements A {
+		if p.peekTok.Kind == token.IDENT {
+			switch p.peekAt(2).Kind {
+			case token.LBRACE, token.COLON, token.IMPLEMENTS:
+				p.curTok.Kind = token.ENUM
+			}
+		}
+		return
+	case "readonly":
+		switch p.peekTok.Kind {
+		case token.CLASS, token.ABSTRACT, token.FINAL:
+			p.curTok.Kind = token.READONLY
+		}
+		return
+	default:
+		return
+	}
+
+	n := 1
+	if kind == token.FN && p.peekTok.Kind == token.AMPERSAND {
+		n++
+	}
+	if p.peekAt(n).Kind != token.LPAREN {
+		return
+	}
+	for depth := 0; ; n++ {
+		switch p.peekAt(n).Kind {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.EOF:
+			return
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	after := p.peekAt(n + 1).Kind
+	if kind == token.MATCH && after == token.LBRACE || kind == token.FN && (after == token.DOUBLE_ARROW || after == token.COLON) {
+		p.curTok.Kind = kind
+	}
+}
+
+// promoteModifier turns the identifier readonly in curTok back into the
+// modifier it is from PHP 8.1 where a modifier may stand, as in
+// "public readonly int $a" under PHP 8.0. Right before the variable, or a
+// '|' or '&' of a type, it is the name of a class used as a type.
+func (p *Parser) promoteModifier() {
+	if p.curTok.Kind != token.IDENT || !strings.EqualFold(p.curTok.Lexeme, "readonly") {
+		return
+	}
+	switch p.peekTok.Kind {
+	case token.VARIABLE, token.PIPE, token.AMPERSAND, token.ELLIPSIS:
+		return
+	}
+	p.curTok.Kind = token.READONLY
+}
+
+// checkModifierVersion reports a class member modifier that is newer than
+// the targeted version.
+func (p *Parser) checkModifierVersion(tok token.Token, mod ast.Modifiers) {
+	if mod == ast.ModReadonly {
+		p.requireVersion(tok, 8, 1, "readonly properties")
+	}
+}
+
+// checkNumberVersion reports number literal syntax that is newer than the
+// targeted version.
+func (p *Parser) checkNumberVersion(tok token.Token) {
+	if strings.Contains(tok.Lexeme, "_") {
+		p.requireVersion(tok, 7, 4, "numeric literal separators")
+	}
+	if len(tok.Lexeme) > 1 && tok.Lexeme[0] == '0' && (tok.Lexeme[1] == 'o' || tok.Lexeme[1] == 'O') {
+		p.requireVersion(tok, 8, 1, "explicit octal literals")
+	}
+}
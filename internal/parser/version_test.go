@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+)
+
+func TestVersionKeywords(t *testing.T) {
+	v73 := lexer.Version{Major: 7, Minor: 3}
+	v80 := lexer.Version{Major: 8, Minor: 0}
+	v81 := lexer.Version{Major: 8, Minor: 1}
+	tests := []struct {
+		src     string
+		version lexer.Version
+		errors  []string
+	}{
+		// Words reserved later are names before.
+		{"const match = 1; echo match;", v73, nil},
+		{"function fn() {} fn(); $a->match(); A::fn(); A::match;", v73, nil},
+		{"class C { const fn = 1; function match() {} }", v73, nil},
+		{"function readonly() {} readonly();", v80, nil},
+		{"class readonly {} new readonly;", v80, nil},
+		{"class enum {} function enum() {} enum();", v80, nil},
+		{"class C { public readonly $a; function f(readonly $b) {} }", v80, nil},
+		{"readonly(); function readonly() {}", lexer.LatestVersion, nil},
+		// Used as keywords, they are reported as unavailable.
+		{"$f = fn() => 1;", v73, []string{"1:12 arrow functions require PHP 7.4 or later, but the target version is 7.3"}},
+		{"echo match ($a) { 1 => 2 };", v73, []string{"1:12 match expressions require PHP 8.0 or later, but the target version is 7.3"}},
+		{"enum E { case A; }", v80, []string{"1:7 enums require PHP 8.1 or later, but the target version is 8.0"}},
+		{"enum E: string implements I { case A = 'a'; }", v80, []string{"1:7 enums require PHP 8.1 or later, but the target version is 8.0"}},
+		{"class C { public readonly int $a; }", v80, []string{"1:24 readonly properties require PHP 8.1 or later, but the target version is 8.0"}},
+		{"class C { function __construct(private readonly ?int $a) {} }", v80, []string{"1:46 readonly properties require PHP 8.1 or later, but the target version is 8.0"}},
+		{"readonly class C {}", v80, []string{"1:7 readonly classes require PHP 8.2 or later, but the target version is 8.0"}},
+		{"readonly class C {}", v81, []string{"1:7 readonly classes require PHP 8.2 or later, but the target version is 8.1"}},
+		{"enum E {} class C { public readonly int $a; }", v81, nil},
+	}
+	for _, tt := range tests {
+		p, _ := parse("<?php "+tt.src, tt.version)
+		if tt.errors == nil {
+			tt.errors = []string{}
+		}
+		if got := errorList(p); !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%q under %s: errors\n got %q\nwant %q", tt.src, tt.version, got, tt.errors)
+		}
+	}
+}
+
+func TestVersionSyntax(t *testing.T) {
+	v74 := lexer.Version{Major: 7, Minor: 4}
+	v80 := lexer.Version{Major: 8, Minor: 0}
+	v83 := lexer.Version{Major: 8, Minor: 3}
+	v84 := lexer.Version{Major: 8, Minor: 4}
+	tests := []struct {
+		src     string
+		version lexer.Version
+		errors  []string
+	}{
+		// Expressions in strings are checked too.
+		{`echo "{$a?->b}";`, v74, []string{"1:16 nullsafe operators require PHP 8.0 or later, but the target version is 7.4"}},
+		{`echo "{$a->b(...)}";`, v74, []string{"1:20 first-class callables require PHP 8.1 or later, but the target version is 7.4"}},
+		{`echo "{$a[match ($b) { 1 => 2 }]}";`, v74, []string{"1:17 match expressions require PHP 8.0 or later, but the target version is 7.4"}},
+		{`echo "{$a?->b}";`, v80, nil},
+
+		// Member accesses on new without parentheses.
+		{"new Foo()->bar();", v83, []string{"1:16 member accesses on new without parentheses require PHP 8.4 or later, but the target version is 8.3"}},
+		{"new Foo()::X;", v83, []string{"1:16 member accesses on new without parentheses require PHP 8.4 or later, but the target version is 8.3"}},
+		{"new Foo()['a'];", v74, []string{"1:16 member accesses on new without parentheses require PHP 8.4 or later, but the target version is 7.4"}},
+		{"new Foo()->bar(); new Foo()::X; new class {}->m();", v84, nil},
+		{"(new Foo())->bar(); (new Foo)::X; new $a->b();", v74, nil},
+		{"new Foo->bar;", v84, []string{`1:14 syntax error, unexpected token "->"`}},
+
+		// Nested ternaries without parentheses.
+		{"$a ? $b : $c ? $d : $e;", v74, nil},
+		{"$a ? $b : $c ? $d : $e;", v80, []string{"1:20 unparenthesized nested ternaries are not supported since PHP 8.0, and the target version is 8.0"}},
+		{"$a ?: $b ? $c : $d;", v80, []string{"1:16 unparenthesized nested ternaries are not supported since PHP 8.0, and the target version is 8.0"}},
+		{"$a ? $b : $c ?: $d;", v80, []string{"1:20 unparenthesized nested ternaries are not supported since PHP 8.0, and the target version is 8.0"}},
+		{"$a ?: $b ?: $c; ($a ? $b : $c) ? $d : $e; $a ? $b : ($c ? $d : $e);", v80, nil},
+		{"$a ? $b ? $c : $d : $e;", v80, nil},
+
+		// Offsets in curly braces.
+		{"echo $a{0}, $a->b{$i + 1}, $a[0]{1};", v74, nil},
+		{"$a{0} = 1;", v80, []string{"1:9 curly brace offsets are not supported since PHP 8.0, and the target version is 8.0"}},
+		{"if ($a) {} while ($a) {} switch ($a) {}", v80, nil},
+	}
+	for _, tt := range tests {
+		p, _ := parse("<?php "+tt.src, tt.version)
+		if tt.errors == nil {
+			tt.errors = []string{}
+		}
+		if got := errorList(p); !reflect.DeepEqual(got, tt.errors) {
+			t.Errorf("%q under %s: errors\n got %q\nwant %q", tt.src, tt.version, got, tt.errors)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package pattern
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// literalVariables are the variables of a pattern that only match
+// themselves: $this and the superglobals.
+var literalVariables = map[string]bool{
+	"this": true, "GLOBALS": true, "_GET": true, "_POST": true, "_COOKIE": true,
+	"_FILES": true, "_SERVER": true, "_ENV": true, "_REQUEST": true, "_SESSION": true,
+}
+
+// isMetavariable reports whether a variable of a pattern, named without
+// the $, is a metavariable.
+func isMetavariable(name string) bool {
+	return name != "" && name != ellipsisVar && !literalVariables[name]
+}
+
+func isEllipsis(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.Variable:
+		return n.Name == ellipsisVar
+	case *ast.ExpressionStatement:
+		return isEllipsis(n.Expression)
+	case *ast.Argument:
+		return n.Name == nil && !n.Unpack && isEllipsis(n.Value)
+	case *ast.ArrayItem:
+		return n.Key == nil && !n.ByRef && !n.Unpack && isEllipsis(n.Value)
+	case *ast.ClosureUse:
+		return !n.ByRef && isEllipsis(n.Var)
+	case *ast.Param:
+		return n.Type == nil && n.Default == nil && n.Name != nil && isEllipsis(n.Name)
+	}
+	return false
+}
+
+// matcher compares a pattern with code. In literal mode it compares two
+// pieces of code, as when a metavariable is used twice, and the pattern
+// syntax has no special meaning.
+type matcher struct {
+	literal bool
+}
+
+var (
+	nodeType   = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	typeType   = reflect.TypeOf((*ast.Type)(nil)).Elem()
+	tokenType  = reflect.TypeOf(token.Token{})
+	tokensType = reflect.TypeOf([]token.Token(nil))
+	docType    = reflect.TypeOf((*phpdoc.Comment)(nil))
+	attrsType  = reflect.TypeOf([]*ast.AttributeGroup(nil))
+)
+
+// match reports whether node matches pat, returning the bindings b
+// extended with the metavariables bound. b is not modified.
+func (m *matcher) match(pat, node ast.Node, b Bindings) (Bindings, bool) {
+	if !m.literal {
+		if isEllipsis(pat) {
+			return b, true
+		}
+		if v, ok := pat.(*ast.Variable); ok && isMetavariable(v.Name) {
+			return m.bind(v.Name, node, b)
+		}
+	}
+	if node == nil || reflect.TypeOf(pat) != reflect.TypeOf(node) {
+		return nil, false
+	}
+
+	switch p := pat.(type) {
+	case *ast.Identifier:
+		n := node.(*ast.Identifier)
+		if m.literal {
+			return b, strings.EqualFold(strings.TrimPrefix(p.FullName(), `\`), strings.TrimPrefix(n.FullName(), `\`))
+		}
+		return b, nameMatches(p.Value, n.Value, n.Resolved, n.Fallback)
+	case *ast.NamedType:
+		n := node.(*ast.NamedType)
+		if m.literal {
+			return b, strings.EqualFold(n.Name, p.Name) && strings.EqualFold(n.Resolved, p.Resolved)
+		}
+		return b, nameMatches(p.Name, n.Name, n.Resolved, "")
+	case *ast.IncludeExpr:
+		// include and require differ only by their token.
+		if !strings.EqualFold(p.Token.Lexeme, node.(*ast.IncludeExpr).Token.Lexeme) {
+			return nil, false
+		}
+	}
+	return m.fields(reflect.ValueOf(pat).Elem(), reflect.ValueOf(node).Elem(), b)
+}
+
+// bind matches a metavariable with node.
+func (m *matcher) bind(name string, node ast.Node, b Bindings) (Bindings, bool) {
+	if _, ok := node.(ast.Expr); !ok {
+		return nil, false
+	}
+	if name == "_" {
+		return b, true
+	}
+	if bound, ok := b[name]; ok {
+		_, same := (&matcher{literal: true}).match(bound, node, nil)
+		return b, same
+	}
+	extended := make(Bindings, len(b)+1)
+	for k, v := range b {
+		extended[k] = v
+	}
+	extended[name] = node
+	return extended, true
+}
+
+// nameMatches reports whether the name of a pattern matches a name of the
+// code, as written, resolved or falling back to the global name.
+func nameMatches(pat, written, resolved, fallback string) bool {
+	if full, ok := strings.CutPrefix(pat, `\`); ok {
+		if resolved == "" && fallback == "" {
+			return strings.EqualFold(full, strings.TrimPrefix(written, `\`))
+		}
+		return strings.EqualFold(full, resolved) || strings.EqualFold(full, fallback)
+	}
+	return strings.EqualFold(pat, strings.TrimPrefix(written, `\`)) ||
+		resolved != "" && strings.EqualFold(pat, resolved) ||
+		fallback != "" && strings.EqualFold(pat, fallback)
+}
+
+// fields matches the fields of two nodes of the same type. Positions,
+// tokens and doc comments are not compared, nor the syntax of blocks.
+func (m *matcher) fields(pat, node reflect.Value, b Bindings) (Bindings, bool) {
+	for i := 0; i < pat.NumField(); i++ {
+		field := pat.Type().Field(i)
+		if !field.IsExported() || field.Anonymous || field.Name == "AltSyntax" {
+			continue
+		}
+		switch field.Type {
+		case tokenType, tokensType, docType:
+			continue
+		}
+		p, n := pat.Field(i), node.Field(i)
+
+		var ok bool
+		switch {
+		case field.Type.Implements(nodeType):
+			if p.IsNil() {
+				// A pattern leaving out a type matches any type.
+				ok = n.IsNil() || field.Type == typeType
+				break
+			}
+			var child ast.Node
+			if !n.IsNil() {
+				child = n.Interface().(ast.Node)
+			}
+			b, ok = m.match(p.Interface().(ast.Node), child, b)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Implements(nodeType):
+			if p.Len() == 0 && field.Type == attrsType && !m.literal {
+				// A pattern without attributes matches any.
+				continue
+			}
+			b, _, ok = m.list(nodes(p), nodes(n), b, false)
+		case field.Type.Kind() == reflect.String && field.Name == "Operator":
+			ok = strings.EqualFold(p.String(), n.String())
+		default:
+			ok = reflect.DeepEqual(p.Interface(), n.Interface())
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+// list matches a list of nodes, in which each ellipsis of the pattern
+// matches any number of nodes. With prefix, the pattern only has to match
+// the start of the list. It returns the number of nodes matched.
+func (m *matcher) list(pats, list []ast.Node, b Bindings, prefix bool) (Bindings, int, bool) {
+	if len(pats) == 0 {
+		return b, 0, prefix || len(list) == 0
+	}
+	if !m.literal && isEllipsis(pats[0]) {
+		for skip := 0; skip <= len(list); skip++ {
+			if b, n, ok := m.list(pats[1:], list[skip:], b, prefix); ok {
+				return b, skip + n, true
+			}
+		}
+		return nil, 0, false
+	}
+	if len(list) == 0 {
+		return nil, 0, false
+	}
+	// Lists can have holes, as in list($a, , $b).
+	var ok bool
+	if pats[0] == nil || list[0] == nil {
+		if pats[0] != list[0] {
+			return nil, 0, false
+		}
+	} else if b, ok = m.match(pats[0], list[0], b); !ok {
+		return nil, 0, false
+	}
+	b, n, ok := m.list(pats[1:], list[1:], b, prefix)
+	return b, n + 1, ok
+}
+
+// nodes returns the elements of a slice of nodes.
+func nodes(slice reflect.Value) []ast.Node {
+	list := make([]ast.Node, slice.Len())
+	for i := range list {
+		if elem := slice.Index(i); !elem.IsNil() {
+			list[i] = elem.Interface().(ast.Node)
+		}
+	}
+	return list
+}
+
+// stmtLists returns the lists of statements held by node, such as the
+// statements of a block.
+func stmtLists(node ast.Node) [][]ast.Node {
+	v := reflect.ValueOf(node).Elem()
+	var lists [][]ast.Node
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Type() == reflect.TypeOf([]ast.Stmt(nil)) {
+			lists = append(lists, nodes(f))
+		}
+	}
+	return lists
+}
@@ -0,0 +1,264 @@
+// Package pattern implements structural search over PHP code. A pattern is
+// written as PHP, and matches the code that has the same syntax tree, e.g.
+// mysqli_query($db, $A . $B). In a pattern:
+//
+//   - A variable, such as $db or $A, is a metavariable: it matches any
+//     expression, or any name where the syntax expects one, as in
+//     $obj->$method(). A metavariable used twice must match the same code
+//     both times. $_ matches anything and binds nothing. $this and the
+//     superglobals, such as $_GET, only match themselves; a where clause
+//     can restrict the name of other variables, as in
+//     $db->query($A) where $db matches /^\$pdo$/.
+//   - ... matches any number of arguments, array items, parameters or
+//     statements, as in foo(..., $A) or if ($A) { ... }. Elsewhere, as in
+//     return ...;, it matches any expression or none.
+//   - Names of functions and classes match case-insensitively, either as
+//     written or as resolved, so mysqli_query matches \mysqli_query() and a
+//     call made in a namespace. A name with a leading backslash only
+//     matches the fully-qualified name.
+//   - Several statements match consecutive statements of a block.
+//
+// A pattern can end with a where clause that constrains metavariables,
+// e.g. $x->query($A) where $A is not literal. Conditions are joined with
+// and; see Compile for the conditions known.
+package pattern
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// ellipsisVar is the variable each ... of a pattern is replaced with before
+// the pattern is parsed, since the parser has no node for it.
+const ellipsisVar = "__ellipsis"
+
+// Pattern is a compiled pattern.
+type Pattern struct {
+	source string
+	nodes  []ast.Node // The statements of the pattern, or the expression alone
+	where  []condition
+}
+
+// Bindings maps the metavariables of a pattern, without the $, to the code
+// they matched.
+type Bindings map[string]ast.Node
+
+// Match is a piece of code a pattern matched.
+type Match struct {
+	// Node is the node matched. For a pattern of several statements, it is
+	// the first statement matched; Span covers them all.
+	Node     ast.Node
+	Span     token.Span
+	Bindings Bindings
+}
+
+// Compile parses a pattern. The where clause ending it is a list of
+// conditions joined with and, each one of:
+//
+//	$A is [not] KIND
+//	$A [not] matches "regexp"
+//
+// where KIND is literal (a scalar, true, false, null, or an array or
+// concatenation of literals), string, number, variable, call, array or
+// closure. The regexp, written in double quotes or between slashes, is
+// matched against the name of an identifier, the $name of a variable, the
+// value of a string literal, and the code of anything else.
+func Compile(src string) (*Pattern, error) {
+	code, clause := splitWhere(src)
+	code = replaceEllipses(code)
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("pattern: empty pattern")
+	}
+	if !strings.HasSuffix(strings.TrimSpace(code), ";") {
+		// After a statement ending with }, this is an empty statement.
+		code += ";"
+	}
+
+	psr := parser.New(lexer.NewFragment(code, token.Pos{Line: 1, Col: 1}))
+	program := psr.ParseProgram()
+	if errs := psr.Errors(); len(errs) > 0 {
+		err := errs[0]
+		return nil, fmt.Errorf("pattern: %s at %d:%d", err.Message, err.Span.Start.Line, err.Span.Start.Col)
+	}
+	if len(program.Stmts) == 0 {
+		return nil, fmt.Errorf("pattern: empty pattern")
+	}
+
+	p := &Pattern{source: src}
+	if stmt, ok := program.Stmts[0].(*ast.ExpressionStatement); ok && len(program.Stmts) == 1 {
+		p.nodes = []ast.Node{stmt.Expression}
+	} else {
+		for _, stmt := range program.Stmts {
+			// The statements are matched from every statement of a block
+			// on, so leading ellipses would only repeat matches.
+			if len(p.nodes) > 0 || !isEllipsis(stmt) {
+				p.nodes = append(p.nodes, stmt)
+			}
+		}
+	}
+	if len(p.nodes) == 0 || len(p.nodes) == 1 && isEllipsis(p.nodes[0]) {
+		return nil, fmt.Errorf("pattern: the pattern matches anything")
+	}
+
+	where, err := parseWhere(clause)
+	if err != nil {
+		return nil, err
+	}
+	metavars := p.metavariables()
+	for _, cond := range where {
+		if !metavars[cond.metavar] {
+			return nil, fmt.Errorf("pattern: where: $%s is not a metavariable of the pattern", cond.metavar)
+		}
+	}
+	p.where = where
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the pattern does not compile.
+// It is meant for patterns declared by rules.
+func MustCompile(src string) *Pattern {
+	p, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source of the pattern.
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// FindAll returns the matches of the pattern in the tree under root, in
+// source order. Matches can be nested, e.g. $A . $B matches both the outer
+// and the inner concatenation of 'a' . $b . 'c'.
+func (p *Pattern) FindAll(root ast.Node) []Match {
+	var matches []Match
+	ast.Inspect(root, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		if len(p.nodes) == 1 {
+			if m, ok := p.Match(node); ok {
+				matches = append(matches, m)
+			}
+			return ast.Continue
+		}
+		for _, list := range stmtLists(node) {
+			for i := range list {
+				if b, n, ok := (&matcher{}).list(p.nodes, list[i:], nil, true); ok && n > 0 && p.satisfied(b) {
+					matches = append(matches, Match{
+						Node:     list[i],
+						Span:     token.Span{Start: list[i].Pos(), End: list[i+n-1].End()},
+						Bindings: b,
+					})
+				}
+			}
+		}
+		return ast.Continue
+	})
+	return matches
+}
+
+// Match reports whether node itself matches the pattern, which must not be
+// a pattern of several statements.
+func (p *Pattern) Match(node ast.Node) (Match, bool) {
+	if len(p.nodes) != 1 {
+		return Match{}, false
+	}
+	b, ok := (&matcher{}).match(p.nodes[0], node, nil)
+	if !ok || !p.satisfied(b) {
+		return Match{}, false
+	}
+	return Match{Node: node, Span: token.Span{Start: node.Pos(), End: node.End()}, Bindings: b}, true
+}
+
+func (p *Pattern) satisfied(b Bindings) bool {
+	for _, cond := range p.where {
+		if !cond.holds(b[cond.metavar]) {
+			return false
+		}
+	}
+	return true
+}
+
+// metavariables returns the names of the metavariables of the pattern.
+func (p *Pattern) metavariables() map[string]bool {
+	names := map[string]bool{}
+	for _, node := range p.nodes {
+		ast.Inspect(node, func(node ast.Node, ancestors []ast.Node) ast.Action {
+			if v, ok := node.(*ast.Variable); ok && isMetavariable(v.Name) {
+				names[v.Name] = true
+			}
+			return ast.Continue
+		})
+	}
+	return names
+}
+
+// splitWhere cuts the where clause off a pattern. The clause starts at the
+// first where followed by a variable, which is never valid PHP.
+func splitWhere(src string) (code, clause string) {
+	tokens := lex(src)
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].Kind == token.IDENT && tokens[i].Lexeme == "where" && tokens[i+1].Kind == token.VARIABLE {
+			offset := tokens[i].Span.Start.Offset
+			return src[:offset], src[offset+len("where"):]
+		}
+	}
+	return src, ""
+}
+
+// replaceEllipses replaces the ... of a pattern with ellipsisVar, adding a
+// semicolon when it stands for statements. A ... followed by an expression
+// is argument unpacking and is kept.
+func replaceEllipses(src string) string {
+	tokens := lex(src)
+	var out strings.Builder
+	last := 0
+	for i, tok := range tokens {
+		if tok.Kind != token.ELLIPSIS {
+			continue
+		}
+		prev, next := token.Kind(""), token.Kind(token.EOF)
+		if i > 0 {
+			prev = tokens[i-1].Kind
+		}
+		if i+1 < len(tokens) {
+			next = tokens[i+1].Kind
+		}
+		var replacement string
+		switch {
+		case prev == "" || prev == token.SEMICOLON || prev == token.LBRACE || prev == token.RBRACE || prev == token.COLON:
+			replacement = "$" + ellipsisVar
+			if next != token.SEMICOLON {
+				replacement += ";"
+			}
+		case next == token.RPAREN || next == token.COMMA || next == token.RBRACKET || next == token.SEMICOLON || next == token.EOF:
+			replacement = "$" + ellipsisVar
+		default:
+			continue
+		}
+		out.WriteString(src[last:tok.Span.Start.Offset])
+		out.WriteString(replacement)
+		last = tok.Span.End.Offset
+	}
+	out.WriteString(src[last:])
+	return out.String()
+}
+
+// lex returns the tokens of a pattern, without comments and the final EOF.
+func lex(src string) []token.Token {
+	l := lexer.NewFragment(src, token.Pos{Line: 1, Col: 1})
+	var tokens []token.Token
+	for tok := l.NextToken(); tok.Kind != token.EOF; tok = l.NextToken() {
+		switch tok.Kind {
+		case token.LINE_COMMENT, token.BLOCK_COMMENT, token.DOC_COMMENT:
+		default:
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
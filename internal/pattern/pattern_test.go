@@ -0,0 +1,142 @@
+package pattern
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/resolver"
+)
+
+// find returns the code each match of pattern in src covers, followed by
+// its bindings in name order.
+func find(t *testing.T, pattern, src string) []string {
+	t.Helper()
+	p, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("%s: %v", pattern, err)
+	}
+	src = "<?php " + src
+	program := parser.New(lexer.New(src)).ParseProgram()
+	resolver.Resolve(program)
+	found := []string{}
+	for _, m := range p.FindAll(program) {
+		s := src[m.Span.Start.Offset:m.Span.End.Offset]
+		names := make([]string, 0, len(m.Bindings))
+		for name := range m.Bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s += fmt.Sprintf(" $%s=%s", name, ast.Print(m.Bindings[name]))
+		}
+		found = append(found, s)
+	}
+	return found
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		pattern, src string
+		want         []string
+	}{
+		{"foo()", "foo(); bar(); foo(1);", []string{"foo()"}},
+		// Metavariables bind what they match and must match the same code
+		// each time.
+		{"mysqli_query($db, $Q)", "mysqli_query($conn, 'SELECT 1');", []string{"mysqli_query($conn, 'SELECT 1') $Q='SELECT 1' $db=$conn"}},
+		{"$A == $A", "$a == $a; $a == $b; f() == f();", []string{"$a == $a $A=$a", "f() == f() $A=f()"}},
+		{"$_ + $_", "1 + 2;", []string{"1 + 2"}},
+		{"$obj->$method()", "$a->run(); $a->$m(); f();", []string{"$a->run() $method=run $obj=$a", "$a->$m() $method=$m $obj=$a"}},
+		{"$this->a", "$this->a; $that->a;", []string{"$this->a"}},
+		// Matches nest.
+		{"$A . $B", "'a' . $b . 'c';", []string{"'a' . $b . 'c' $A='a' . $b $B='c'", "'a' . $b $A='a' $B=$b"}},
+		// Names match as written or resolved, case-insensitively.
+		{"strlen($A)", "namespace N; STRLEN($a); \\strlen($b);", []string{"STRLEN($a) $A=$a", "\\strlen($b) $A=$b"}},
+		{"\\N\\f()", "namespace N; f(); \\f();", []string{"f()"}},
+		{"new Foo()", "use A\\Foo; new Foo(); new Bar();", []string{"new Foo()"}},
+	}
+	for _, tt := range tests {
+		if got := find(t, tt.pattern, tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s in %s:\n got %q\nwant %q", tt.pattern, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	tests := []struct {
+		pattern, src string
+		want         []string
+	}{
+		{"foo(...)", "foo(); foo(1, 2); bar(1);", []string{"foo()", "foo(1, 2)"}},
+		{"foo(..., $A)", "foo(1); foo(1, 2, 3); foo();", []string{"foo(1) $A=1", "foo(1, 2, 3) $A=3"}},
+		{"foo(...$args)", "foo(...$a); foo($a);", []string{"foo(...$a) $args=$a"}},
+		{"[..., 'x' => $V, ...]", "$a = [1, 'x' => 2, 3]; $b = ['y' => 2];", []string{"[1, 'x' => 2, 3] $V=2"}},
+		{"function f(...) { ... }", "function f($a, $b) { return 1; } function g() {}", []string{"function f($a, $b) { return 1; }"}},
+		{"if ($A) { ... }", "if ($x) { a(); b(); } if ($y) {}", []string{"if ($x) { a(); b(); } $A=$x", "if ($y) {} $A=$y"}},
+		{"return ...;", "function f() { return 1; } function g() { return; }", []string{"return 1;", "return;"}},
+		// Statements match consecutive statements.
+		{"$A = fopen(...); ...; fclose($A);", "$f = fopen('x'); fwrite($f, 'y'); fclose($f); $g = fopen('z'); fclose($f);",
+			[]string{"$f = fopen('x'); fwrite($f, 'y'); fclose($f); $A=$f"}},
+		// Comments in a pattern are ignored, so ... after one still stands for
+		// statements.
+		{"if ($A) { // anything\n ... }", "if ($x) { a(); }", []string{"if ($x) { a(); } $A=$x"}},
+		{"foo(/* first */ $A, /* rest */ ...)", "foo(1, 2);", []string{"foo(1, 2) $A=1"}},
+	}
+	for _, tt := range tests {
+		if got := find(t, tt.pattern, tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s in %s:\n got %q\nwant %q", tt.pattern, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		pattern, src string
+		want         []string
+	}{
+		{"query($A) where $A is not literal", "query('x'); query('a' . 'b'); query($sql); query('a' . $b);",
+			[]string{"query($sql) $A=$sql", "query('a' . $b) $A='a' . $b"}},
+		{"f($A) where $A is string", "f('a'); f(\"b $c\"); f(1);", []string{"f('a') $A='a'", `f("b $c") $A="b {$c}"`}},
+		{"f($A) where $A is number", "f(1); f(1.5); f('1');", []string{"f(1) $A=1", "f(1.5) $A=1.5"}},
+		{"f($A) where $A is call", "f(g()); f($a->b()); f(new C); f($a);", []string{"f(g()) $A=g()", "f($a->b()) $A=$a->b()", "f(new C) $A=new C()"}},
+		{"f($A) where $A is closure", "f(fn() => 1); f(function () {}); f('g');", []string{"f(fn() => 1) $A=fn() => 1", "f(function () {}) $A=function () {}"}},
+		{"$db->query($A) where $db matches /^\\$pdo$/", "$pdo->query(1); $mysql->query(2);", []string{"$pdo->query(1) $A=1 $db=$pdo"}},
+		{"$F($A) where $F matches \"^(md5|sha1)$\" and $A is variable", "md5($p); sha1('x'); crc32($p);", []string{"md5($p) $A=$p $F=md5"}},
+		{"f($A) where $A not matches /^a/", "f('abc'); f('xyz');", []string{"f('xyz') $A='xyz'"}},
+	}
+	for _, tt := range tests {
+		if got := find(t, tt.pattern, tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s in %s:\n got %q\nwant %q", tt.pattern, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{"", "pattern: empty pattern"},
+		{"...", "pattern: the pattern matches anything"},
+		{"foo(", "pattern: syntax error"},
+		{"f($A) where $B is string", "pattern: where: $B is not a metavariable of the pattern"},
+		{"f($A) where $A is big", `pattern: where: unknown kind "big"`},
+		{"f($A) where $A is string or $A is number", `pattern: where: expected and, found "or"`},
+		{"f($A) where $A equals 1", "pattern: where: expected is or matches after $A"},
+		{"f($A) where $A matches /(/", "pattern: where: error parsing regexp"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.pattern)
+		if err == nil {
+			t.Errorf("%q: compiled, want an error", tt.pattern)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%q: got error %q, want %q", tt.pattern, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package pattern
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+)
+
+// condition is a condition of a where clause on the code a metavariable
+// matched.
+type condition struct {
+	metavar string
+	negated bool
+	test    func(node ast.Node) bool
+}
+
+func (c condition) holds(node ast.Node) bool {
+	return node != nil && c.test(node) != c.negated
+}
+
+// kinds are the kinds of code a where clause can test for with is.
+var kinds = map[string]func(node ast.Node) bool{
+	"literal": isLiteral,
+	"string": func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.StringLiteral, *ast.InterpolatedString:
+			return true
+		}
+		return false
+	},
+	"number": func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral:
+			return true
+		}
+		return false
+	},
+	"variable": func(node ast.Node) bool {
+		_, ok := node.(*ast.Variable)
+		return ok
+	},
+	"call": func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.CallExpr, *ast.MethodCallExpr, *ast.StaticCallExpr, *ast.NewExpr:
+			return true
+		}
+		return false
+	},
+	"array": func(node ast.Node) bool {
+		_, ok := node.(*ast.ArrayLiteral)
+		return ok
+	},
+	"closure": func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.ClosureExpr, *ast.ArrowFuncExpr:
+			return true
+		}
+		return false
+	},
+}
+
+// isLiteral reports whether node is a value written in the code: a scalar,
+// true, false or null, or an array, concatenation or negation of literals.
+func isLiteral(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.StringLiteral, *ast.IntegerLiteral, *ast.FloatLiteral:
+		return true
+	case *ast.InterpolatedString:
+		for _, part := range n.Parts {
+			if _, ok := part.(*ast.StringLiteral); !ok {
+				return false
+			}
+		}
+		return true
+	case *ast.Identifier:
+		switch strings.ToLower(strings.TrimPrefix(n.Value, `\`)) {
+		case "true", "false", "null":
+			return true
+		}
+	case *ast.ArrayLiteral:
+		for _, item := range n.Items {
+			if item != nil && (item.Key != nil && !isLiteral(item.Key) || !isLiteral(item.Value)) {
+				return false
+			}
+		}
+		return true
+	case *ast.BinaryExpr:
+		return n.Operator == "." && isLiteral(n.Left) && isLiteral(n.Right)
+	case *ast.PrefixExpr:
+		return (n.Operator == "-" || n.Operator == "+") && isLiteral(n.Right)
+	}
+	return false
+}
+
+// text returns what a regexp of a where clause is matched against.
+func text(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		return strings.TrimPrefix(n.FullName(), `\`)
+	case *ast.Variable:
+		return "$" + n.Name
+	case *ast.StringLiteral:
+		return n.Value
+	}
+	return ast.Print(node)
+}
+
+// parseWhere parses the conditions of a where clause, given without the
+// where keyword.
+func parseWhere(clause string) ([]condition, error) {
+	if strings.TrimSpace(clause) == "" {
+		return nil, nil
+	}
+	words, err := splitWords(clause)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []condition
+	for len(words) > 0 {
+		if len(conds) > 0 {
+			if words[0] != "and" {
+				return nil, fmt.Errorf("pattern: where: expected and, found %q", words[0])
+			}
+			words = words[1:]
+		}
+		if len(words) == 0 || !strings.HasPrefix(words[0], "$") || !isMetavariable(words[0][1:]) {
+			return nil, fmt.Errorf("pattern: where: expected a metavariable, found %q", strings.Join(words, " "))
+		}
+		cond := condition{metavar: words[0][1:]}
+		words = words[1:]
+
+		switch {
+		case len(words) >= 2 && words[0] == "is":
+			words = words[1:]
+			if words[0] == "not" && len(words) > 1 {
+				cond.negated = true
+				words = words[1:]
+			}
+			test, ok := kinds[words[0]]
+			if !ok {
+				return nil, fmt.Errorf("pattern: where: unknown kind %q", words[0])
+			}
+			cond.test = test
+			words = words[1:]
+		case len(words) >= 2 && (words[0] == "matches" || words[0] == "not" && words[1] == "matches"):
+			if words[0] == "not" {
+				cond.negated = true
+				words = words[1:]
+			}
+			if len(words) < 2 {
+				return nil, fmt.Errorf("pattern: where: expected a regexp after matches")
+			}
+			re, err := regexp.Compile(words[1])
+			if err != nil {
+				return nil, fmt.Errorf("pattern: where: %v", err)
+			}
+			cond.test = func(node ast.Node) bool { return re.MatchString(text(node)) }
+			words = words[2:]
+		default:
+			return nil, fmt.Errorf("pattern: where: expected is or matches after $%s", cond.metavar)
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// splitWords splits a where clause into words, metavariables and regexps.
+// A regexp is written as a Go string in double quotes or between slashes,
+// with \/ for a slash.
+func splitWords(clause string) ([]string, error) {
+	var words []string
+	for i := 0; i < len(clause); {
+		switch c := clause[i]; {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(clause) && clause[end] != '"' {
+				if clause[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(clause) {
+				return nil, fmt.Errorf("pattern: where: unterminated string")
+			}
+			word, err := strconv.Unquote(clause[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("pattern: where: bad string %s", clause[i:end+1])
+			}
+			words = append(words, word)
+			i = end + 1
+		case c == '/':
+			var word strings.Builder
+			end := i + 1
+			for ; end < len(clause) && clause[end] != '/'; end++ {
+				if clause[end] == '\\' && end+1 < len(clause) && clause[end+1] == '/' {
+					end++
+				}
+				word.WriteByte(clause[end])
+			}
+			if end >= len(clause) {
+				return nil, fmt.Errorf("pattern: where: unterminated regexp")
+			}
+			words = append(words, word.String())
+			i = end + 1
+		default:
+			end := i + 1
+			for end < len(clause) && !unicode.IsSpace(rune(clause[end])) {
+				end++
+			}
+			words = append(words, clause[i:end])
+			i = end
+		}
+	}
+	return words, nil
+}
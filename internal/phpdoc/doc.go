@@ -0,0 +1,313 @@
+// Package phpdoc parses PHPDoc comments ("/** ... */") into a summary, a
+// description and structured tags with PHPDoc types.
+package phpdoc
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// Comment is a parsed doc comment.
+type Comment struct {
+	Text        string     // The comment as written, including "/**" and "*/"
+	Span        token.Span // Where the comment is in the file
+	Summary     string     // The first paragraph of the text before the tags
+	Description string     // The rest of the text before the tags
+	Tags        []*Tag
+}
+
+// Tag is a "@name ..." line of a doc comment. Which of the fields are set
+// depends on the tag; the rest of the line always ends up in Description.
+type Tag struct {
+	Name string     // The tag name without the '@', e.g. "param" or "psalm-return"
+	Span token.Span // The tag's text, from the '@' to the end of its last line
+
+	// Type is the type of a @param, @return, @var, @throws, @property,
+	// @mixin, @extends, @implements or @use tag. It is nil if the tag has
+	// no type or the type is invalid, see Err.
+	Type Type
+	// Var is the "$name" of a @param, @var or @property tag, if given.
+	Var      string
+	ByRef    bool // @param Type &$name
+	Variadic bool // @param Type ...$name
+	// Template is the name of the type parameter declared by a @template
+	// tag, and Bound its "of" or "as" bound, if any.
+	Template string
+	Bound    Type
+
+	Description string
+	// Err is set when the tag's type could not be parsed.
+	Err error
+}
+
+// vendorPrefixes are the tag prefixes of static analysers. Their tags take
+// precedence over the plain ones, which are often kept less precise for
+// older tools.
+var vendorPrefixes = []string{"phpstan-", "psalm-", "phan-"}
+
+// BaseName returns the tag name without a vendor prefix, so that
+// @psalm-param and @phpstan-param are both "param".
+func (t *Tag) BaseName() string {
+	for _, prefix := range vendorPrefixes {
+		if name, ok := strings.CutPrefix(t.Name, prefix); ok {
+			return name
+		}
+	}
+	return t.Name
+}
+
+// Vendor reports whether the tag has a vendor prefix such as "psalm-".
+func (t *Tag) Vendor() bool {
+	return t.BaseName() != t.Name
+}
+
+// TagsNamed returns the tags whose base name is name, in source order.
+func (c *Comment) TagsNamed(name string) []*Tag {
+	var tags []*Tag
+	for _, tag := range c.Tags {
+		if tag.BaseName() == name {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Tag returns the tag whose base name is name, preferring a vendor-prefixed
+// one, or nil if there is none.
+func (c *Comment) Tag(name string) *Tag {
+	return preferVendor(c.TagsNamed(name))
+}
+
+// Param returns the @param tag for the parameter "$name", preferring a
+// vendor-prefixed one, or nil if there is none.
+func (c *Comment) Param(name string) *Tag {
+	var tags []*Tag
+	for _, tag := range c.TagsNamed("param") {
+		if tag.Var == name {
+			tags = append(tags, tag)
+		}
+	}
+	return preferVendor(tags)
+}
+
+// Return returns the @return tag, or nil if there is none.
+func (c *Comment) Return() *Tag {
+	return c.Tag("return")
+}
+
+// Deprecated reports whether the comment has a @deprecated tag.
+func (c *Comment) Deprecated() bool {
+	return c.Tag("deprecated") != nil
+}
+
+func preferVendor(tags []*Tag) *Tag {
+	for _, tag := range tags {
+		if tag.Vendor() {
+			return tag
+		}
+	}
+	if len(tags) > 0 {
+		return tags[0]
+	}
+	return nil
+}
+
+// line is a line of a comment with its decoration stripped.
+type line struct {
+	text   string
+	offset int // Offset of text within the comment
+}
+
+// Parse parses a doc comment that appears at span in a file.
+func Parse(text string, span token.Span) *Comment {
+	c := &Comment{Text: text, Span: span, Tags: []*Tag{}}
+
+	var intro []string
+	var tag []line
+	flush := func() {
+		if tag != nil {
+			c.Tags = append(c.Tags, c.parseTag(tag))
+			tag = nil
+		}
+	}
+	for _, l := range splitLines(text) {
+		switch {
+		case strings.HasPrefix(l.text, "@") && len(l.text) > 1 && isTagChar(l.text[1]):
+			flush()
+			tag = []line{l}
+		case tag != nil:
+			tag = append(tag, l)
+		default:
+			intro = append(intro, l.text)
+		}
+	}
+	flush()
+
+	summary, description := splitParagraph(strings.TrimSpace(strings.Join(intro, "\n")))
+	c.Summary = strings.Join(strings.Fields(summary), " ")
+	c.Description = description
+	return c
+}
+
+// splitLines splits a comment into lines, dropping the "/**" and "*/"
+// delimiters, the leading '*' of each line and the whitespace around it.
+func splitLines(text string) []line {
+	body := strings.TrimPrefix(text, "/**")
+	body = strings.TrimSuffix(body, "*/")
+	offset := len(text) - len(strings.TrimPrefix(text, "/**"))
+
+	var lines []line
+	for _, raw := range strings.SplitAfter(body, "\n") {
+		start := offset
+		offset += len(raw)
+
+		s := strings.TrimRight(raw, " \t\r\n")
+		trimmed := strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(trimmed, "*") {
+			trimmed = trimmed[1:]
+		}
+		if strings.HasPrefix(trimmed, " ") || strings.HasPrefix(trimmed, "\t") {
+			trimmed = trimmed[1:]
+		}
+		lines = append(lines, line{text: trimmed, offset: start + len(s) - len(trimmed)})
+	}
+	return lines
+}
+
+// splitParagraph splits text after its first paragraph.
+func splitParagraph(text string) (string, string) {
+	first, rest, _ := strings.Cut(text, "\n\n")
+	return first, strings.TrimSpace(rest)
+}
+
+// parseTag parses a tag from its lines, the first of which starts with '@'.
+func (c *Comment) parseTag(lines []line) *Tag {
+	// Trailing blank lines separate the tag from the next one.
+	for len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1].text) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	first, last := lines[0], lines[len(lines)-1]
+	tag := &Tag{Span: token.Span{
+		Start: c.posAt(first.offset),
+		End:   c.posAt(last.offset + len(last.text)),
+	}}
+
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	body := strings.Join(texts, "\n")[1:]
+	n := 0
+	for n < len(body) && isTagChar(body[n]) {
+		n++
+	}
+	tag.Name, body = body[:n], strings.TrimSpace(body[n:])
+
+	switch tag.BaseName() {
+	case "param":
+		body = tag.parseTypeIfPresent(body)
+		body = tag.parseParamVar(body)
+	case "var", "property", "property-read", "property-write":
+		body = tag.parseTypeIfPresent(body)
+		body = tag.parseVar(body)
+	case "return", "throws", "mixin", "extends", "implements", "use",
+		"template-extends", "template-implements", "template-use":
+		body = tag.parseTypeIfPresent(body)
+	case "template", "template-covariant", "template-contravariant":
+		body = tag.parseTemplate(body)
+	}
+	tag.Description = strings.TrimSpace(body)
+	return tag
+}
+
+// parseTypeIfPresent parses the type at the start of body, unless body
+// starts with a variable, and returns the rest of it.
+func (t *Tag) parseTypeIfPresent(body string) string {
+	if body == "" || strings.HasPrefix(body, "$") || strings.HasPrefix(body, "&$") || strings.HasPrefix(body, "...$") {
+		return body
+	}
+	typ, n, err := parseTypePrefix(body)
+	if err != nil {
+		t.Err = err
+		return body
+	}
+	t.Type = typ
+	return body[n:]
+}
+
+// parseParamVar parses the "&...$name" of a @param tag at the start of body.
+func (t *Tag) parseParamVar(body string) string {
+	body = strings.TrimSpace(body)
+	if rest, ok := strings.CutPrefix(body, "&"); ok && isParamVar(rest) {
+		t.ByRef, body = true, rest
+	}
+	if rest, ok := strings.CutPrefix(body, "..."); ok && isParamVar(rest) {
+		t.Variadic, body = true, rest
+	}
+	return t.parseVar(body)
+}
+
+// isParamVar reports whether s starts with the variable of a @param tag,
+// possibly after a "...".
+func isParamVar(s string) bool {
+	return strings.HasPrefix(s, "$") || strings.HasPrefix(s, "...$")
+}
+
+// parseVar parses a "$name" at the start of body.
+func (t *Tag) parseVar(body string) string {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "$") {
+		return body
+	}
+	n := 1
+	for n < len(body) && (isIdentChar(body[n]) && body[n] != '-' && body[n] != '\\') {
+		n++
+	}
+	if n == 1 {
+		return body
+	}
+	t.Var = body[:n]
+	return body[n:]
+}
+
+// parseTemplate parses "T of Bound" or "T as Bound" at the start of body.
+func (t *Tag) parseTemplate(body string) string {
+	fields := strings.Fields(body)
+	if len(fields) == 0 {
+		return body
+	}
+	t.Template = fields[0]
+	body = strings.TrimSpace(strings.TrimPrefix(body, fields[0]))
+	if len(fields) < 3 || fields[1] != "of" && fields[1] != "as" {
+		return body
+	}
+	bound, n, err := parseTypePrefix(strings.TrimSpace(body[2:]))
+	if err != nil {
+		t.Err = err
+		return body
+	}
+	t.Bound = bound
+	return strings.TrimSpace(body[2:])[n:]
+}
+
+// posAt returns the position of the byte at offset i of the comment.
+func (c *Comment) posAt(i int) token.Pos {
+	pos := c.Span.Start
+	text := c.Text[:min(i, len(c.Text))]
+	if nl := strings.LastIndexByte(text, '\n'); nl >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Col = 1 + utf8.RuneCountInString(text[nl+1:])
+	} else {
+		pos.Col += utf8.RuneCountInString(text)
+	}
+	pos.Offset += len(text)
+	return pos
+}
+
+// isTagChar reports whether c may be part of a tag name such as
+// "psalm-param" or "ORM\Column".
+func isTagChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-' || c == ':'
+}
@@ -0,0 +1,123 @@
+package phpdoc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// describe prints the fields of a tag that are set.
+func describe(tag *Tag) string {
+	s := "@" + tag.Name
+	if tag.Type != nil {
+		s += " type=" + tag.Type.String()
+	}
+	if tag.ByRef {
+		s += " byref"
+	}
+	if tag.Variadic {
+		s += " variadic"
+	}
+	if tag.Var != "" {
+		s += " var=" + tag.Var
+	}
+	if tag.Template != "" {
+		s += " template=" + tag.Template
+	}
+	if tag.Bound != nil {
+		s += " bound=" + tag.Bound.String()
+	}
+	if tag.Description != "" {
+		s += fmt.Sprintf(" desc=%q", tag.Description)
+	}
+	if tag.Err != nil {
+		s += " err=" + tag.Err.Error()
+	}
+	return s
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		tag, want string
+	}{
+		{"@param int $a", "@param type=int var=$a"},
+		{"@param int &$total the total", `@param type=int byref var=$total desc="the total"`},
+		{"@param int ...$items", "@param type=int variadic var=$items"},
+		{"@param string &...$refs", "@param type=string byref variadic var=$refs"},
+		{"@param array<int, string> $map a map", `@param type=array<int, string> var=$map desc="a map"`},
+		{"@param $untyped", "@param var=$untyped"},
+		{"@param &$ref", "@param byref var=$ref"},
+		{"@psalm-param list<int> $a", "@psalm-param type=list<int> var=$a"},
+		{"@return int|null", "@return type=int|null"},
+		{"@return static the same", `@return type=static desc="the same"`},
+		{"@var Foo $foo", "@var type=Foo var=$foo"},
+		{"@var Foo", "@var type=Foo"},
+		{"@var array{a: int} $x some text", `@var type=array{a: int} var=$x desc="some text"`},
+		{"@throws RuntimeException|LogicException when bad", `@throws type=RuntimeException|LogicException desc="when bad"`},
+		{"@template T of object", "@template template=T bound=object"},
+		{"@deprecated use other", `@deprecated desc="use other"`},
+		// A bad type leaves the text in the description.
+		{"@param array<int $bad", `@param desc="array<int $bad" err=unexpected "$bad", expecting ">" at offset 10`},
+	}
+	for _, tt := range tests {
+		c := Parse("/** "+tt.tag+" */", token.Span{})
+		if len(c.Tags) != 1 {
+			t.Errorf("%s: got %d tags", tt.tag, len(c.Tags))
+			continue
+		}
+		if got := describe(c.Tags[0]); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	text := `/**
+     * Sums numbers.
+     *
+     * Longer text
+     * here.
+     *
+     * @param int $a the first
+     *   spanning lines
+     * @param int $b
+     * @phpstan-param positive-int $b
+     * @return int
+     */`
+	c := Parse(text, token.Span{Start: token.Pos{Line: 3, Col: 5, Offset: 20}})
+	if c.Summary != "Sums numbers." || c.Description != "Longer text\nhere." {
+		t.Errorf("got summary %q and description %q", c.Summary, c.Description)
+	}
+
+	var tags []string
+	for _, tag := range c.Tags {
+		tags = append(tags, describe(tag))
+	}
+	want := []string{
+		`@param type=int var=$a desc="the first\n  spanning lines"`,
+		"@param type=int var=$b",
+		"@phpstan-param type=positive-int var=$b",
+		"@return type=int",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags:\n got %q\nwant %q", tags, want)
+	}
+
+	// Tag spans run from the '@' to the end of the tag's last line.
+	span := c.Tags[0].Span
+	if span.Start != (token.Pos{Line: 9, Col: 8, Offset: 98}) || span.End != (token.Pos{Line: 10, Col: 24, Offset: 145}) {
+		t.Errorf("span of @param $a: got %v-%v", span.Start, span.End)
+	}
+
+	if tag := c.Param("$b"); tag == nil || tag.Name != "phpstan-param" {
+		t.Errorf("Param($b): got %v, want the @phpstan-param tag", tag)
+	}
+	if tag := c.Return(); tag == nil || tag.Type.String() != "int" {
+		t.Errorf("Return(): got %v", tag)
+	}
+	if c.Param("$c") != nil || c.Deprecated() {
+		t.Error("found a tag that is not there")
+	}
+}
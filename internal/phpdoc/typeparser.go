@@ -0,0 +1,498 @@
+package phpdoc
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseType parses a complete type expression such as
+// "array<string, list<int>>|null".
+func ParseType(input string) (Type, error) {
+	p := &typeParser{input: input}
+	t := p.parseType()
+	if p.err == nil && p.peek().kind != itemEOF {
+		p.fail(p.peek())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return t, nil
+}
+
+// parseTypePrefix parses the type expression at the start of input and
+// returns it together with the number of bytes it spans, leaving whatever
+// follows it, such as a variable name and a description, unparsed.
+func parseTypePrefix(input string) (Type, int, error) {
+	p := &typeParser{input: input}
+	t := p.parseType()
+	if p.err != nil {
+		return nil, 0, p.err
+	}
+	return t, p.end, nil
+}
+
+type itemKind int
+
+const (
+	itemEOF itemKind = iota
+	itemIdent
+	itemVariable
+	itemNumber
+	itemString
+	itemPunct
+	itemIllegal
+)
+
+// item is a token of a type expression.
+type item struct {
+	kind       itemKind
+	text       string
+	start, end int  // Byte offsets in the input
+	spaced     bool // Whether whitespace precedes the item
+}
+
+// typeParser is a recursive descent parser for type expressions. Items are
+// scanned on demand rather than up front, because a type is usually followed
+// by free text that is not made of type tokens.
+type typeParser struct {
+	input string
+	pos   int   // Offset after the last consumed item
+	end   int   // End of the last consumed item, not counting whitespace
+	err   error // The first syntax error
+}
+
+// scan returns the item at offset i.
+func (p *typeParser) scan(i int) item {
+	start := i
+	for i < len(p.input) && isTypeSpace(p.input[i]) {
+		i++
+	}
+	it := item{start: i, spaced: i > start}
+	if i >= len(p.input) {
+		it.kind, it.end = itemEOF, i
+		return it
+	}
+
+	c := p.input[i]
+	j := i + 1
+	switch {
+	case isIdentStart(c):
+		for j < len(p.input) && isIdentChar(p.input[j]) {
+			j++
+		}
+		it.kind = itemIdent
+	case c == '$' && j < len(p.input) && isIdentStart(p.input[j]) && p.input[j] != '\\':
+		for j < len(p.input) && isIdentChar(p.input[j]) && p.input[j] != '-' && p.input[j] != '\\' {
+			j++
+		}
+		it.kind = itemVariable
+	case isDigit(c) || (c == '-' || c == '.') && j < len(p.input) && isDigit(p.input[j]):
+		for j < len(p.input) && (isDigit(p.input[j]) || isLetter(p.input[j]) || p.input[j] == '_' || p.input[j] == '.') {
+			j++
+		}
+		it.kind = itemNumber
+	case c == '\'' || c == '"':
+		it.kind = itemIllegal
+		for ; j < len(p.input); j++ {
+			if p.input[j] == '\\' {
+				j++
+			} else if p.input[j] == c {
+				j++
+				it.kind = itemString
+				break
+			}
+		}
+		j = min(j, len(p.input))
+	case strings.HasPrefix(p.input[i:], "..."):
+		j = i + 3
+		it.kind = itemPunct
+	case strings.HasPrefix(p.input[i:], "::"):
+		j = i + 2
+		it.kind = itemPunct
+	case strings.ContainsRune("|&?()<>{}[],:=*", rune(c)):
+		it.kind = itemPunct
+	default:
+		_, width := utf8.DecodeRuneInString(p.input[i:])
+		j = i + width
+		it.kind = itemIllegal
+	}
+	it.text, it.end = p.input[i:j], j
+	return it
+}
+
+func (p *typeParser) peek() item {
+	return p.scan(p.pos)
+}
+
+// peekAfter returns the item following it.
+func (p *typeParser) peekAfter(it item) item {
+	return p.scan(it.end)
+}
+
+func (p *typeParser) next() item {
+	it := p.peek()
+	p.pos, p.end = it.end, it.end
+	return it
+}
+
+// accept consumes the next item if it is the punctuation text.
+func (p *typeParser) accept(text string) bool {
+	if it := p.peek(); it.kind == itemPunct && it.text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// acceptAdjacent is accept for punctuation that must directly follow the
+// previous item, such as the '<' of a generic type. With whitespace in
+// between, "array <" is the type array followed by free text.
+func (p *typeParser) acceptAdjacent(text string) bool {
+	if it := p.peek(); it.spaced {
+		return false
+	}
+	return p.accept(text)
+}
+
+func (p *typeParser) expect(text string) bool {
+	if p.accept(text) {
+		return true
+	}
+	p.fail(p.peek(), fmt.Sprintf("%q", text))
+	return false
+}
+
+// fail records a syntax error at it, unless an earlier one was recorded.
+func (p *typeParser) fail(it item, expected ...string) {
+	if p.err != nil {
+		return
+	}
+	found := fmt.Sprintf("%q", it.text)
+	if it.kind == itemEOF {
+		found = "end of type"
+	}
+	msg := "unexpected " + found
+	if len(expected) > 0 {
+		msg += ", expecting " + strings.Join(expected, " or ")
+	}
+	p.err = fmt.Errorf("%s at offset %d", msg, it.start)
+}
+
+// parseType parses a union, the loosest-binding type operator.
+func (p *typeParser) parseType() Type {
+	t := p.parseIntersection()
+	if t == nil || p.peek().text != "|" {
+		return t
+	}
+	union := &UnionType{Types: []Type{t}}
+	for p.accept("|") {
+		if t = p.parseIntersection(); t == nil {
+			return nil
+		}
+		union.Types = append(union.Types, t)
+	}
+	return union
+}
+
+func (p *typeParser) parseIntersection() Type {
+	t := p.parseNullable()
+	if t == nil || !p.isIntersection() {
+		return t
+	}
+	inter := &IntersectionType{Types: []Type{t}}
+	for p.isIntersection() {
+		p.next()
+		if t = p.parseNullable(); t == nil {
+			return nil
+		}
+		inter.Types = append(inter.Types, t)
+	}
+	return inter
+}
+
+// isIntersection reports whether the next item is an '&' joining two types,
+// as opposed to the '&' of a by-reference parameter such as "int &$a".
+func (p *typeParser) isIntersection() bool {
+	it := p.peek()
+	if it.kind != itemPunct || it.text != "&" {
+		return false
+	}
+	after := p.peekAfter(it)
+	return after.kind != itemVariable && after.text != "..."
+}
+
+func (p *typeParser) parseNullable() Type {
+	if p.accept("?") {
+		t := p.parseNullable()
+		if t == nil {
+			return nil
+		}
+		return &NullableType{Type: t}
+	}
+	return p.parsePostfix(p.parseAtomic())
+}
+
+// parsePostfix parses the "[]" and "[offset]" suffixes after t.
+func (p *typeParser) parsePostfix(t Type) Type {
+	for t != nil && p.acceptAdjacent("[") {
+		if p.accept("]") {
+			t = &ArrayType{Type: t}
+			continue
+		}
+		offset := p.parseType()
+		if offset == nil || !p.expect("]") {
+			return nil
+		}
+		t = &OffsetAccessType{Type: t, Offset: offset}
+	}
+	return t
+}
+
+func (p *typeParser) parseAtomic() Type {
+	it := p.next()
+	switch it.kind {
+	case itemIdent:
+		return p.parseNamed(it)
+	case itemVariable:
+		return &IdentifierType{Name: it.text}
+	case itemNumber, itemString:
+		return &LiteralType{Value: it.text}
+	case itemPunct:
+		switch it.text {
+		case "(":
+			return p.parseParenthesized()
+		case "*":
+			// A wildcard type argument, as in Collection<*>.
+			return &IdentifierType{Name: "*"}
+		}
+	}
+	p.fail(it, "type")
+	return nil
+}
+
+// parseNamed parses a type starting with the name it: a plain, generic,
+// shape or callable type, or a constant.
+func (p *typeParser) parseNamed(it item) Type {
+	name := &IdentifierType{Name: it.text}
+	if next := p.peek(); next.text == "::" && !next.spaced {
+		p.next()
+		return p.parseConstFetch(it.text)
+	}
+	switch {
+	case p.acceptAdjacent("<"):
+		args := p.parseTypeList(">")
+		if args == nil {
+			return nil
+		}
+		return &GenericType{Type: name, Args: args}
+	case p.acceptAdjacent("{"):
+		return p.parseShape(name)
+	case isCallableName(it.text) && p.acceptAdjacent("("):
+		return p.parseCallable(name)
+	}
+	return name
+}
+
+// parseConstFetch parses the constant name after "Class::". The name may
+// end in a '*' wildcard.
+func (p *typeParser) parseConstFetch(class string) Type {
+	start := p.pos
+	i := start
+	for i < len(p.input) && (isIdentChar(p.input[i]) && p.input[i] != '-' && p.input[i] != '\\' || p.input[i] == '*') {
+		i++
+	}
+	if i == start {
+		p.fail(p.peek(), "constant name")
+		return nil
+	}
+	p.pos, p.end = i, i
+	return &ConstFetchType{Class: class, Name: p.input[start:i]}
+}
+
+// parseTypeList parses "T, U, ...>" after the opening bracket. A trailing
+// comma is allowed.
+func (p *typeParser) parseTypeList(closing string) []Type {
+	types := []Type{}
+	for !p.accept(closing) {
+		t := p.parseType()
+		if t == nil {
+			return nil
+		}
+		types = append(types, t)
+		if !p.accept(",") {
+			if !p.expect(closing) {
+				return nil
+			}
+			break
+		}
+	}
+	return types
+}
+
+// parseParenthesized parses a parenthesised type or a conditional type after
+// the '('.
+func (p *typeParser) parseParenthesized() Type {
+	t := p.parseType()
+	if t == nil {
+		return nil
+	}
+	if it := p.peek(); it.kind == itemIdent && it.text == "is" {
+		p.next()
+		return p.parseConditional(t)
+	}
+	if !p.expect(")") {
+		return nil
+	}
+	return t
+}
+
+// parseConditional parses "not? Target ? If : Else)" after the "is" of a
+// conditional type.
+func (p *typeParser) parseConditional(subject Type) Type {
+	cond := &ConditionalType{Subject: subject}
+	if it := p.peek(); it.kind == itemIdent && it.text == "not" {
+		p.next()
+		cond.Negated = true
+	}
+	if cond.Target = p.parseType(); cond.Target == nil || !p.expect("?") {
+		return nil
+	}
+	if cond.If = p.parseType(); cond.If == nil || !p.expect(":") {
+		return nil
+	}
+	if cond.Else = p.parseType(); cond.Else == nil || !p.expect(")") {
+		return nil
+	}
+	return cond
+}
+
+// parseShape parses the items of a shape after the '{'.
+func (p *typeParser) parseShape(name *IdentifierType) Type {
+	shape := &ShapeType{Type: name, Items: []*ShapeItem{}, Sealed: true}
+	for !p.accept("}") {
+		if p.accept("...") {
+			shape.Sealed = false
+			if p.acceptAdjacent("<") {
+				if shape.Rest = p.parseTypeList(">"); shape.Rest == nil {
+					return nil
+				}
+			}
+			p.accept(",")
+			if !p.expect("}") {
+				return nil
+			}
+			break
+		}
+
+		item := p.parseShapeItem()
+		if item == nil {
+			return nil
+		}
+		shape.Items = append(shape.Items, item)
+		if !p.accept(",") {
+			if !p.expect("}") {
+				return nil
+			}
+			break
+		}
+	}
+	return shape
+}
+
+// parseShapeItem parses "key?: Type", "key: Type" or a bare "Type".
+func (p *typeParser) parseShapeItem() *ShapeItem {
+	item := &ShapeItem{}
+	key := p.peek()
+	if key.kind == itemIdent || key.kind == itemNumber || key.kind == itemString {
+		after := p.peekAfter(key)
+		if after.text == "?" && p.peekAfter(after).text == ":" {
+			item.Optional = true
+			after = p.peekAfter(after)
+		}
+		if after.text == ":" {
+			item.Key = key.text
+			p.pos, p.end = after.end, after.end
+		}
+	}
+	if item.Value = p.parseType(); item.Value == nil {
+		return nil
+	}
+	return item
+}
+
+// parseCallable parses the parameters and return type of a callable after
+// the '('.
+func (p *typeParser) parseCallable(name *IdentifierType) Type {
+	callable := &CallableType{Type: name, Params: []*CallableParam{}}
+	for !p.accept(")") {
+		param := p.parseCallableParam()
+		if param == nil {
+			return nil
+		}
+		callable.Params = append(callable.Params, param)
+		if !p.accept(",") {
+			if !p.expect(")") {
+				return nil
+			}
+			break
+		}
+	}
+	if p.accept(":") {
+		// The return type binds tighter than a union, so that
+		// "callable(): int|null" is a nullable callable.
+		if callable.Return = p.parseNullable(); callable.Return == nil {
+			return nil
+		}
+	}
+	return callable
+}
+
+// parseCallableParam parses "Type &...$name=" where all but the type are
+// optional.
+func (p *typeParser) parseCallableParam() *CallableParam {
+	param := &CallableParam{}
+	if param.Type = p.parseType(); param.Type == nil {
+		return nil
+	}
+	param.ByRef = p.accept("&")
+	param.Variadic = p.accept("...")
+	if it := p.peek(); it.kind == itemVariable {
+		p.next()
+		param.Name = it.text
+	}
+	param.Optional = p.accept("=")
+	return param
+}
+
+// isCallableName reports whether name can be followed by a signature, as
+// in callable(int): void or Closure(): void.
+func isCallableName(name string) bool {
+	switch strings.ToLower(strings.TrimPrefix(name, "\\")) {
+	case "callable", "closure", "pure-callable", "pure-closure":
+		return true
+	}
+	return false
+}
+
+func isTypeSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentStart(c byte) bool {
+	return isLetter(c) || c == '_' || c == '\\' || c >= 0x80
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// isIdentChar reports whether c may continue a name. Dashes are allowed for
+// names such as non-empty-string and class-string.
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,127 @@
+package phpdoc
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  string // The type of the result
+		want  string // The type printed back
+	}{
+		{"int", "*phpdoc.IdentifierType", "int"},
+		{`\Foo\Bar`, "*phpdoc.IdentifierType", `\Foo\Bar`},
+		{"callable", "*phpdoc.IdentifierType", "callable"},
+
+		// Unions and intersections
+		{"int|string|null", "*phpdoc.UnionType", "int|string|null"},
+		{"int | string", "*phpdoc.UnionType", "int|string"},
+		{"A&B", "*phpdoc.IntersectionType", "A&B"},
+		{"(A&B)|null", "*phpdoc.UnionType", "(A&B)|null"},
+		{"A&(B|C)", "*phpdoc.IntersectionType", "A&(B|C)"},
+
+		// Nullables and arrays
+		{"?int", "*phpdoc.NullableType", "?int"},
+		{"?Foo[]", "*phpdoc.NullableType", "?Foo[]"},
+		{"int[][]", "*phpdoc.ArrayType", "int[][]"},
+		{"(int|string)[]", "*phpdoc.ArrayType", "(int|string)[]"},
+		{"T[K]", "*phpdoc.OffsetAccessType", "T[K]"},
+
+		// Generics
+		{"array<string, list<int>>", "*phpdoc.GenericType", "array<string, list<int>>"},
+		{"Collection<int,Foo>", "*phpdoc.GenericType", "Collection<int, Foo>"},
+		{"int<0, max>", "*phpdoc.GenericType", "int<0, max>"},
+
+		// Shapes
+		{"array{id: int, name?: string}", "*phpdoc.ShapeType", "array{id: int, name?: string}"},
+		{"array{int, string}", "*phpdoc.ShapeType", "array{int, string}"},
+		{"array{'a': int, ...}", "*phpdoc.ShapeType", "array{'a': int, ...}"},
+		{"array{a: int, ...<string, mixed>}", "*phpdoc.ShapeType", "array{a: int, ...<string, mixed>}"},
+		{"list{int, string}", "*phpdoc.ShapeType", "list{int, string}"},
+		{"object{a: int}", "*phpdoc.ShapeType", "object{a: int}"},
+
+		// Callables
+		{"callable(int, string=): bool", "*phpdoc.CallableType", "callable(int, string=): bool"},
+		{"Closure(Foo ...$foos): void", "*phpdoc.CallableType", "Closure(Foo ...$foos): void"},
+		{`\Closure(int &$a): void`, "*phpdoc.CallableType", `\Closure(int &$a): void`},
+		{"callable(): (int|string)", "*phpdoc.CallableType", "callable(): (int|string)"},
+
+		// Conditional types
+		{"($value is string ? non-empty-string : int)", "*phpdoc.ConditionalType", "($value is string ? non-empty-string : int)"},
+		{"($a is not null ? A : B)", "*phpdoc.ConditionalType", "($a is not null ? A : B)"},
+
+		// Literals and constants
+		{"'foo'", "*phpdoc.LiteralType", "'foo'"},
+		{"42", "*phpdoc.LiteralType", "42"},
+		{"-1.5", "*phpdoc.LiteralType", "-1.5"},
+		{"Foo::BAR", "*phpdoc.ConstFetchType", "Foo::BAR"},
+		{"Foo::STATUS_*", "*phpdoc.ConstFetchType", "Foo::STATUS_*"},
+	}
+	for _, tt := range tests {
+		typ, err := ParseType(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if kind := fmt.Sprintf("%T", typ); kind != tt.kind {
+			t.Errorf("%s: got %s, want %s", tt.input, kind, tt.kind)
+		}
+		if got := typ.String(); got != tt.want {
+			t.Errorf("%s: printed as %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseTypeParts(t *testing.T) {
+	typ, err := ParseType("array{id: int, name?: string, ...}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape := typ.(*ShapeType)
+	if shape.Sealed || len(shape.Items) != 2 || shape.Items[0].Optional || !shape.Items[1].Optional || shape.Items[1].Key != "name" {
+		t.Errorf("shape: got %+v", shape)
+	}
+
+	typ, err = ParseType("callable(int &$a, string ...$rest): void")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callable := typ.(*CallableType)
+	if len(callable.Params) != 2 || !callable.Params[0].ByRef || callable.Params[0].Name != "$a" || !callable.Params[1].Variadic || callable.Return.String() != "void" {
+		t.Errorf("callable: got %s", callable)
+	}
+
+	typ, err = ParseType("($a is not null ? A : B)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := typ.(*ConditionalType); !cond.Negated || cond.Subject.String() != "$a" || cond.Target.String() != "null" {
+		t.Errorf("conditional: got %s", cond)
+	}
+}
+
+func TestParseTypeErrors(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"int|", "unexpected end of type, expecting type at offset 4"},
+		{"?", "unexpected end of type, expecting type at offset 1"},
+		{"array<int", `unexpected end of type, expecting ">" at offset 9`},
+		{"array{a: }", `unexpected "}", expecting type at offset 9`},
+		{"callable(int", `unexpected end of type, expecting ")" at offset 12`},
+		{"(int", `unexpected end of type, expecting ")" at offset 4`},
+		{"int string", `unexpected "string" at offset 4`},
+	}
+	for _, tt := range tests {
+		typ, err := ParseType(tt.input)
+		if err == nil {
+			t.Errorf("%s: got %s, want an error", tt.input, typ)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("%s: got error %q, want %q", tt.input, err, tt.want)
+		}
+	}
+}
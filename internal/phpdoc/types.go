@@ -0,0 +1,245 @@
+package phpdoc
+
+import "strings"
+
+// Type is a type expression in a doc comment. The grammar is the one shared
+// by phpDocumentor, PHPStan and Psalm, which is a superset of PHP's own type
+// declarations.
+type Type interface {
+	String() string
+	isType()
+}
+
+// IdentifierType is a single named type such as int, non-empty-string, Foo
+// or \Foo\Bar, or a variable in a conditional type such as $this or $param.
+// Class names are kept as written; doc comments are not name-resolved.
+type IdentifierType struct {
+	Name string
+}
+
+func (t *IdentifierType) isType()        {}
+func (t *IdentifierType) String() string { return t.Name }
+
+// NullableType is a type prefixed with '?', e.g. ?int.
+type NullableType struct {
+	Type Type
+}
+
+func (t *NullableType) isType()        {}
+func (t *NullableType) String() string { return "?" + t.Type.String() }
+
+// UnionType is a list of alternatives, e.g. int|string|null.
+type UnionType struct {
+	Types []Type
+}
+
+func (t *UnionType) isType()        {}
+func (t *UnionType) String() string { return joinTypes(t.Types, "|") }
+
+// IntersectionType is a list of types a value must satisfy together, e.g.
+// Countable&Traversable.
+type IntersectionType struct {
+	Types []Type
+}
+
+func (t *IntersectionType) isType()        {}
+func (t *IntersectionType) String() string { return joinTypes(t.Types, "&") }
+
+// ArrayType is the "T[]" shorthand for array<T>.
+type ArrayType struct {
+	Type Type
+}
+
+func (t *ArrayType) isType()        {}
+func (t *ArrayType) String() string { return operand(t.Type) + "[]" }
+
+// OffsetAccessType is the type of an offset of another type, e.g.
+// T['key'].
+type OffsetAccessType struct {
+	Type   Type
+	Offset Type
+}
+
+func (t *OffsetAccessType) isType() {}
+func (t *OffsetAccessType) String() string {
+	return operand(t.Type) + "[" + t.Offset.String() + "]"
+}
+
+// GenericType is a type with type arguments, e.g. array<int, string>,
+// list<Foo> or Collection<T>.
+type GenericType struct {
+	Type *IdentifierType
+	Args []Type
+}
+
+func (t *GenericType) isType() {}
+func (t *GenericType) String() string {
+	return t.Type.String() + "<" + joinList(t.Args) + ">"
+}
+
+// ShapeType is an array or object shape such as array{id: int, name?:
+// string} or list{int, string}.
+type ShapeType struct {
+	Type  *IdentifierType // array, list, non-empty-array, object, ...
+	Items []*ShapeItem
+	// Sealed is false when the shape ends with "...", allowing more items.
+	Sealed bool
+	// Rest holds the key and value types of the extra items of an unsealed
+	// shape, as in array{a: int, ...<string, mixed>}, if given.
+	Rest []Type
+}
+
+func (t *ShapeType) isType() {}
+func (t *ShapeType) String() string {
+	items := make([]string, 0, len(t.Items)+1)
+	for _, item := range t.Items {
+		items = append(items, item.String())
+	}
+	if !t.Sealed {
+		rest := "..."
+		if len(t.Rest) > 0 {
+			rest += "<" + joinList(t.Rest) + ">"
+		}
+		items = append(items, rest)
+	}
+	return t.Type.String() + "{" + strings.Join(items, ", ") + "}"
+}
+
+// ShapeItem is one item of a shape. Key is empty for items without a key,
+// and otherwise holds the key as written, including any quotes.
+type ShapeItem struct {
+	Key      string
+	Optional bool
+	Value    Type
+}
+
+func (i *ShapeItem) String() string {
+	if i.Key == "" {
+		return i.Value.String()
+	}
+	key := i.Key
+	if i.Optional {
+		key += "?"
+	}
+	return key + ": " + i.Value.String()
+}
+
+// CallableType is a callable with a signature, e.g.
+// callable(int, string=): bool or Closure(Foo ...$foos): void.
+type CallableType struct {
+	Type   *IdentifierType // callable, Closure, \Closure, pure-callable, ...
+	Params []*CallableParam
+	Return Type // nil if no return type is given
+}
+
+func (t *CallableType) isType() {}
+func (t *CallableType) String() string {
+	params := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		params = append(params, param.String())
+	}
+	out := t.Type.String() + "(" + strings.Join(params, ", ") + ")"
+	if t.Return != nil {
+		out += ": " + operand(t.Return)
+	}
+	return out
+}
+
+// CallableParam is a parameter in a callable signature.
+type CallableParam struct {
+	Type     Type
+	ByRef    bool
+	Variadic bool
+	Optional bool   // Marked with a trailing '='
+	Name     string // "$name", or empty if not given
+}
+
+func (p *CallableParam) String() string {
+	out := p.Type.String()
+	if p.ByRef || p.Variadic || p.Name != "" {
+		out += " "
+	}
+	if p.ByRef {
+		out += "&"
+	}
+	if p.Variadic {
+		out += "..."
+	}
+	out += p.Name
+	if p.Optional {
+		out += "="
+	}
+	return out
+}
+
+// LiteralType is a literal value used as a type, e.g. 'foo', 42 or -1.5.
+// Value holds the literal as written, including any quotes.
+type LiteralType struct {
+	Value string
+}
+
+func (t *LiteralType) isType()        {}
+func (t *LiteralType) String() string { return t.Value }
+
+// ConstFetchType is a constant used as a type, e.g. Foo::BAR, or a set of
+// constants matched by a wildcard, e.g. Foo::STATUS_*.
+type ConstFetchType struct {
+	Class string // Empty for a global constant
+	Name  string
+}
+
+func (t *ConstFetchType) isType() {}
+func (t *ConstFetchType) String() string {
+	if t.Class == "" {
+		return t.Name
+	}
+	return t.Class + "::" + t.Name
+}
+
+// ConditionalType picks one of two types depending on whether a parameter
+// or type matches another type, e.g.
+// ($value is string ? non-empty-string : int).
+type ConditionalType struct {
+	Subject Type // An IdentifierType holding "$param", or a type
+	Negated bool // "is not"
+	Target  Type
+	If      Type
+	Else    Type
+}
+
+func (t *ConditionalType) isType() {}
+func (t *ConditionalType) String() string {
+	is := " is "
+	if t.Negated {
+		is = " is not "
+	}
+	return "(" + t.Subject.String() + is + t.Target.String() + " ? " + t.If.String() + " : " + t.Else.String() + ")"
+}
+
+// joinTypes prints a union or intersection. Unions and intersections nested
+// in one another are parenthesised.
+func joinTypes(types []Type, sep string) string {
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, operand(t))
+	}
+	return strings.Join(parts, sep)
+}
+
+// operand prints t as the operand of a type operator, parenthesising unions
+// and intersections.
+func operand(t Type) string {
+	switch t.(type) {
+	case *UnionType, *IntersectionType:
+		return "(" + t.String() + ")"
+	}
+	return t.String()
+}
+
+func joinList(types []Type) string {
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, ", ")
+}
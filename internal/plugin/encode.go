@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/phpdoc"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+var (
+	nodeType   = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	tokenType  = reflect.TypeOf(token.Token{})
+	tokensType = reflect.TypeOf([]token.Token(nil))
+	docType    = reflect.TypeOf((*phpdoc.Comment)(nil))
+)
+
+// encodeNode returns the JSON form of a syntax tree sent to plugins, see
+// the package documentation.
+func encodeNode(node ast.Node) map[string]any {
+	v := reflect.ValueOf(node).Elem()
+	out := map[string]any{
+		"node": v.Type().Name(),
+		"span": wireSpan(token.Span{Start: node.Pos(), End: node.End()}),
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Anonymous || field.Type == tokensType {
+			continue
+		}
+		out[jsonName(field.Name)] = encodeValue(v.Field(i))
+	}
+	return out
+}
+
+func encodeValue(f reflect.Value) any {
+	switch {
+	case f.Type() == tokenType:
+		return f.Interface().(token.Token).Lexeme
+	case f.Type() == docType:
+		if f.IsNil() {
+			return nil
+		}
+		return f.Interface().(*phpdoc.Comment).Text
+	case f.Type().Implements(nodeType):
+		if f.IsNil() {
+			return nil
+		}
+		return encodeNode(f.Interface().(ast.Node))
+	case f.Kind() == reflect.Slice && f.Type().Elem().Implements(nodeType):
+		list := make([]any, f.Len())
+		for i := range list {
+			if elem := f.Index(i); !elem.IsNil() {
+				list[i] = encodeNode(elem.Interface().(ast.Node))
+			}
+		}
+		return list
+	case f.Kind() == reflect.Float64:
+		// JSON has no infinite numbers, which literals too large give.
+		if x := f.Float(); !math.IsInf(x, 0) && !math.IsNaN(x) {
+			return x
+		}
+		return nil
+	}
+	// Modifiers and kinds of use are given by name.
+	if s, ok := f.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return f.Interface()
+}
+
+func jsonName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,322 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/pkg/types"
+	"github.com/rs/zerolog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// DefaultTimeout bounds each request to a plugin whose config gives no
+// timeout.
+const DefaultTimeout = 10 * time.Second
+
+// maxFailures is how many times a plugin can crash or time out before it
+// is no longer started again.
+const maxFailures = 3
+
+// shutdownGrace is how long a plugin has to exit once its input is closed.
+const shutdownGrace = time.Second
+
+// Config tells how to run a plugin.
+type Config struct {
+	Command string
+	Args    []string
+	Dir     string        // The directory the plugin runs in
+	Timeout time.Duration // DefaultTimeout if zero
+}
+
+// Plugin is a running plugin. Its methods are safe for concurrent use; the
+// requests are sent one at a time.
+type Plugin struct {
+	config Config
+	logger zerolog.Logger
+	name   string
+	rules  []RuleInfo
+
+	mu       sync.Mutex
+	proc     *process // nil while the plugin is not running
+	failures int
+}
+
+// process is one run of a plugin.
+type process struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	encoder  *json.Encoder
+	messages chan incoming // Closed when the plugin's output ends
+	exited   chan struct{} // Closed once the plugin has exited
+	nextID   int
+}
+
+// Start runs a plugin and asks it for its rules.
+func Start(config Config, logger zerolog.Logger) (*Plugin, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	p := &Plugin{config: config, logger: logger.With().Str("plugin", config.Command).Logger()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name returns the name the plugin gave itself.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Rules returns the rules the plugin implements.
+func (p *Plugin) Rules() []RuleInfo {
+	return p.rules
+}
+
+// start runs the plugin and initializes it. p.mu must be held.
+func (p *Plugin) start() error {
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.Dir
+	cmd.Stderr = os.Stderr
+	// Wait gives up on the output of processes the plugin leaves behind.
+	cmd.WaitDelay = shutdownGrace
+	isolate(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		p.failures++
+		return err
+	}
+
+	proc := &process{
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   stdout,
+		encoder:  json.NewEncoder(stdin),
+		messages: make(chan incoming, 16),
+		exited:   make(chan struct{}),
+	}
+	go func() {
+		decoder := json.NewDecoder(stdout)
+		for {
+			var msg incoming
+			if err := decoder.Decode(&msg); err != nil {
+				break
+			}
+			proc.messages <- msg
+		}
+		close(proc.messages)
+		// Wait closes stdout, so it must not be called before the output
+		// has been read.
+		cmd.Wait()
+		close(proc.exited)
+	}()
+	p.proc = proc
+
+	var result initializeResult
+	if err := p.call("initialize", initializeParams{ProtocolVersion: ProtocolVersion}, &result); err != nil {
+		p.kill()
+		return fmt.Errorf("initializing plugin %s: %w", p.config.Command, err)
+	}
+	if p.name == "" {
+		p.name = result.Name
+		if p.name == "" {
+			p.name = p.config.Command
+		}
+		p.rules = result.Rules
+	}
+	return nil
+}
+
+// Check sends a file to the plugin and returns the issues it reports for
+// the given rules, with options set for some of them. If the plugin fails,
+// it is stopped and the error is returned; it is started again by the next
+// Check.
+func (p *Plugin) Check(path string, content []byte, program *ast.Program, version lexer.Version, rules []string, options map[string]json.RawMessage) ([]types.Issue, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.proc == nil {
+		if p.failures >= maxFailures {
+			return nil, fmt.Errorf("plugin %s is disabled after failing %d times", p.name, p.failures)
+		}
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	params := checkParams{
+		Path:       path,
+		Content:    string(content),
+		PHPVersion: version.String(),
+		Rules:      rules,
+		Options:    options,
+		AST:        encodeNode(program),
+	}
+	var result checkResult
+	if err := p.call("check", params, &result); err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+	issues := make([]types.Issue, 0, len(result.Issues))
+	for _, is := range result.Issues {
+		if !enabled[is.Rule] {
+			continue
+		}
+		severity := protocol.DiagnosticSeverity(is.Severity)
+		if severity < protocol.DiagnosticSeverityError || severity > protocol.DiagnosticSeverityHint {
+			severity = protocol.DiagnosticSeverityWarning
+		}
+		issue := types.Issue{
+			RuleName: is.Rule,
+			Message:  is.Message,
+			Range:    is.Range.token(),
+			Severity: severity,
+			Source:   p.name,
+		}
+		for _, e := range is.Fix {
+			issue.Fix = append(issue.Fix, ast.Edit{Span: e.Range.token(), NewText: e.NewText})
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// call sends a request and decodes its result. If the plugin crashes or
+// does not answer in time, it is killed. p.mu must be held.
+func (p *Plugin) call(method string, params, result any) error {
+	proc := p.proc
+	proc.nextID++
+	id := proc.nextID
+	err := proc.encoder.Encode(message{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		err = fmt.Errorf("sending %s: %w", method, err)
+	} else {
+		err = p.await(proc, id, result)
+	}
+	// An error response leaves the plugin running; anything else means it
+	// is broken.
+	var rpcErr *rpcError
+	if err != nil && !errors.As(err, &rpcErr) {
+		p.failures++
+		p.kill()
+		p.logger.Error().Err(err).Int("failures", p.failures).Msg("Plugin failed, stopped it")
+	}
+	return err
+}
+
+// await waits for the response to the request id.
+func (p *Plugin) await(proc *process, id int, result any) error {
+	timer := time.NewTimer(p.config.Timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-proc.messages:
+			if !ok {
+				return errors.New("the plugin exited")
+			}
+			if msg.ID == nil {
+				p.notification(msg)
+				continue
+			}
+			if *msg.ID != id {
+				// The answer to a request that timed out.
+				continue
+			}
+			if msg.Error != nil {
+				return msg.Error
+			}
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("bad result: %v", err)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("no answer within %s", p.config.Timeout)
+		}
+	}
+}
+
+func (p *Plugin) notification(msg incoming) {
+	if msg.Method != "log" {
+		return
+	}
+	var params logParams
+	if err := json.Unmarshal(msg.Params, &params); err == nil {
+		p.logger.Info().Msg(params.Message)
+	}
+}
+
+// kill stops the plugin at once. p.mu must be held.
+func (p *Plugin) kill() {
+	if p.proc == nil {
+		return
+	}
+	p.proc.kill()
+	p.proc.wait()
+	p.proc = nil
+}
+
+// Close asks the plugin to exit, and kills it if it does not.
+func (p *Plugin) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.proc == nil {
+		return
+	}
+	proc := p.proc
+	proc.encoder.Encode(message{JSONRPC: "2.0", Method: "shutdown"})
+	proc.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		proc.wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(shutdownGrace):
+		proc.kill()
+		<-exited
+	}
+	p.proc = nil
+}
+
+// kill kills the plugin and the processes it started. A process that left
+// its group could still hold the output open, so it is closed, for the
+// reader to stop.
+func (proc *process) kill() {
+	proc.stdin.Close()
+	killGroup(proc.cmd)
+	proc.stdout.Close()
+}
+
+// wait waits for the plugin to exit, dropping the messages it still sends.
+func (proc *process) wait() {
+	for range proc.messages {
+	}
+	<-proc.exited
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/rs/zerolog"
+)
+
+// writeScript writes a plugin as a shell script and returns its path.
+func writeScript(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const initialized = `read line
+echo '{"jsonrpc": "2.0", "id": 1, "result": {"name": "test", "rules": [{"name": "test-rule"}]}}'
+`
+
+func TestCheckTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"exec", initialized + "read line\nexec sleep 30\n"},
+		// The shell waits for sleep, which holds the output open.
+		{"wrapper", initialized + "read line\nsleep 30\n"},
+	}
+	for _, tt := range tests {
+		p, err := Start(Config{Command: writeScript(t, tt.script), Timeout: 200 * time.Millisecond}, zerolog.Nop())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		start := time.Now()
+		_, err = p.Check("a.php", []byte("<?php"), &ast.Program{}, lexer.LatestVersion, []string{"test-rule"}, nil)
+		if err == nil {
+			t.Errorf("%s: Check succeeded, want a timeout", tt.name)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("%s: Check took %s with a timeout of 200ms", tt.name, elapsed)
+		}
+		p.Close()
+	}
+}
+
+func TestCheck(t *testing.T) {
+	script := initialized + `read line
+echo '{"jsonrpc": "2.0", "id": 2, "result": {"issues": [
+  {"rule": "test-rule", "message": "found", "severity": 9, "range": {"start": {"line": 1, "col": 1}, "end": {"line": 1, "col": 6}}},
+  {"rule": "other-rule", "message": "not asked for", "severity": 1}]}}' | tr -d '\n'
+echo
+read line
+`
+	p, err := Start(Config{Command: writeScript(t, script)}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if p.Name() != "test" || len(p.Rules()) != 1 {
+		t.Errorf("got plugin %q with rules %v", p.Name(), p.Rules())
+	}
+	issues, err := p.Check("a.php", []byte("<?php"), &ast.Program{}, lexer.LatestVersion, []string{"test-rule"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want the one of test-rule", len(issues))
+	}
+	// Severities out of range become warnings.
+	if issues[0].Message != "found" || issues[0].Severity != 2 || issues[0].Source != "test" {
+		t.Errorf("got issue %+v", issues[0])
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package plugin
+
+import "os/exec"
+
+func isolate(cmd *exec.Cmd) {}
+
+func killGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package plugin
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolate starts the plugin in a process group of its own, for kill to
+// stop the processes it starts too, as a wrapper script does.
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killGroup kills the process group of the plugin.
+func killGroup(cmd *exec.Cmd) {
+	// The group has the id of its leader, the plugin.
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
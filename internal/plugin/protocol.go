@@ -0,0 +1,142 @@
+// Package plugin runs rules shipped as separate executables. A plugin
+// speaks JSON-RPC 2.0 on its standard input and output, one message per
+// line; what it writes to its standard error ends up in the linter's logs.
+//
+// The linter starts the plugin and sends it an initialize request:
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": 1}}
+//
+// to which the plugin answers with its name and the rules it implements:
+//
+//	{"jsonrpc": "2.0", "id": 1, "result": {"name": "acme", "rules": [{"name": "acme-no-globals", "description": "..."}]}}
+//
+// Then, for each file, the linter sends a check request with the file and
+// its syntax tree, the rules the config enables, and the options it sets
+// for them:
+//
+//	{"jsonrpc": "2.0", "id": 2, "method": "check", "params": {"path": "/src/a.php", "content": "<?php ...",
+//	 "phpVersion": "8.2", "rules": ["acme-no-globals"], "options": {"acme-no-globals": {"allow": ["config"]}},
+//	 "ast": {"node": "Program", "span": {...}, "stmts": [...]}}}
+//
+// Each node of the tree is an object with the node type of internal/ast in
+// "node", its "span", and its fields under their Go names with a lowercase
+// first letter. Tokens are given by their text and doc comments by theirs.
+// The plugin answers with the issues it found, which can carry edits that
+// fix them:
+//
+//	{"jsonrpc": "2.0", "id": 2, "result": {"issues": [{"rule": "acme-no-globals", "message": "...", "severity": 2,
+//	 "range": {"start": {"line": 3, "col": 5, "offset": 30}, "end": {...}}, "fix": [{"range": {...}, "newText": "..."}]}]}}
+//
+// Severities are those of LSP: 1 for errors, 2 for warnings, 3 for
+// information and 4 for hints. Columns count characters from 1, offsets
+// bytes from 0. A plugin can send a log notification, with a "message"
+// param, at any time. Before the linter exits, it sends a shutdown
+// notification and closes the plugin's input.
+//
+// A plugin that does not answer in time is killed, like one that crashes,
+// and the file is linted without it. It is started again for the next
+// file, until it has failed too many times.
+package plugin
+
+import (
+	"encoding/json"
+
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// ProtocolVersion is the version of the protocol described above.
+const ProtocolVersion = 1
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// incoming is a message received from a plugin.
+type incoming struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type initializeParams struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	Name  string     `json:"name"`
+	Rules []RuleInfo `json:"rules"`
+}
+
+// RuleInfo describes a rule implemented by a plugin.
+type RuleInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type checkParams struct {
+	Path       string                     `json:"path"`
+	Content    string                     `json:"content"`
+	PHPVersion string                     `json:"phpVersion"`
+	Rules      []string                   `json:"rules"`
+	Options    map[string]json.RawMessage `json:"options"`
+	AST        any                        `json:"ast"`
+}
+
+type checkResult struct {
+	Issues []issue `json:"issues"`
+}
+
+type issue struct {
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Severity int    `json:"severity"`
+	Range    span   `json:"range"`
+	Fix      []edit `json:"fix"`
+}
+
+type edit struct {
+	Range   span   `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type span struct {
+	Start pos `json:"start"`
+	End   pos `json:"end"`
+}
+
+type pos struct {
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+	Offset int `json:"offset"`
+}
+
+type logParams struct {
+	Message string `json:"message"`
+}
+
+func wireSpan(s token.Span) span {
+	return span{Start: wirePos(s.Start), End: wirePos(s.End)}
+}
+
+func wirePos(p token.Pos) pos {
+	return pos{Line: p.Line, Col: p.Col, Offset: p.Offset}
+}
+
+func (s span) token() token.Span {
+	return token.Span{
+		Start: token.Pos{Line: s.Start.Line, Col: s.Start.Col, Offset: s.Start.Offset},
+		End:   token.Pos{Line: s.End.Line, Col: s.End.Col, Offset: s.End.Offset},
+	}
+}
@@ -2,44 +2,86 @@ package reporter
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/codevault-llc/php-lint/pkg/types"
 	"github.com/fatih/color"
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 // Render formats and prints the final linting report to the console.
 func Render(issues []types.Issue) {
+	if len(issues) == 0 {
+		return
+	}
+
 	groupedIssues := make(map[string][]types.Issue)
+	files := []string{}
 	for _, issue := range issues {
-		groupedIssues[issue.Source] = append(groupedIssues[issue.Source], issue)
+		if _, seen := groupedIssues[issue.File]; !seen {
+			files = append(files, issue.File)
+		}
+		groupedIssues[issue.File] = append(groupedIssues[issue.File], issue)
 	}
+	sort.Strings(files)
 
 	// Create color functions
 	errorColor := color.New(color.FgRed).Add(color.Bold)
-	warningColor := color.New(color.FgYellow)
 	filePathColor := color.New(color.Underline)
 	ruleColor := color.New(color.Faint)
 
 	fmt.Println() // Add a newline for spacing
 
-	for _, issueList := range groupedIssues {
-		if len(issueList) == 0 {
-			continue
-		}
+	for _, file := range files {
+		issueList := groupedIssues[file]
+		sort.SliceStable(issueList, func(i, j int) bool {
+			return issueList[i].Range.Start.Offset < issueList[j].Range.Start.Offset
+		})
 
+		filePathColor.Println(file)
 		for _, issue := range issueList {
-			filePathColor.Println(issue.Source)
-			for _, issue := range issueList {
-				// In a real implementation, you'd populate the issue's Line and Col
-				// from the AST node's position. For now, we'll omit them.
-			warningColor.Printf("  %s ", "warning")
+			location := fmt.Sprintf("%d:%d", issue.Range.Start.Line, issue.Range.Start.Col)
+			fmt.Printf("  %-8s ", location)
+			severityColor(issue.Severity).Printf("%-7s", severityName(issue.Severity))
 			fmt.Printf(" %s ", issue.Message)
 			ruleColor.Printf(" (%s)\n", issue.RuleName)
 		}
 		fmt.Println()
 	}
 
-	summary := fmt.Sprintf("\n✖ %d problem(s) found in %d file(s).", len(groupedIssues), len(issues))
+	summary := fmt.Sprintf("✖ %d problem(s) found in %d file(s).", len(issues), len(files))
 	errorColor.Println(summary)
 }
-}
\ No newline at end of file
+
+// HasErrors reports whether any of issues is an error, as opposed to a
+// warning or a hint.
+func HasErrors(issues []types.Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == protocol.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func severityName(severity protocol.DiagnosticSeverity) string {
+	switch severity {
+	case protocol.DiagnosticSeverityError:
+		return "error"
+	case protocol.DiagnosticSeverityInformation:
+		return "info"
+	case protocol.DiagnosticSeverityHint:
+		return "hint"
+	}
+	return "warning"
+}
+
+func severityColor(severity protocol.DiagnosticSeverity) *color.Color {
+	switch severity {
+	case protocol.DiagnosticSeverityError:
+		return color.New(color.FgRed)
+	case protocol.DiagnosticSeverityWarning:
+		return color.New(color.FgYellow)
+	}
+	return color.New(color.FgBlue)
+}
@@ -0,0 +1,289 @@
+// Package resolver turns the names referenced in a program into
+// fully-qualified names, following PHP's rules for namespaces and imports.
+package resolver
+
+import (
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/token"
+)
+
+// specialClassNames are class names that are relative to the current class
+// and never namespaced.
+var specialClassNames = map[string]bool{
+	"self": true, "parent": true, "static": true,
+}
+
+// builtinTypes are the type names that do not refer to classes.
+var builtinTypes = map[string]bool{
+	"int": true, "float": true, "string": true, "bool": true, "array": true,
+	"callable": true, "iterable": true, "object": true, "mixed": true,
+	"void": true, "null": true, "never": true, "false": true, "true": true,
+	"self": true, "parent": true, "static": true,
+}
+
+// globalConstants are resolved globally in every namespace.
+var globalConstants = map[string]bool{
+	"true": true, "false": true, "null": true,
+}
+
+// Resolve sets Resolved on every identifier of program that refers to a
+// class, function or constant, and on named types. Unqualified function and
+// constant names in a namespace also get their global Fallback, since PHP
+// falls back to the global symbol when the namespaced one does not exist.
+func Resolve(program *ast.Program) {
+	r := newResolver()
+	ast.Inspect(program, r.enter)
+}
+
+type resolver struct {
+	namespace string
+	classes   map[string]string // lowercase alias -> fully-qualified name
+	functions map[string]string // lowercase alias -> fully-qualified name
+	constants map[string]string // alias -> fully-qualified name
+	// done holds identifiers already handled by a parent node, such as
+	// declaration and member names, so they are not taken for constants.
+	done map[*ast.Identifier]bool
+}
+
+func newResolver() *resolver {
+	r := &resolver{done: make(map[*ast.Identifier]bool)}
+	r.enterNamespace("")
+	return r
+}
+
+// enterNamespace starts a namespace. Imports do not carry over from one
+// namespace to the next.
+func (r *resolver) enterNamespace(name string) {
+	r.namespace = name
+	r.classes = make(map[string]string)
+	r.functions = make(map[string]string)
+	r.constants = make(map[string]string)
+}
+
+func (r *resolver) enter(node ast.Node, ancestors []ast.Node) ast.Action {
+	switch n := node.(type) {
+	case *ast.NamespaceStmt:
+		name := ""
+		if n.Name != nil {
+			name = strings.TrimPrefix(n.Name.Value, "\\")
+			n.Name.Resolved = name
+			r.done[n.Name] = true
+		}
+		r.enterNamespace(name)
+	case *ast.UseStmt:
+		r.addImports(n)
+	case *ast.FunctionDeclStmt:
+		r.declare(n.Name)
+	case *ast.ClassDecl:
+		r.declare(n.Name)
+		r.resolve(n.Extends, ast.UseClass)
+		r.resolveAll(n.Implements, ast.UseClass)
+	case *ast.InterfaceDecl:
+		r.declare(n.Name)
+		r.resolveAll(n.Extends, ast.UseClass)
+	case *ast.TraitDecl:
+		r.declare(n.Name)
+	case *ast.EnumDecl:
+		r.declare(n.Name)
+		r.resolveAll(n.Implements, ast.UseClass)
+	case *ast.MethodDecl:
+		r.skip(n.Name)
+	case *ast.EnumCaseDecl:
+		r.skip(n.Name)
+	case *ast.ConstStmt:
+		for _, c := range n.Consts {
+			r.declare(c.Name)
+		}
+	case *ast.ConstItem:
+		r.skip(n.Name)
+	case *ast.TraitUseStmt:
+		r.resolveAll(n.Traits, ast.UseClass)
+	case *ast.TraitAdaptation:
+		r.resolve(n.Trait, ast.UseClass)
+		r.resolveAll(n.Insteadof, ast.UseClass)
+		r.skip(n.Method)
+		r.skip(n.Alias)
+	case *ast.Attribute:
+		r.resolve(n.Name, ast.UseClass)
+	case *ast.NamedType:
+		if !builtinTypes[strings.ToLower(n.Name)] {
+			n.Resolved, _ = r.resolveName(n.Token.Kind, n.Name, ast.UseClass)
+		}
+	case *ast.CallExpr:
+		if ident, ok := n.Function.(*ast.Identifier); ok {
+			r.resolve(ident, ast.UseFunction)
+		}
+	case *ast.Argument:
+		r.skip(n.Name)
+	case *ast.NewExpr:
+		r.resolveClassExpr(n.Class)
+	case *ast.InstanceofExpr:
+		r.resolveClassExpr(n.Class)
+	case *ast.StaticCallExpr:
+		r.resolveClassExpr(n.Class)
+		r.skipExpr(n.Method)
+	case *ast.StaticPropertyFetchExpr:
+		r.resolveClassExpr(n.Class)
+	case *ast.ClassConstFetchExpr:
+		r.resolveClassExpr(n.Class)
+		r.skip(n.Name)
+	case *ast.CatchClause:
+		r.resolveAll(n.Types, ast.UseClass)
+	case *ast.GotoStmt:
+		r.skip(n.Label)
+	case *ast.LabelStmt:
+		r.skip(n.Name)
+	case *ast.PropertyFetchExpr:
+		r.skipExpr(n.Property)
+	case *ast.MethodCallExpr:
+		r.skipExpr(n.Method)
+	case *ast.Identifier:
+		// Any name not claimed by a parent node is a constant.
+		if !r.done[n] {
+			r.resolve(n, ast.UseConst)
+		}
+	}
+	return ast.Continue
+}
+
+// addImports records the aliases introduced by a use statement.
+func (r *resolver) addImports(stmt *ast.UseStmt) {
+	prefix := ""
+	if stmt.Prefix != nil {
+		stmt.Prefix.Resolved = strings.TrimPrefix(stmt.Prefix.Value, "\\")
+		prefix = stmt.Prefix.Resolved + "\\"
+		r.done[stmt.Prefix] = true
+	}
+	for _, use := range stmt.Uses {
+		kind := stmt.Kind
+		if use.Kind != ast.UseClass {
+			kind = use.Kind
+		}
+		// Imported names are always fully qualified; a leading backslash
+		// is allowed but redundant.
+		full := prefix + strings.TrimPrefix(use.Name.Value, "\\")
+		use.Name.Resolved = full
+		r.done[use.Name] = true
+		if use.Alias != nil {
+			r.done[use.Alias] = true
+		}
+
+		alias := use.AliasName()
+		switch kind {
+		case ast.UseFunction:
+			r.functions[strings.ToLower(alias)] = full
+		case ast.UseConst:
+			r.constants[alias] = full
+		default:
+			r.classes[strings.ToLower(alias)] = full
+		}
+	}
+}
+
+// skip marks a name that does not refer to a class, function or constant,
+// such as a method or property name.
+func (r *resolver) skip(ident *ast.Identifier) {
+	if ident != nil {
+		r.done[ident] = true
+	}
+}
+
+// skipExpr marks a member name, which is an Identifier unless the member is
+// accessed dynamically.
+func (r *resolver) skipExpr(member ast.Expr) {
+	if ident, ok := member.(*ast.Identifier); ok {
+		r.skip(ident)
+	}
+}
+
+// resolveClassExpr resolves the class of new, instanceof and static
+// accesses when it is given by name rather than by an expression.
+func (r *resolver) resolveClassExpr(class ast.Expr) {
+	if ident, ok := class.(*ast.Identifier); ok {
+		r.resolve(ident, ast.UseClass)
+	}
+}
+
+// declare resolves the name of a declaration to its namespaced name.
+func (r *resolver) declare(ident *ast.Identifier) {
+	if ident == nil {
+		return
+	}
+	ident.Resolved = r.qualify(ident.Value)
+	r.done[ident] = true
+}
+
+func (r *resolver) resolveAll(idents []*ast.Identifier, kind ast.UseKind) {
+	for _, ident := range idents {
+		r.resolve(ident, kind)
+	}
+}
+
+// resolve resolves a reference to a class, function or constant.
+func (r *resolver) resolve(ident *ast.Identifier, kind ast.UseKind) {
+	if ident == nil {
+		return
+	}
+	r.done[ident] = true
+	switch ident.Token.Kind {
+	case token.IDENT, token.NAME_QUALIFIED, token.NAME_FULLY_QUALIFIED, token.NAME_RELATIVE:
+		ident.Resolved, ident.Fallback = r.resolveName(ident.Token.Kind, ident.Value, kind)
+	}
+}
+
+// resolveName applies PHP's name resolution rules to a name written as
+// name and lexed as kind. The fallback is only set for unqualified function
+// and constant names inside a namespace.
+func (r *resolver) resolveName(kind token.Kind, name string, useKind ast.UseKind) (resolved, fallback string) {
+	switch kind {
+	case token.NAME_FULLY_QUALIFIED:
+		return strings.TrimPrefix(name, "\\"), ""
+	case token.NAME_RELATIVE:
+		return r.qualify(name[len("namespace\\"):]), ""
+	case token.NAME_QUALIFIED:
+		// The first segment of a qualified name may be an imported class or
+		// namespace alias, whatever kind of symbol the name refers to.
+		first, rest, _ := strings.Cut(name, "\\")
+		if full, ok := r.classes[strings.ToLower(first)]; ok {
+			return full + "\\" + rest, ""
+		}
+		return r.qualify(name), ""
+	}
+
+	switch useKind {
+	case ast.UseFunction:
+		if full, ok := r.functions[strings.ToLower(name)]; ok {
+			return full, ""
+		}
+	case ast.UseConst:
+		if globalConstants[strings.ToLower(name)] {
+			return name, ""
+		}
+		if full, ok := r.constants[name]; ok {
+			return full, ""
+		}
+	default:
+		if specialClassNames[strings.ToLower(name)] {
+			return "", ""
+		}
+		if full, ok := r.classes[strings.ToLower(name)]; ok {
+			return full, ""
+		}
+		return r.qualify(name), ""
+	}
+
+	if r.namespace == "" {
+		return name, ""
+	}
+	return r.qualify(name), name
+}
+
+// qualify prefixes name with the current namespace.
+func (r *resolver) qualify(name string) string {
+	if r.namespace == "" {
+		return name
+	}
+	return r.namespace + "\\" + name
+}
@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+)
+
+// resolved returns the names of src that refer to symbols, as
+// "name=resolved" or "name=resolved|fallback", in order.
+func resolved(t *testing.T, src string) []string {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: %s", src, errs[0].Message)
+	}
+	Resolve(program)
+	names := []string{}
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		switch n := node.(type) {
+		case *ast.Identifier:
+			if n.Resolved != "" {
+				name := n.Value + "=" + n.Resolved
+				if n.Fallback != "" {
+					name += "|" + n.Fallback
+				}
+				names = append(names, name)
+			}
+		case *ast.NamedType:
+			if n.Resolved != "" {
+				names = append(names, n.Name+"="+n.Resolved)
+			}
+		}
+		return ast.Continue
+	})
+	return names
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		src   string
+		names []string
+	}{
+		// Outside a namespace, names are global.
+		{"<?php foo(BAR); new C;", []string{"foo=foo", "BAR=BAR", "C=C"}},
+		// In a namespace, unqualified functions and constants fall back to
+		// the global ones; classes do not.
+		{"<?php namespace App; foo(BAR); new C;", []string{"App=App", "foo=App\\foo|foo", "BAR=App\\BAR|BAR", "C=App\\C"}},
+		{"<?php namespace App; \\foo(\\BAR); new \\C;", []string{"App=App", "\\foo=foo", "\\BAR=BAR", "\\C=C"}},
+		{"<?php namespace App; new Sub\\C; new namespace\\D;", []string{"App=App", "Sub\\C=App\\Sub\\C", "namespace\\D=App\\D"}},
+		{"<?php namespace App; true; null;", []string{"App=App", "true=true", "null=null"}},
+		// Imports, case-insensitive but for constants.
+		{"<?php namespace App; use Lib\\Str; use function Lib\\f; use const Lib\\X; new str; F(); X; x;",
+			[]string{"App=App", "Lib\\Str=Lib\\Str", "Lib\\f=Lib\\f", "Lib\\X=Lib\\X", "str=Lib\\Str", "F=Lib\\f", "X=Lib\\X", "x=App\\x|x"}},
+		{"<?php use Lib\\{A, B as C, function f}; new A; new C; f(); new B;",
+			[]string{"Lib=Lib", "A=Lib\\A", "B=Lib\\B", "f=Lib\\f", "A=Lib\\A", "C=Lib\\B", "f=Lib\\f", "B=B"}},
+		// An imported namespace starts qualified names of any kind.
+		{"<?php use Lib\\Sub; Sub\\f(); new Sub\\C;", []string{"Lib\\Sub=Lib\\Sub", "Sub\\f=Lib\\Sub\\f", "Sub\\C=Lib\\Sub\\C"}},
+		// Imports end with their namespace.
+		{"<?php namespace A; use Lib\\C; namespace B; new C;", []string{"A=A", "Lib\\C=Lib\\C", "B=B", "C=B\\C"}},
+		// Declarations, types and class references.
+		{"<?php namespace App; class C extends B implements I { function m(self $a, int $b): ?D {} }",
+			[]string{"App=App", "C=App\\C", "B=App\\B", "I=App\\I", "D=App\\D"}},
+		{"<?php namespace App; $a instanceof C; C::f(); C::X; C::$p; try {} catch (E $e) {}",
+			[]string{"App=App", "C=App\\C", "C=App\\C", "C=App\\C", "C=App\\C", "E=App\\E"}},
+		// Member names are not symbols.
+		{"<?php $a->b; $a->c(); f(name: 1);", []string{"f=f"}},
+	}
+	for _, tt := range tests {
+		if got := resolved(t, tt.src); !reflect.DeepEqual(got, tt.names) {
+			t.Errorf("%q:\n got %q\nwant %q", tt.src, got, tt.names)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+)
+
+// Attributes returns the attributes declared on node, in source order, or
+// nil if node cannot have attributes. Attributes can be put on functions,
+// closures, class-likes and their members, enum cases and parameters.
+func Attributes(node ast.Node) []*ast.Attribute {
+	var groups []*ast.AttributeGroup
+	switch n := node.(type) {
+	case *ast.FunctionDeclStmt:
+		groups = n.Attributes
+	case *ast.ClosureExpr:
+		groups = n.Attributes
+	case *ast.ArrowFuncExpr:
+		groups = n.Attributes
+	case *ast.ClassDecl:
+		groups = n.Attributes
+	case *ast.InterfaceDecl:
+		groups = n.Attributes
+	case *ast.TraitDecl:
+		groups = n.Attributes
+	case *ast.EnumDecl:
+		groups = n.Attributes
+	case *ast.EnumCaseDecl:
+		groups = n.Attributes
+	case *ast.MethodDecl:
+		groups = n.Attributes
+	case *ast.PropertyDecl:
+		groups = n.Attributes
+	case *ast.ClassConstDecl:
+		groups = n.Attributes
+	case *ast.Param:
+		groups = n.Attributes
+	}
+
+	var attrs []*ast.Attribute
+	for _, group := range groups {
+		attrs = append(attrs, group.Attributes...)
+	}
+	return attrs
+}
+
+// FindAttribute returns the first attribute on node whose class is name,
+// e.g. "Deprecated" or "\SensitiveParameter". Names are compared once
+// resolved, so the attribute may be written with a short name imported by a
+// use statement. Like class names in PHP, they are case-insensitive.
+func FindAttribute(node ast.Node, name string) (*ast.Attribute, bool) {
+	name = strings.TrimPrefix(name, `\`)
+	for _, attr := range Attributes(node) {
+		if strings.EqualFold(strings.TrimPrefix(attr.Name.FullName(), `\`), name) {
+			return attr, true
+		}
+	}
+	return nil, false
+}
+
+// HasAttribute reports whether node has an attribute whose class is name,
+// see FindAttribute.
+func HasAttribute(node ast.Node, name string) bool {
+	_, found := FindAttribute(node, name)
+	return found
+}
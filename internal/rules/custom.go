@@ -0,0 +1,297 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/glob"
+	"github.com/codevault-llc/php-lint/internal/pattern"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+	"github.com/codevault-llc/php-lint/pkg/types"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+// customRuleFile is the layout of a file of custom rules, in YAML or JSON:
+//
+//	rules:
+//	  - id: no-concatenated-queries
+//	    message: Pass the values of $db->query() as parameters
+//	    severity: error
+//	    pattern: $db->query($A . $B) where $B is not literal
+//	    files: [src/**/*.php]
+//	  - id: no-debug-output
+//	    message: Remove debugging output
+//	    banned:
+//	      functions: [var_dump, print_r]
+//	      classes: [Legacy\Db]
+//	      methods: [Logger::debug, ->dump]
+type customRuleFile struct {
+	Rules []customRuleSpec `json:"rules" yaml:"rules"`
+}
+
+type customRuleSpec struct {
+	ID          string      `json:"id" yaml:"id"`
+	Description string      `json:"description" yaml:"description"`
+	Message     string      `json:"message" yaml:"message"`
+	Severity    string      `json:"severity" yaml:"severity"`
+	Pattern     string      `json:"pattern" yaml:"pattern"`
+	Banned      *bannedList `json:"banned" yaml:"banned"`
+	Files       []string    `json:"files" yaml:"files"`
+	Exclude     []string    `json:"exclude" yaml:"exclude"`
+	Fix         string      `json:"fix" yaml:"fix"`
+}
+
+// bannedList lists names whose use a rule reports. Methods are given as
+// Class::method for static calls, or ->method for calls on any object.
+type bannedList struct {
+	Functions []string `json:"functions" yaml:"functions"`
+	Classes   []string `json:"classes" yaml:"classes"`
+	Methods   []string `json:"methods" yaml:"methods"`
+}
+
+// CustomRule is a rule declared in a rules file rather than in Go. It
+// reports the code matching any of its patterns.
+type CustomRule struct {
+	id          string
+	description string
+	severity    protocol.DiagnosticSeverity
+	patterns    []customPattern
+	files       []string // Globs of the files checked, all if empty
+	exclude     []string // Globs of the files not checked
+	baseDir     string   // The directory globs are relative to
+	fix         string   // Template of the code replacing a match
+}
+
+type customPattern struct {
+	pattern *pattern.Pattern
+	message string
+}
+
+var severities = map[string]protocol.DiagnosticSeverity{
+	"error":   protocol.DiagnosticSeverityError,
+	"warning": protocol.DiagnosticSeverityWarning,
+	"warn":    protocol.DiagnosticSeverityWarning,
+	"info":    protocol.DiagnosticSeverityInformation,
+	"hint":    protocol.DiagnosticSeverityHint,
+}
+
+// LoadCustomRules reads the rules declared in a file, in JSON if its name
+// ends with .json and in YAML otherwise. File globs of the rules are
+// relative to baseDir, the directory of the config.
+func LoadCustomRules(filename, baseDir string) ([]Rule, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	// Unknown keys are errors, so that a misspelt setting is not ignored.
+	var file customRuleFile
+	if filepath.Ext(filename) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(&file); err == io.EOF {
+			// An empty file.
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	loaded := make([]Rule, 0, len(file.Rules))
+	for i, spec := range file.Rules {
+		rule, err := newCustomRule(spec, baseDir)
+		if err != nil {
+			if spec.ID == "" {
+				return nil, fmt.Errorf("%s: rule %d: %w", filename, i+1, err)
+			}
+			return nil, fmt.Errorf("%s: rule %s: %w", filename, spec.ID, err)
+		}
+		loaded = append(loaded, rule)
+	}
+	return loaded, nil
+}
+
+func newCustomRule(spec customRuleSpec, baseDir string) (*CustomRule, error) {
+	if spec.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	rule := &CustomRule{
+		id:          spec.ID,
+		description: spec.Description,
+		severity:    protocol.DiagnosticSeverityWarning,
+		files:       spec.Files,
+		exclude:     spec.Exclude,
+		baseDir:     baseDir,
+		fix:         spec.Fix,
+	}
+	if spec.Severity != "" {
+		severity, ok := severities[strings.ToLower(spec.Severity)]
+		if !ok {
+			return nil, fmt.Errorf("unknown severity %q, expected error, warning, info or hint", spec.Severity)
+		}
+		rule.severity = severity
+	}
+	for _, g := range append(append([]string{}, spec.Files...), spec.Exclude...) {
+		if err := glob.Validate(g); err != nil {
+			return nil, err
+		}
+	}
+
+	if (spec.Pattern == "") == (spec.Banned == nil) {
+		return nil, fmt.Errorf("exactly one of pattern and banned is required")
+	}
+	if spec.Pattern != "" {
+		if spec.Message == "" {
+			return nil, fmt.Errorf("message is required")
+		}
+		p, err := pattern.Compile(spec.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		rule.patterns = append(rule.patterns, customPattern{pattern: p, message: spec.Message})
+	} else {
+		if err := rule.addBanned(spec.Banned, spec.Message); err != nil {
+			return nil, err
+		}
+	}
+	if rule.description == "" {
+		rule.description = spec.Message
+	}
+	return rule, nil
+}
+
+// identifierName matches the names a banned list can give, such as foo,
+// Foo\Bar or \Foo\bar.
+var identifierName = regexp.MustCompile(`^\\?[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*(\\[A-Za-z_\x80-\xff][A-Za-z0-9_\x80-\xff]*)*$`)
+
+// addBanned adds a pattern for each use of the names of a banned list. The
+// message defaults to one naming what is banned.
+func (r *CustomRule) addBanned(banned *bannedList, message string) error {
+	add := func(name, kind, src, defaultMessage string) error {
+		if !identifierName.MatchString(name) {
+			return fmt.Errorf("bad %s name %q", kind, name)
+		}
+		msg := message
+		if msg == "" {
+			msg = defaultMessage
+		}
+		r.patterns = append(r.patterns, customPattern{pattern: pattern.MustCompile(src), message: msg})
+		return nil
+	}
+
+	for _, name := range banned.Functions {
+		if err := add(name, "function", name+"(...)", fmt.Sprintf("Function %s() must not be used", name)); err != nil {
+			return err
+		}
+	}
+	for _, name := range banned.Classes {
+		msg := fmt.Sprintf("Class %s must not be used", name)
+		if err := add(name, "class", "new "+name+"(...)", msg); err != nil {
+			return err
+		}
+		if err := add(name, "class", name+"::$_(...)", msg); err != nil {
+			return err
+		}
+	}
+	for _, name := range banned.Methods {
+		if method, ok := strings.CutPrefix(name, "->"); ok {
+			msg := fmt.Sprintf("Method %s() must not be used", method)
+			if err := add(method, "method", "$_->"+method+"(...)", msg); err != nil {
+				return err
+			}
+			if err := add(method, "method", "$_?->"+method+"(...)", msg); err != nil {
+				return err
+			}
+			continue
+		}
+		class, method, ok := strings.Cut(name, "::")
+		if !ok || !identifierName.MatchString(class) {
+			return fmt.Errorf("bad method %q, expected Class::method or ->method", name)
+		}
+		msg := fmt.Sprintf("Method %s::%s() must not be used", class, method)
+		if err := add(method, "method", class+"::"+method+"(...)", msg); err != nil {
+			return err
+		}
+	}
+	if len(r.patterns) == 0 {
+		return fmt.Errorf("banned lists no functions, classes or methods")
+	}
+	return nil
+}
+
+func (r *CustomRule) Name() string { return r.id }
+
+func (r *CustomRule) Description() string { return r.description }
+
+func (r *CustomRule) Check(filename string, content []byte, program *ast.Program, symbolTable *stubs.SymbolTable) []types.Issue {
+	if !r.appliesTo(filename) {
+		return nil
+	}
+	issues := []types.Issue{}
+	for _, p := range r.patterns {
+		for _, m := range p.pattern.FindAll(program) {
+			issue := types.Issue{
+				RuleName: r.id,
+				Message:  expandTemplate(p.message, m.Bindings, content),
+				Range:    m.Span,
+				Severity: r.severity,
+				Source:   "php-lint",
+			}
+			if r.fix != "" {
+				issue.Fix = []ast.Edit{{Span: m.Span, NewText: expandTemplate(r.fix, m.Bindings, content)}}
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// appliesTo reports whether the rule checks a file, given the globs of the
+// files it includes and excludes.
+func (r *CustomRule) appliesTo(filename string) bool {
+	name := filepath.ToSlash(filename)
+	if rel, err := filepath.Rel(r.baseDir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	for _, g := range r.exclude {
+		if glob.Match(g, name) {
+			return false
+		}
+	}
+	if len(r.files) == 0 {
+		return true
+	}
+	for _, g := range r.files {
+		if glob.Match(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// metavariableRef matches the metavariables a message or fix can refer to.
+var metavariableRef = regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_]*`)
+
+// expandTemplate replaces the metavariables of a message or fix with the
+// code they matched. Variables the pattern did not bind are kept.
+func expandTemplate(template string, bindings pattern.Bindings, content []byte) string {
+	return metavariableRef.ReplaceAllStringFunc(template, func(ref string) string {
+		node, ok := bindings[ref[1:]]
+		if !ok {
+			return ref
+		}
+		return string(content[node.Pos().Offset:node.End().Offset])
+	})
+}
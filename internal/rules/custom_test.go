@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/resolver"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+)
+
+func TestLoadCustomRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		rules   int
+		err     string // The start of the error
+	}{
+		{"r.yaml", "rules:\n  - id: a\n    message: m\n    banned: {functions: [f]}\n", 1, ""},
+		{"r.yaml", "", 0, ""},
+		{"r.json", `{"rules": [{"id": "a", "message": "m", "banned": {"functions": ["f"]}}]}`, 1, ""},
+		// Misspelt keys are not ignored.
+		{"r.yaml", "rules:\n  - id: a\n    mesage: m\n", 0, "r.yaml: yaml: unmarshal errors:\n  line 3: field mesage not found"},
+		{"r.yaml", "rules:\n  - id: a\n    banned: {function: [f]}\n", 0, "r.yaml: yaml: unmarshal errors:\n  line 3: field function not found"},
+		{"r.yaml", "rule: []\n", 0, "r.yaml: yaml: unmarshal errors:\n  line 1: field rule not found"},
+		{"r.json", `{"rules": [{"id": "a", "patern": "f()"}]}`, 0, `r.json: json: unknown field "patern"`},
+		{"r.json", `{"rules": [{"id": "a", "banned": {"method": ["f"]}}]}`, 0, `r.json: json: unknown field "method"`},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rules, err := LoadCustomRules(path, dir)
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), filepath.Join(dir, tt.err)) {
+				t.Errorf("%s %q: got error %v, want %s", tt.name, tt.content, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || len(rules) != tt.rules {
+			t.Errorf("%s %q: got %d rules, error %v, want %d rules", tt.name, tt.content, len(rules), err, tt.rules)
+		}
+	}
+}
+
+// checkCustom runs a custom rule over src, as the file filename, and
+// returns each issue as "text: message", with " => fix" if it has a fix.
+func checkCustom(t *testing.T, spec customRuleSpec, filename, src string) []string {
+	t.Helper()
+	rule, err := newCustomRule(spec, "/project")
+	if err != nil {
+		t.Fatalf("%s: %v", spec.ID, err)
+	}
+	src = "<?php " + src
+	program := parser.New(lexer.New(src)).ParseProgram()
+	resolver.Resolve(program)
+	got := []string{}
+	for _, issue := range rule.Check(filename, []byte(src), program, stubs.NewSymbolTable()) {
+		s := src[issue.Range.Start.Offset:issue.Range.End.Offset] + ": " + issue.Message
+		for _, edit := range issue.Fix {
+			s += " => " + edit.NewText
+		}
+		got = append(got, s)
+	}
+	return got
+}
+
+func TestCustomRuleCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		spec customRuleSpec
+		src  string
+		want []string
+	}{
+		{
+			"pattern",
+			customRuleSpec{ID: "r", Message: "Pass $B as a parameter", Pattern: "$db->query($A . $B) where $B is not literal"},
+			"$pdo->query('SELECT ' . $cols); $pdo->query('a' . 'b'); $pdo->query($sql);",
+			[]string{"$pdo->query('SELECT ' . $cols): Pass $cols as a parameter"},
+		},
+		{
+			"banned functions",
+			customRuleSpec{ID: "r", Banned: &bannedList{Functions: []string{"var_dump", "print_r"}}},
+			"namespace App; var_dump($a); \\print_r($b, true); printf('x');",
+			[]string{"var_dump($a): Function var_dump() must not be used", "\\print_r($b, true): Function print_r() must not be used"},
+		},
+		{
+			"banned classes",
+			customRuleSpec{ID: "r", Banned: &bannedList{Classes: []string{`Legacy\Db`}}},
+			`use Legacy\Db; new Db(); Db::connect(); new \Other\Db();`,
+			[]string{`new Db(): Class Legacy\Db must not be used`, `Db::connect(): Class Legacy\Db must not be used`},
+		},
+		{
+			"banned methods",
+			customRuleSpec{ID: "r", Message: "No debugging", Banned: &bannedList{Methods: []string{"Logger::debug", "->dump"}}},
+			"Logger::debug('a'); Logger::info('b'); $a->dump(); $a?->dump(); dump();",
+			[]string{"Logger::debug('a'): No debugging", "$a->dump(): No debugging", "$a?->dump(): No debugging"},
+		},
+		{
+			"fix",
+			customRuleSpec{ID: "r", Message: "Use ??", Pattern: "isset($A) ? $A : $B", Fix: "$A ?? $B"},
+			"$x = isset($a['k']) ? $a['k'] : 'none'; $y = isset($a) ? $b : 1;",
+			[]string{"isset($a['k']) ? $a['k'] : 'none': Use ?? => $a['k'] ?? 'none'"},
+		},
+		{
+			// Metavariables the pattern does not bind are kept as written.
+			"unbound",
+			customRuleSpec{ID: "r", Message: "$A and $Z", Pattern: "f($A)", Fix: "g($A, $Z)"},
+			"f(1);",
+			[]string{"f(1): 1 and $Z => g(1, $Z)"},
+		},
+	}
+	for _, tt := range tests {
+		if got := checkCustom(t, tt.spec, "/project/a.php", tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCustomRuleFiles(t *testing.T) {
+	spec := customRuleSpec{
+		ID:      "r",
+		Message: "m",
+		Banned:  &bannedList{Functions: []string{"f"}},
+		Files:   []string{"src/**/*.php"},
+		Exclude: []string{"src/vendor/**"},
+	}
+	tests := []struct {
+		filename string
+		checked  bool
+	}{
+		{"/project/src/a.php", true},
+		{"/project/src/lib/b.php", true},
+		{"src/lib/b.php", true},
+		{"/project/src/a.inc", false},
+		{"/project/tests/a.php", false},
+		{"/project/src/vendor/x/a.php", false},
+		{"/elsewhere/src/a.php", false},
+	}
+	for _, tt := range tests {
+		got := checkCustom(t, spec, tt.filename, "f();")
+		if checked := len(got) > 0; checked != tt.checked {
+			t.Errorf("%s: checked %v, want %v", tt.filename, checked, tt.checked)
+		}
+	}
+
+	// Without files, every file is checked but the excluded ones.
+	spec.Files = nil
+	if got := checkCustom(t, spec, "/project/tests/a.php", "f();"); len(got) != 1 {
+		t.Errorf("tests/a.php: got %q", got)
+	}
+	if got := checkCustom(t, spec, "/project/src/vendor/a.php", "f();"); len(got) != 0 {
+		t.Errorf("src/vendor/a.php: got %q", got)
+	}
+}
@@ -2,6 +2,8 @@ package rules
 
 import (
 	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+	"github.com/codevault-llc/php-lint/internal/token"
 	"github.com/codevault-llc/php-lint/pkg/types"
 )
 
@@ -11,10 +13,40 @@ type callExprVisitor struct {
 	check    func(node *ast.CallExpr) (*types.Issue, bool)
 }
 
-func (v *callExprVisitor) Visit(node ast.Node) {
+func (v *callExprVisitor) enter(node ast.Node, ancestors []ast.Node) ast.Action {
 	if n, ok := node.(*ast.CallExpr); ok {
 		if issue, found := v.check(n); found {
 			v.issues = append(v.issues, *issue)
 		}
 	}
-}
\ No newline at end of file
+	return ast.Continue
+}
+
+// calledFunction returns the name a call is made through when the callee is
+// a function name, e.g. "foo" in foo($x) or "Foo\bar" in \Foo\bar($x).
+// Language constructs such as eval() and exit() are not functions and are
+// not returned.
+func calledFunction(node *ast.CallExpr) (*ast.Identifier, bool) {
+	ident, ok := node.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	switch ident.Token.Kind {
+	case token.IDENT, token.NAME_QUALIFIED, token.NAME_FULLY_QUALIFIED, token.NAME_RELATIVE:
+		return ident, true
+	}
+	return nil, false
+}
+
+// lookupFunction returns the declared name of the function a call through
+// ident reaches. An unqualified call in a namespace reaches the namespaced
+// function if there is one and the global function otherwise.
+func lookupFunction(ident *ast.Identifier, symbolTable *stubs.SymbolTable) (string, bool) {
+	if declared, found := symbolTable.LookupFunction(ident.FullName()); found {
+		return declared, true
+	}
+	if ident.Fallback != "" {
+		return symbolTable.LookupFunction(ident.Fallback)
+	}
+	return "", false
+}
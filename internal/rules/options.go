@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Configurable is implemented by rules that take options from the config.
+// DefaultOptions returns a pointer to a new options struct holding the
+// defaults. Its exported fields, named by their json tags, are the options
+// the config can set, and their types are checked when it is loaded; if
+// the struct has a Validate() error method, it is called too. A
+// description tag on a field describes the option in the schema of the
+// config. WithOptions returns a copy of the rule using options, a pointer
+// to such a struct.
+type Configurable interface {
+	Rule
+	DefaultOptions() any
+	WithOptions(options any) Rule
+}
+
+// Configure returns rule set up with options, the JSON object given in the
+// config, or nil to use the defaults.
+func Configure(rule Rule, options json.RawMessage) (Rule, error) {
+	configurable, ok := rule.(Configurable)
+	if !ok {
+		if options != nil {
+			return nil, fmt.Errorf("rule %s takes no options", rule.Name())
+		}
+		return rule, nil
+	}
+
+	opts := configurable.DefaultOptions()
+	if options != nil {
+		decoder := json.NewDecoder(bytes.NewReader(options))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(opts); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name(), optionsError(err))
+		}
+	}
+	if v, ok := opts.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
+	}
+	return configurable.WithOptions(opts), nil
+}
+
+// optionsError rewords the errors of decoding options in terms of the
+// config.
+func optionsError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("option %s must be %s, not %s", typeErr.Field, typeDescription(typeErr.Type), typeErr.Value)
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("unknown option %s", field)
+	}
+	return err
+}
+
+// typeDescription names the JSON values a Go type is decoded from.
+func typeDescription(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list of " + strings.TrimPrefix(strings.TrimPrefix(typeDescription(t.Elem()), "a "), "an ") + "s"
+	}
+	return "an object"
+}
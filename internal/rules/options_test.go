@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+	"github.com/codevault-llc/php-lint/pkg/types"
+)
+
+// configurableRule is a rule with options of each type.
+type configurableRule struct {
+	options *testOptions
+}
+
+type testOptions struct {
+	Max    int      `json:"max"`
+	Ratio  float64  `json:"ratio"`
+	Strict bool     `json:"strict"`
+	Prefix string   `json:"prefix"`
+	Names  []string `json:"names"`
+}
+
+func (o *testOptions) Validate() error {
+	if o.Max < 0 {
+		return errors.New("max must not be negative")
+	}
+	return nil
+}
+
+func (r *configurableRule) Name() string        { return "configurable" }
+func (r *configurableRule) Description() string { return "" }
+func (r *configurableRule) Check(string, []byte, *ast.Program, *stubs.SymbolTable) []types.Issue {
+	return nil
+}
+func (r *configurableRule) DefaultOptions() any { return &testOptions{Max: 10, Prefix: "x"} }
+func (r *configurableRule) WithOptions(options any) Rule {
+	return &configurableRule{options: options.(*testOptions)}
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		options string // "" for none
+		want    testOptions
+		err     string
+	}{
+		{"", testOptions{Max: 10, Prefix: "x"}, ""},
+		{`{}`, testOptions{Max: 10, Prefix: "x"}, ""},
+		{`{"max": 3, "strict": true}`, testOptions{Max: 3, Prefix: "x", Strict: true}, ""},
+		{`{"ratio": 0.5, "prefix": "", "names": ["a", "b"]}`, testOptions{Max: 10, Ratio: 0.5, Names: []string{"a", "b"}}, ""},
+		// Unknown options and options of the wrong type.
+		{`{"maximum": 3}`, testOptions{}, `rule configurable: unknown option "maximum"`},
+		{`{"max": "3"}`, testOptions{}, "rule configurable: option max must be an integer, not string"},
+		{`{"max": 1.5}`, testOptions{}, "rule configurable: option max must be an integer, not number 1.5"},
+		{`{"ratio": true}`, testOptions{}, "rule configurable: option ratio must be a number, not bool"},
+		{`{"strict": 1}`, testOptions{}, "rule configurable: option strict must be a boolean, not number"},
+		{`{"prefix": ["a"]}`, testOptions{}, "rule configurable: option prefix must be a string, not array"},
+		{`{"names": "a"}`, testOptions{}, "rule configurable: option names must be a list of strings, not string"},
+		{`{"names": [1]}`, testOptions{}, "rule configurable: option names.0 must be a string, not number"},
+		// Validate is called after decoding.
+		{`{"max": -1}`, testOptions{}, "rule configurable: max must not be negative"},
+	}
+	for _, tt := range tests {
+		var options json.RawMessage
+		if tt.options != "" {
+			options = json.RawMessage(tt.options)
+		}
+		rule, err := Configure(&configurableRule{}, options)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: got error %v, want %s", tt.options, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.options, err)
+			continue
+		}
+		if got := rule.(*configurableRule).options; !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.options, *got, tt.want)
+		}
+	}
+}
+
+func TestConfigureWithoutOptions(t *testing.T) {
+	rule := &RuleNoEval{}
+	if got, err := Configure(rule, nil); err != nil || got != Rule(rule) {
+		t.Errorf("without options: got %v, %v", got, err)
+	}
+	if _, err := Configure(rule, json.RawMessage(`{}`)); err == nil || err.Error() != "rule security-no-eval takes no options" {
+		t.Errorf("with options: got error %v", err)
+	}
+}
+
+func TestUndefinedFunctionOptions(t *testing.T) {
+	rule, err := Configure(&RuleUndefinedFunction{}, json.RawMessage(`{"ignore": ["\\Apcu_Fetch"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ignore := rule.(*RuleUndefinedFunction).ignore; !reflect.DeepEqual(ignore, map[string]bool{"apcu_fetch": true}) {
+		t.Errorf("got ignore %v", ignore)
+	}
+	if _, err := Configure(&RuleUndefinedFunction{}, json.RawMessage(`{"ignore": "apcu_fetch"}`)); err == nil {
+		t.Error("a string is taken for the list of ignored functions")
+	}
+}
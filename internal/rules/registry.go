@@ -22,6 +22,12 @@ func Register(rule Rule) {
 	registry[rule.Name()] = rule
 }
 
+// Lookup returns the registered rule of the given name.
+func Lookup(name string) (Rule, bool) {
+	rule, ok := registry[name]
+	return rule, ok
+}
+
 // GetRegistered returns a slice of all registered rules.
 func GetRegistered() []Rule {
 	rules := make([]Rule, 0, len(registry))
@@ -37,4 +43,5 @@ func init() {
 	Register(&RuleNoEval{})
 	Register(&RuleNoShellExec{})
 	Register(&RuleUndefinedFunction{})
-}
\ No newline at end of file
+	Register(&RuleFunctionCase{})
+}
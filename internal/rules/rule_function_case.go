@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codevault-llc/php-lint/internal/ast"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+	"github.com/codevault-llc/php-lint/pkg/types"
+)
+
+type RuleFunctionCase struct{}
+
+func (r *RuleFunctionCase) Name() string { return "style-function-case" }
+func (r *RuleFunctionCase) Description() string {
+	return "Reports function calls whose casing differs from the function's declaration."
+}
+
+func (r *RuleFunctionCase) Check(filename string, content []byte, program *ast.Program, symbolTable *stubs.SymbolTable) []types.Issue {
+	visitor := &callExprVisitor{
+		issues:   []types.Issue{},
+		ruleName: r.Name(),
+		check: func(node *ast.CallExpr) (*types.Issue, bool) {
+			ident, ok := calledFunction(node)
+			if !ok {
+				return nil, false
+			}
+			// Only the function's own name is compared; namespace
+			// segments are spelled by the use statement or the call.
+			declared, found := lookupFunction(ident, symbolTable)
+			if !found || shortName(declared) == shortName(ident.Value) {
+				return nil, false
+			}
+
+			issue := &types.Issue{
+				RuleName: r.Name(),
+				Message:  fmt.Sprintf("Function %s() is declared as %s()", ident.Value, declared),
+				Range:    ident.Token.Span,
+				Severity: 3,
+				Source:   "php-lint",
+			}
+			return issue, true
+		},
+	}
+	ast.Inspect(program, visitor.enter)
+	return visitor.issues
+}
+
+// shortName returns the last segment of a namespaced name.
+func shortName(name string) string {
+	return name[strings.LastIndex(name, "\\")+1:]
+}
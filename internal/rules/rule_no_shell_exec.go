@@ -1,27 +1,39 @@
 package rules
 
 import (
+	"strings"
+
 	"github.com/codevault-llc/php-lint/internal/ast"
 	"github.com/codevault-llc/php-lint/internal/stubs"
-	"github.com/codevault-llc/php-lint/internal/token"
 	"github.com/codevault-llc/php-lint/pkg/types"
 )
 
 type RuleNoShellExec struct{}
 
+// shellFunctions lists the functions that run shell commands, in lowercase.
+var shellFunctions = map[string]bool{
+	"shell_exec": true, "exec": true, "passthru": true, "system": true,
+}
+
 func (r *RuleNoShellExec) Name() string { return "security-no-shell-exec" }
 
-func (r *RuleNoShellExec) Description() string { return "Disallows the use of shell_exec() and similar functions." }
+func (r *RuleNoShellExec) Description() string {
+	return "Disallows the use of shell_exec(), similar functions and backticks."
+}
 
 func (r *RuleNoShellExec) Check(filename string, content []byte, program *ast.Program, symbolTable *stubs.SymbolTable) []types.Issue {
 	visitor := &callExprVisitor{
 		ruleName: r.Name(),
 		check: func(node *ast.CallExpr) (*types.Issue, bool) {
-			dangerousFunctions := map[token.Kind]bool{
-				token.SHELL_EXEC: true, token.EXEC: true, token.PASSTHRU: true, token.SYSTEM: true,
-			}
-			if ident, ok := node.Function.(*ast.Identifier); ok {
-				if _, found := dangerousFunctions[ident.Token.Kind]; found {
+			if ident, ok := calledFunction(node); ok {
+				// Function names are case-insensitive, so System() is system().
+				// In a namespace, an unqualified call reaches the global
+				// function unless a namespaced one of that name is declared.
+				name := ident.FullName()
+				if _, found := symbolTable.LookupFunction(name); !found && ident.Fallback != "" {
+					name = ident.Fallback
+				}
+				if _, found := shellFunctions[strings.ToLower(name)]; found {
 					issue := &types.Issue{
 						RuleName: r.Name(),
 						Message:  "Execution of shell commands is a security risk",
@@ -37,6 +49,18 @@ func (r *RuleNoShellExec) Check(filename string, content []byte, program *ast.Pr
 			return nil, false
 		},
 	}
-	ast.Walk(program, visitor)
+	ast.Inspect(program, func(node ast.Node, ancestors []ast.Node) ast.Action {
+		// A command in backticks runs like one given to shell_exec().
+		if shell, ok := node.(*ast.ShellExecExpr); ok {
+			visitor.issues = append(visitor.issues, types.Issue{
+				RuleName: r.Name(),
+				Message:  "Execution of shell commands is a security risk",
+				Range:    shell.Token.Span,
+				Severity: 2,
+				Source:   "php-lint",
+			})
+		}
+		return visitor.enter(node, ancestors)
+	})
 	return visitor.issues
-}
\ No newline at end of file
+}
@@ -0,0 +1,35 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codevault-llc/php-lint/internal/lexer"
+	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/resolver"
+	"github.com/codevault-llc/php-lint/internal/stubs"
+)
+
+func TestNoShellExec(t *testing.T) {
+	tests := []struct {
+		src    string
+		issues []string // The text the issues are on
+	}{
+		{"shell_exec('ls'); System('ls'); strlen('ls');", []string{"shell_exec", "System"}},
+		{"$o = `ls $dir`; echo '`ls`';", []string{"`ls $dir`"}},
+		{"namespace App; exec('ls');", []string{"exec"}},
+	}
+	for _, tt := range tests {
+		src := "<?php " + tt.src
+		program := parser.New(lexer.New(src)).ParseProgram()
+		resolver.Resolve(program)
+		issues := (&RuleNoShellExec{}).Check("a.php", []byte(src), program, stubs.NewSymbolTable())
+		var got []string
+		for _, issue := range issues {
+			got = append(got, src[issue.Range.Start.Offset:issue.Range.End.Offset])
+		}
+		if !reflect.DeepEqual(got, tt.issues) {
+			t.Errorf("%q: issues on %q, want %q", tt.src, got, tt.issues)
+		}
+	}
+}
@@ -3,13 +3,34 @@ package rules
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/codevault-llc/php-lint/internal/ast"
 	"github.com/codevault-llc/php-lint/internal/stubs"
 	"github.com/codevault-llc/php-lint/pkg/types"
 )
 
-type RuleUndefinedFunction struct{}
+type RuleUndefinedFunction struct {
+	ignore map[string]bool // Lowercase names of the functions not reported
+}
+
+// UndefinedFunctionOptions are the options of undefined-function.
+type UndefinedFunctionOptions struct {
+	// Ignore lists functions that are never reported, such as those of an
+	// extension the stubs do not cover.
+	Ignore []string `json:"ignore" description:"Functions never reported, such as those of an extension the stubs do not cover."`
+}
+
+func (r *RuleUndefinedFunction) DefaultOptions() any { return &UndefinedFunctionOptions{} }
+
+func (r *RuleUndefinedFunction) WithOptions(options any) Rule {
+	opts := options.(*UndefinedFunctionOptions)
+	ignore := make(map[string]bool, len(opts.Ignore))
+	for _, name := range opts.Ignore {
+		ignore[strings.ToLower(strings.TrimPrefix(name, `\`))] = true
+	}
+	return &RuleUndefinedFunction{ignore: ignore}
+}
 
 func (r *RuleUndefinedFunction) Name() string { return "undefined-function" }
 func (r *RuleUndefinedFunction) Description() string {
@@ -21,8 +42,8 @@ func (r *RuleUndefinedFunction) Check(filename string, content []byte, program *
 		issues:   []types.Issue{},
 		ruleName: r.Name(),
 		check: func(node *ast.CallExpr) (*types.Issue, bool) {
-			if ident, ok := node.Function.(*ast.Identifier); ok {
-				if !symbolTable.IsFunctionDefined(ident.Value) {
+			if ident, ok := calledFunction(node); ok {
+				if _, found := lookupFunction(ident, symbolTable); !found && !r.ignored(ident) {
 					log.Printf("Undefined function %s() called", ident.Token.Lexeme)
 
 					issue := types.Issue{
@@ -40,6 +61,12 @@ func (r *RuleUndefinedFunction) Check(filename string, content []byte, program *
 			return nil, false
 		},
 	}
-	ast.Walk(program, visitor)
+	ast.Inspect(program, visitor.enter)
 	return visitor.issues
-}
\ No newline at end of file
+}
+
+// ignored reports whether a call is to a function the options ignore.
+func (r *RuleUndefinedFunction) ignored(ident *ast.Identifier) bool {
+	return r.ignore[strings.ToLower(strings.TrimPrefix(ident.FullName(), `\`))] ||
+		ident.Fallback != "" && r.ignore[strings.ToLower(ident.Fallback)]
+}
@@ -2,41 +2,119 @@ package stubs
 
 import (
 	"log"
+	"strings"
 
 	"github.com/codevault-llc/php-lint/internal/ast"
 )
 
-
+// SymbolTable records the functions and classes known to the linter.
+// Function and class names are case-insensitive in PHP, so lookups ignore
+// case; the spelling used at the declaration is kept for diagnostics.
 type SymbolTable struct {
-	functions map[string]bool
+	functions map[string]string // lowercase name -> declared name
+	classes   map[string]string // lowercase name -> declared name
+
+	// The symbols of stubs, which ClearLocalSymbols keeps, see KeepAsStubs.
+	stubFunctions map[string]string
+	stubClasses   map[string]string
 }
 
 func NewSymbolTable() *SymbolTable {
-	return &SymbolTable{functions: make(map[string]bool)}
+	return &SymbolTable{
+		functions: make(map[string]string),
+		classes:   make(map[string]string),
+	}
 }
 
 func (st *SymbolTable) AddFunction(name string) {
-	st.functions[name] = true
+	st.functions[strings.ToLower(name)] = name
 }
 
 func (st *SymbolTable) IsFunctionDefined(name string) bool {
-	_, exists := st.functions[name]
+	_, exists := st.LookupFunction(name)
+	return exists
+}
+
+// LookupFunction returns the declared spelling of the function called name.
+func (st *SymbolTable) LookupFunction(name string) (string, bool) {
+	declared, exists := st.functions[strings.ToLower(name)]
+	return declared, exists
+}
+
+func (st *SymbolTable) AddClass(name string) {
+	st.classes[strings.ToLower(name)] = name
+}
+
+func (st *SymbolTable) IsClassDefined(name string) bool {
+	_, exists := st.LookupClass(name)
 	return exists
 }
 
+// LookupClass returns the declared spelling of the class called name.
+func (st *SymbolTable) LookupClass(name string) (string, bool) {
+	declared, exists := st.classes[strings.ToLower(name)]
+	return declared, exists
+}
+
+// AddSymbolsFromAST records every function and class-like (class,
+// interface, trait or enum) declared in program, including conditional
+// declarations nested in blocks or other functions, which PHP also defines
+// globally. Names are recorded fully qualified when the program has been
+// through resolver.Resolve.
 func (st *SymbolTable) AddSymbolsFromAST(program *ast.Program) {
-	for _, stmt := range program.Stmts {
-		if funcDecl, ok := stmt.(*ast.FunctionDeclStmt); ok {
-			log.Println("Found function declaration:", funcDecl.Name.Value)
-			st.AddFunction(funcDecl.Name.Value)
+	dc := &declarationCollector{st: st}
+	ast.Inspect(program, dc.enter)
+}
+
+type declarationCollector struct {
+	st *SymbolTable
+}
+
+func (dc *declarationCollector) enter(node ast.Node, ancestors []ast.Node) ast.Action {
+	switch decl := node.(type) {
+	case *ast.FunctionDeclStmt:
+		log.Println("Found function declaration:", decl.Name.FullName())
+		dc.st.AddFunction(decl.Name.FullName())
+	case *ast.ClassDecl:
+		if decl.Name != nil { // Anonymous classes have no name
+			dc.st.AddClass(decl.Name.FullName())
 		}
+	case *ast.InterfaceDecl:
+		dc.st.AddClass(decl.Name.FullName())
+	case *ast.TraitDecl:
+		dc.st.AddClass(decl.Name.FullName())
+	case *ast.EnumDecl:
+		dc.st.AddClass(decl.Name.FullName())
 	}
+	return ast.Continue
 }
 
 func (st *SymbolTable) FunctionCount() int {
 	return len(st.functions)
 }
 
+func (st *SymbolTable) ClassCount() int {
+	return len(st.classes)
+}
+
+// KeepAsStubs marks the symbols recorded so far as those of stubs, which
+// outlive ClearLocalSymbols.
+func (st *SymbolTable) KeepAsStubs() {
+	st.stubFunctions = clone(st.functions)
+	st.stubClasses = clone(st.classes)
+}
+
+// ClearLocalSymbols forgets the symbols of the code, keeping those of the
+// stubs.
 func (st *SymbolTable) ClearLocalSymbols() {
-	st.functions = make(map[string]bool)
-}
\ No newline at end of file
+	st.functions = clone(st.stubFunctions)
+	st.classes = clone(st.stubClasses)
+}
+
+func clone(symbols map[string]string) map[string]string {
+	c := make(map[string]string, len(symbols))
+	for lower, name := range symbols {
+		c[lower] = name
+	}
+	return c
+}
@@ -1,6 +1,9 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Span represents a region of source code.
 type Span struct {
@@ -11,11 +14,10 @@ type Span struct {
 // Pos represents a position in a file.
 type Pos struct {
 	Line   int // 1-based line number
-	Col    int // 1-based column number
+	Col    int // 1-based column number, counted in characters (runes)
 	Offset int // 0-based byte offset
 }
 
-
 // Kind is the type of a token.
 type Kind string
 
@@ -25,66 +27,389 @@ const (
 	EOF     = "EOF"
 
 	// Literals
-	IDENT  = "IDENT"
-	STRING = "STRING"
-
-	// Keywords & Dangerous Functions
-	ECHO       = "ECHO"
-	EVAL       = "EVAL"
-	EXIT       = "EXIT"
-	DIE        = "DIE"
+	IDENT    = "IDENT"
+	VARIABLE = "VARIABLE" // $name
+	INTEGER  = "INTEGER"  // 42, 0x2A, 0o52, 052, 0b101010, 1_000
+	FLOAT    = "FLOAT"    // 4.2, .5, 1e10, 1_000.5
+	STRING   = "STRING"   // 'text', "text", <<<'EOT' nowdoc
+	// INTERPOLATED_STRING is a double-quoted string or heredoc that contains
+	// variables. Its Parts hold the literal and interpolated pieces.
+	INTERPOLATED_STRING = "INTERPOLATED_STRING"
+	// SHELL_EXEC is a shell command in backticks, whose Parts are those of
+	// an interpolated string.
 	SHELL_EXEC = "SHELL_EXEC"
-	EXEC       = "EXEC"
-	PASSTHRU   = "PASSTHRU"
-	SYSTEM     = "SYSTEM"
-	FUNCTION   = "FUNCTION"
+
+	// Namespaced names are single tokens, as in PHP 8, so that reserved
+	// words may appear as segments, e.g. App\List.
+	NAME_QUALIFIED       = "NAME_QUALIFIED"       // Foo\Bar
+	NAME_FULLY_QUALIFIED = "NAME_FULLY_QUALIFIED" // \Foo\Bar
+	NAME_RELATIVE        = "NAME_RELATIVE"        // namespace\Foo
+
+	// Language constructs
+	ECHO     = "ECHO"
+	EVAL     = "EVAL"
+	EXIT     = "EXIT"
+	DIE      = "DIE"
+	FUNCTION = "FUNCTION"
+
+	// Keywords
+	ABSTRACT      = "ABSTRACT"
+	ARRAY         = "ARRAY"
+	AS            = "AS"
+	BREAK         = "BREAK"
+	CALLABLE      = "CALLABLE"
+	CASE          = "CASE"
+	CATCH         = "CATCH"
+	CLASS         = "CLASS"
+	CLONE         = "CLONE"
+	CONST         = "CONST"
+	CONTINUE      = "CONTINUE"
+	DECLARE       = "DECLARE"
+	DEFAULT       = "DEFAULT"
+	DO            = "DO"
+	ELSE          = "ELSE"
+	ELSEIF        = "ELSEIF"
+	EMPTY         = "EMPTY"
+	ENDDECLARE    = "ENDDECLARE"
+	ENDFOR        = "ENDFOR"
+	ENDFOREACH    = "ENDFOREACH"
+	ENDIF         = "ENDIF"
+	ENDSWITCH     = "ENDSWITCH"
+	ENDWHILE      = "ENDWHILE"
+	ENUM          = "ENUM"
+	EXTENDS       = "EXTENDS"
+	FINAL         = "FINAL"
+	FINALLY       = "FINALLY"
+	FN            = "FN"
+	FOR           = "FOR"
+	FOREACH       = "FOREACH"
+	GLOBAL        = "GLOBAL"
+	GOTO          = "GOTO"
+	HALT_COMPILER = "HALT_COMPILER"
+	IF            = "IF"
+	IMPLEMENTS    = "IMPLEMENTS"
+	INCLUDE       = "INCLUDE"
+	INCLUDE_ONCE  = "INCLUDE_ONCE"
+	INSTANCEOF    = "INSTANCEOF"
+	INSTEADOF     = "INSTEADOF"
+	INTERFACE     = "INTERFACE"
+	ISSET         = "ISSET"
+	LIST          = "LIST"
+	LOGICAL_AND   = "AND"
+	LOGICAL_OR    = "OR"
+	LOGICAL_XOR   = "XOR"
+	MATCH         = "MATCH"
+	NAMESPACE     = "NAMESPACE"
+	NEW           = "NEW"
+	PRINT         = "PRINT"
+	PRIVATE       = "PRIVATE"
+	PROTECTED     = "PROTECTED"
+	PUBLIC        = "PUBLIC"
+	READONLY      = "READONLY"
+	REQUIRE       = "REQUIRE"
+	REQUIRE_ONCE  = "REQUIRE_ONCE"
+	RETURN        = "RETURN"
+	STATIC        = "STATIC"
+	SWITCH        = "SWITCH"
+	THROW         = "THROW"
+	TRAIT         = "TRAIT"
+	TRY           = "TRY"
+	UNSET         = "UNSET"
+	USE           = "USE"
+	VAR           = "VAR"
+	WHILE         = "WHILE"
+	YIELD         = "YIELD"
+	YIELD_FROM    = "YIELD_FROM" // yield from
+
+	// Magic constants
+	MAGIC_CLASS     = "__CLASS__"
+	MAGIC_DIR       = "__DIR__"
+	MAGIC_FILE      = "__FILE__"
+	MAGIC_FUNCTION  = "__FUNCTION__"
+	MAGIC_LINE      = "__LINE__"
+	MAGIC_METHOD    = "__METHOD__"
+	MAGIC_NAMESPACE = "__NAMESPACE__"
+	MAGIC_TRAIT     = "__TRAIT__"
+	MAGIC_PROPERTY  = "__PROPERTY__"
+
+	// Casts. The lexeme keeps the original spelling, e.g. "(integer)".
+	INT_CAST    = "(int)"
+	FLOAT_CAST  = "(float)"
+	STRING_CAST = "(string)"
+	BOOL_CAST   = "(bool)"
+	ARRAY_CAST  = "(array)"
+	OBJECT_CAST = "(object)"
+	UNSET_CAST  = "(unset)"
+
+	// Arithmetic & string operators
+	PLUS     = "+"
+	MINUS    = "-"
+	ASTERISK = "*"
+	SLASH    = "/"
+	PERCENT  = "%"
+	POW      = "**"
+	DOT      = "."
+
+	// Assignment operators
+	ASSIGN          = "="
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	MUL_ASSIGN      = "*="
+	DIV_ASSIGN      = "/="
+	MOD_ASSIGN      = "%="
+	POW_ASSIGN      = "**="
+	CONCAT_ASSIGN   = ".="
+	AND_ASSIGN      = "&="
+	OR_ASSIGN       = "|="
+	XOR_ASSIGN      = "^="
+	SL_ASSIGN       = "<<="
+	SR_ASSIGN       = ">>="
+	COALESCE_ASSIGN = "??="
+
+	// Comparison operators. "<>" is lexed as NOT_EQ.
+	EQ            = "=="
+	NOT_EQ        = "!="
+	IDENTICAL     = "==="
+	NOT_IDENTICAL = "!=="
+	LT            = "<"
+	GT            = ">"
+	LT_EQ         = "<="
+	GT_EQ         = ">="
+	SPACESHIP     = "<=>"
+
+	// Logical, bitwise and other operators
+	BOOLEAN_AND    = "&&"
+	BOOLEAN_OR     = "||"
+	BANG           = "!"
+	AMPERSAND      = "&"
+	PIPE           = "|"
+	CARET          = "^"
+	TILDE          = "~"
+	SL             = "<<"
+	SR             = ">>"
+	INC            = "++"
+	DEC            = "--"
+	COALESCE       = "??"
+	QUESTION       = "?"
+	AT             = "@"
+	DOLLAR         = "$"
+	ARROW          = "->"
+	NULLSAFE_ARROW = "?->"
+	DOUBLE_ARROW   = "=>"
+	DOUBLE_COLON   = "::"
+	ELLIPSIS       = "..."
+	NS_SEPARATOR   = "\\"
 
 	// Delimiters
 	SEMICOLON = ";"
+	COLON     = ":"
 	LPAREN    = "("
 	RPAREN    = ")"
 	COMMA     = ","
 	RBRACE    = "}"
 	LBRACE    = "{"
-	SLASH     = "/"
+	LBRACKET  = "["
+	RBRACKET  = "]"
+	ATTRIBUTE = "#["
 
 	// Misc
-	WHITESPACE = "WHITESPACE"
-	COMMENT    = "COMMENT"
-	LINE_COMMENT = "LINE_COMMENT"
+	WHITESPACE    = "WHITESPACE"
+	LINE_COMMENT  = "LINE_COMMENT"
 	BLOCK_COMMENT = "BLOCK_COMMENT"
+	DOC_COMMENT   = "DOC_COMMENT" // A "/** ... */" block comment
 
 	// PHP Tags
-	OPEN_TAG  = "<?php"
-	CLOSE_TAG = "?>"
+	OPEN_TAG           = "<?php"
+	OPEN_TAG_WITH_ECHO = "<?="
+	CLOSE_TAG          = "?>"
+	INLINE_HTML        = "INLINE_HTML" // Text outside of <?php ... ?>
 )
 
 // Token represents a lexical token.
 type Token struct {
 	Kind   Kind
 	Lexeme string
-	Span   Span 
+	Span   Span
+
+	// Parts is set for STRING, INTERPOLATED_STRING and SHELL_EXEC tokens. A
+	// plain string has a single literal part holding its unescaped value.
+	Parts []StringPart
+
+	// Leading and Trailing hold the whitespace and comments around the
+	// token. They are only set by a lexer created with lexer.WithTrivia.
+	// Trailing trivia runs up to and including the end of the token's line;
+	// everything after that belongs to the next token's Leading trivia.
+	Leading, Trailing []Trivia
+}
+
+// Trivia is a piece of source text without meaning to the grammar: a run of
+// whitespace (WHITESPACE) or a comment (LINE_COMMENT or BLOCK_COMMENT).
+type Trivia struct {
+	Kind Kind
+	Text string
+	Span Span
+}
+
+// FullText returns the token's source text together with its trivia.
+func (t Token) FullText() string {
+	var out strings.Builder
+	t.WriteFullText(&out)
+	return out.String()
+}
+
+// WriteFullText writes the token's source text, including its trivia, to
+// out.
+func (t Token) WriteFullText(out *strings.Builder) {
+	for _, trivia := range t.Leading {
+		out.WriteString(trivia.Text)
+	}
+	out.WriteString(t.Lexeme)
+	for _, trivia := range t.Trailing {
+		out.WriteString(trivia.Text)
+	}
+}
+
+// PartKind identifies the kind of a piece of a string literal.
+type PartKind int
+
+const (
+	// PartLiteral is literal text. Value holds the unescaped text.
+	PartLiteral PartKind = iota
+	// PartVar is a simple interpolation such as "$name", "$name[0]" or
+	// "$name->prop". Value holds its source, starting with the "$".
+	PartVar
+	// PartExpr is a complex "{$expr}" interpolation. Value holds the source
+	// between the braces, starting with the "$".
+	PartExpr
+	// PartDollarBrace is a "${expr}" interpolation. Value holds the source
+	// between the braces.
+	PartDollarBrace
+)
+
+// StringPart is a literal or interpolated piece of a string token.
+type StringPart struct {
+	Kind  PartKind
+	Value string
+	Span  Span
 }
 
 func (t Token) String() string {
 	return fmt.Sprintf("Token{Kind: %s, Lexeme: \"%s\"}", t.Kind, t.Lexeme)
 }
 
+// keywords maps the lowercase spelling of every reserved word to its kind.
 var keywords = map[string]Kind{
-	"echo":       ECHO,
-	"eval":       EVAL,
-	"exit":       EXIT,
-	"die":        DIE,
-	"shell_exec": SHELL_EXEC,
-	"exec":       EXEC,
-	"passthru":   PASSTHRU,
-	"system":     SYSTEM,
+	"echo":     ECHO,
+	"eval":     EVAL,
+	"exit":     EXIT,
+	"die":      DIE,
+	"function": FUNCTION,
+
+	"abstract":        ABSTRACT,
+	"and":             LOGICAL_AND,
+	"array":           ARRAY,
+	"as":              AS,
+	"break":           BREAK,
+	"callable":        CALLABLE,
+	"case":            CASE,
+	"catch":           CATCH,
+	"class":           CLASS,
+	"clone":           CLONE,
+	"const":           CONST,
+	"continue":        CONTINUE,
+	"declare":         DECLARE,
+	"default":         DEFAULT,
+	"do":              DO,
+	"else":            ELSE,
+	"elseif":          ELSEIF,
+	"empty":           EMPTY,
+	"enddeclare":      ENDDECLARE,
+	"endfor":          ENDFOR,
+	"endforeach":      ENDFOREACH,
+	"endif":           ENDIF,
+	"endswitch":       ENDSWITCH,
+	"endwhile":        ENDWHILE,
+	"extends":         EXTENDS,
+	"final":           FINAL,
+	"finally":         FINALLY,
+	"fn":              FN,
+	"for":             FOR,
+	"foreach":         FOREACH,
+	"global":          GLOBAL,
+	"goto":            GOTO,
+	"__halt_compiler": HALT_COMPILER,
+	"if":              IF,
+	"implements":      IMPLEMENTS,
+	"include":         INCLUDE,
+	"include_once":    INCLUDE_ONCE,
+	"instanceof":      INSTANCEOF,
+	"insteadof":       INSTEADOF,
+	"interface":       INTERFACE,
+	"isset":           ISSET,
+	"list":            LIST,
+	"match":           MATCH,
+	"namespace":       NAMESPACE,
+	"new":             NEW,
+	"or":              LOGICAL_OR,
+	"print":           PRINT,
+	"private":         PRIVATE,
+	"protected":       PROTECTED,
+	"public":          PUBLIC,
+	"readonly":        READONLY,
+	"require":         REQUIRE,
+	"require_once":    REQUIRE_ONCE,
+	"return":          RETURN,
+	"static":          STATIC,
+	"switch":          SWITCH,
+	"throw":           THROW,
+	"trait":           TRAIT,
+	"try":             TRY,
+	"unset":           UNSET,
+	"use":             USE,
+	"var":             VAR,
+	"while":           WHILE,
+	"xor":             LOGICAL_XOR,
+	"yield":           YIELD,
+
+	"__class__":     MAGIC_CLASS,
+	"__dir__":       MAGIC_DIR,
+	"__file__":      MAGIC_FILE,
+	"__function__":  MAGIC_FUNCTION,
+	"__line__":      MAGIC_LINE,
+	"__method__":    MAGIC_METHOD,
+	"__namespace__": MAGIC_NAMESPACE,
+	"__trait__":     MAGIC_TRAIT,
+	"__property__":  MAGIC_PROPERTY,
+}
+
+// casts maps the type names accepted inside a cast to their token kind.
+var casts = map[string]Kind{
+	"int":     INT_CAST,
+	"integer": INT_CAST,
+	"bool":    BOOL_CAST,
+	"boolean": BOOL_CAST,
+	"float":   FLOAT_CAST,
+	"double":  FLOAT_CAST,
+	"real":    FLOAT_CAST,
+	"string":  STRING_CAST,
+	"binary":  STRING_CAST,
+	"array":   ARRAY_CAST,
+	"object":  OBJECT_CAST,
+	"unset":   UNSET_CAST,
 }
 
 // LookupIdent checks the keywords table to see if a given identifier is a keyword.
+// Keywords are case-insensitive in PHP, so "ECHO" and "Echo" are both ECHO.
 func LookupIdent(ident string) Kind {
-	if tok, ok := keywords[ident]; ok {
+	if tok, ok := keywords[strings.ToLower(ident)]; ok {
 		return tok
 	}
 	return IDENT
-}
\ No newline at end of file
+}
+
+// LookupCast returns the cast token kind for the type name found between
+// the parentheses of a cast, e.g. "integer" in "(integer)".
+func LookupCast(name string) (Kind, bool) {
+	kind, ok := casts[name]
+	return kind, ok
+}
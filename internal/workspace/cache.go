@@ -9,6 +9,7 @@ import (
 	"github.com/codevault-llc/php-lint/internal/ast"
 	"github.com/codevault-llc/php-lint/internal/lexer"
 	"github.com/codevault-llc/php-lint/internal/parser"
+	"github.com/codevault-llc/php-lint/internal/resolver"
 	"github.com/codevault-llc/php-lint/internal/stubs"
 	"github.com/rs/zerolog"
 )
@@ -25,16 +26,19 @@ type Workspace struct {
 	logger      zerolog.Logger
 	cache       map[string]CacheEntry
 	symbolTable *stubs.SymbolTable
-	mu          sync.RWMutex // To protect concurrent access to cache and symbols
+	version     lexer.Version // The PHP version files are parsed for
+	mu          sync.RWMutex  // To protect concurrent access to cache and symbols
 }
 
-// New creates and initializes a new workspace for the given root directory.
-func New(rootDir string, stubsTable *stubs.SymbolTable, logger zerolog.Logger) *Workspace {
+// New creates and initializes a new workspace for the given root directory,
+// whose files are written for the given PHP version.
+func New(rootDir string, stubsTable *stubs.SymbolTable, version lexer.Version, logger zerolog.Logger) *Workspace {
 	return &Workspace{
 		rootDir:     rootDir,
 		logger:      logger,
 		cache:       make(map[string]CacheEntry),
 		symbolTable: stubsTable, // Start with stubs (WordPress, etc.)
+		version:     version,
 	}
 }
 
@@ -86,9 +90,10 @@ func (w *Workspace) GetSymbolTable() *stubs.SymbolTable {
 
 // updateCacheEntry is an internal helper to parse and cache a file.
 func (w *Workspace) updateCacheEntry(path string, content []byte, modTime time.Time) {
-	lxr := lexer.New(string(content))
+	lxr := lexer.New(string(content), lexer.WithVersion(w.version))
 	psr := parser.New(lxr)
 	program := psr.ParseProgram()
+	resolver.Resolve(program)
 	w.cache[path] = CacheEntry{
 		AST:     program,
 		ModTime: modTime,
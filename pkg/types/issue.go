@@ -1,15 +1,21 @@
 package types
 
 import (
+	"github.com/codevault-llc/php-lint/internal/ast"
 	"github.com/codevault-llc/php-lint/internal/token"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 type Issue struct {
+	File     string
 	RuleName string
 	Message  string
 	Range    token.Span
 
+	// Fix holds the edits to the file that fix the issue, if the rule can
+	// fix it.
+	Fix []ast.Edit
+
 	// LSP Information
 	Severity protocol.DiagnosticSeverity
 	Source   string